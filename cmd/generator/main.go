@@ -43,9 +43,16 @@ func main() {
 
 func runCli(module string, tableSchema []generator.Table) {
 	selectedTables := getTableSelection(tableSchema)
-	selectedActions := []string{"create", "get", "update", "list", "delete", "exists", "model", "routes", "test_helper"}
+	selectedActions := []string{"create", "get", "update", "list", "delete", "exists", "model", "routes", "test_helper", "csv"}
 
-	actionMap := getActionMap()
+	var renderOptions []generator.RenderOption
+	if getYesNoInput("Resolve foreign keys with batched loaders? (y/n): ") {
+		renderOptions = append(renderOptions, generator.WithForeignKeyLoaders(tableSchema))
+	}
+
+	renderOptions = append(renderOptions, getJSONFilterOptions(selectedTables)...)
+
+	actionMap := getActionMap(renderOptions)
 
 	writeFileIfNotExist("internal/schema_test.go", []byte(generator.GetSchemaTest()))
 
@@ -136,6 +143,71 @@ func getUserInput(prompt string) []string {
 	return strings.Split(input, ",")
 }
 
+func getYesNoInput(prompt string) bool {
+	input := normalizeInput(getUserInput(prompt))
+
+	return len(input) > 0 && (input[0] == "y" || input[0] == "yes")
+}
+
+// getJSONFilterOptions asks which sub-fields of each JSON column should be exposed as search filters.
+func getJSONFilterOptions(tables []generator.Table) []generator.RenderOption {
+	var options []generator.RenderOption
+
+	for _, table := range tables {
+		for _, field := range table.Fields {
+			if field.DataType != generator.SQLJson {
+				continue
+			}
+
+			filters := getJSONFilters(table.Name, field.Name)
+			if len(filters) > 0 {
+				options = append(options, generator.WithJSONFilters(table.Name, filters...))
+			}
+		}
+	}
+
+	return options
+}
+
+// getJSONFilters asks for the filters of a JSON column until they are all valid. Paths keep their case since
+// JSON keys are case sensitive.
+func getJSONFilters(tableName, columnName string) []generator.JSONFilter {
+	for {
+		input := getUserInput(fmt.Sprintf(
+			"Search filters for %s.%s as path:type, e.g. theme:string,notifications.email:bool (none to skip): ",
+			tableName, columnName))
+
+		filters, err := parseJSONFilters(columnName, input)
+		if err == nil {
+			return filters
+		}
+
+		fmt.Println(err)
+	}
+}
+
+func parseJSONFilters(columnName string, input []string) ([]generator.JSONFilter, error) {
+	filters := []generator.JSONFilter{}
+
+	for _, filter := range input {
+		filter = strings.TrimSpace(filter)
+		if filter == "" || strings.EqualFold(filter, "none") {
+			continue
+		}
+
+		path, goType, _ := strings.Cut(filter, ":")
+		jsonFilter := generator.JSONFilter{Column: columnName, Path: path, GoType: strings.ToLower(goType)}
+
+		if err := jsonFilter.Validate(); err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, jsonFilter)
+	}
+
+	return filters, nil
+}
+
 func normalizeInput(inputs []string) []string {
 	for i := range inputs {
 		inputs[i] = strings.ToLower(strings.TrimSpace(inputs[i]))
@@ -182,7 +254,7 @@ type actionConfig struct {
 }
 
 // nolint: funlen
-func getActionMap() map[string]actionConfig {
+func getActionMap(renderOptions []generator.RenderOption) map[string]actionConfig {
 	return map[string]actionConfig{
 		"create": {
 			generator.RenderCreateTemplate,
@@ -193,7 +265,9 @@ func getActionMap() map[string]actionConfig {
 			},
 		},
 		"get": {
-			generator.RenderGetOneTemplate,
+			func(module string, table generator.Table) ([]byte, []byte, error) {
+				return generator.RenderGetOneTemplate(module, table, renderOptions...)
+			},
 			func(table generator.Table) (string, string) {
 				singularModelName := strings.ToLower(strings.TrimSuffix(table.Name, "s"))
 				return fmt.Sprintf("internal/%s/get_%s_by_id.go", strings.ToLower(table.Name), singularModelName),
@@ -209,7 +283,9 @@ func getActionMap() map[string]actionConfig {
 			},
 		},
 		"list": {
-			generator.RenderSearchTemplate,
+			func(module string, table generator.Table) ([]byte, []byte, error) {
+				return generator.RenderSearchTemplate(module, table, renderOptions...)
+			},
 			func(table generator.Table) (string, string) {
 				return fmt.Sprintf("internal/%s/search_%s.go", strings.ToLower(table.Name), strings.ToLower(table.Name)),
 					fmt.Sprintf("internal/%s/search_%s_test.go", strings.ToLower(table.Name), strings.ToLower(table.Name))
@@ -225,7 +301,7 @@ func getActionMap() map[string]actionConfig {
 		},
 		"model": {
 			func(module string, table generator.Table) ([]byte, []byte, error) {
-				modelTemplate, err := generator.RenderModelTemplate(module, table)
+				modelTemplate, err := generator.RenderModelTemplate(module, table, renderOptions...)
 				return modelTemplate, nil, err
 			},
 			func(table generator.Table) (string, string) {
@@ -250,6 +326,13 @@ func getActionMap() map[string]actionConfig {
 				return fmt.Sprintf("internal/%s/test_helpers.go", strings.ToLower(table.Name)), ""
 			},
 		},
+		"csv": {
+			generator.RenderCSVTemplate,
+			func(table generator.Table) (string, string) {
+				return fmt.Sprintf("internal/%s/import_export_%s.go", strings.ToLower(table.Name), strings.ToLower(table.Name)),
+					fmt.Sprintf("internal/%s/import_export_%s_test.go", strings.ToLower(table.Name), strings.ToLower(table.Name))
+			},
+		},
 		"exists": {
 			func(module string, table generator.Table) ([]byte, []byte, error) {
 				existsTemplate, err := generator.RenderExistsTemplate(module, table)
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/generator"
+	"github.com/gurch101/gowebutils/pkg/parser"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// compares the database at DB_FILEPATH with db/migrations and exits with status 1 if they differ.
+func main() {
+	migrationsDir := flag.String("migrations", "db/migrations", "directory containing the .up.sql migrations")
+	asJSON := flag.Bool("json", false, "print the diff as JSON")
+	write := flag.Bool("write", false, "write a corrective migration to the migrations directory")
+	flag.Parse()
+
+	db := dbutils.OpenDBPool(parser.ParseEnvStringPanic("DB_FILEPATH"))
+	defer db.Close()
+
+	diff, err := generator.DetectSchemaDrift(*migrationsDir, db)
+	if err != nil {
+		panic(err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(diff); err != nil {
+			panic(err)
+		}
+	} else {
+		fmt.Print(diff)
+	}
+
+	if diff.IsEmpty() {
+		return
+	}
+
+	if *write {
+		filename, err := diff.WriteCorrectiveMigration(*migrationsDir)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Wrote %s. Review it before applying.\n", filename)
+	}
+
+	os.Exit(1)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fixtures"
+	"github.com/gurch101/gowebutils/pkg/parser"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// seeds the database at DB_FILEPATH with one or more fixture sets, e.g. go run ./cmd/seed dev demo.
+func main() {
+	dir := flag.String("dir", "db/fixtures", "directory containing one sub-directory per fixture set")
+	flag.Parse()
+
+	sets := flag.Args()
+	if len(sets) == 0 {
+		sets = []string{"dev"}
+	}
+
+	db := dbutils.OpenDBPool(parser.ParseEnvStringPanic("DB_FILEPATH"))
+	defer db.Close()
+
+	refs, err := fixtures.Seed(context.Background(), db, os.DirFS(*dir), sets...)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Seeded %d rows from %v\n", len(refs), sets)
+}
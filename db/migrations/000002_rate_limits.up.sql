@@ -0,0 +1,8 @@
+-- rate limit buckets shared by every process using the database. tat is the theoretical
+-- arrival time of the next request in unix nanoseconds.
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key TEXT PRIMARY KEY,
+    tat INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limits_tat_idx ON rate_limits (tat);
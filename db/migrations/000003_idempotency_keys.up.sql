@@ -0,0 +1,12 @@
+-- responses to requests with an Idempotency-Key header. status is NULL while the first request
+-- is in progress. expires_at is in unix nanoseconds.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    status INTEGER,
+    header TEXT,
+    body BLOB,
+    expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -14,45 +14,27 @@ type envelope map[string]interface{}
 
 /*
 func (c *TenantController) UploadFile(w http.ResponseWriter, r *http.Request) {
-	// Parse the multipart form, 10 << 20 specifies a maximum upload of 10 MB files.
-	r.ParseMultipartForm(10 << 20)
-
-	// Retrieve the file from the form data
-	file, handler, err := r.FormFile("file")
-	if err != nil {
-		fmt.Println("Error Retrieving the File")
-		fmt.Println(err)
-		return
-	}
-	defer file.Close()
-
-	location, err := c.app.FileService.UploadFile(handler.Filename, file)
+	// Stream the files to the bucket without buffering them.
+	uploaded, err := httputils.StreamUploads(w, r, c.app.FileService, httputils.UploadConfig{
+		MaxFileBytes:        10 << 20,
+		AllowedContentTypes: []string{"application/pdf", "image/*"},
+	})
 	if err != nil {
-		fmt.Println("Error uploading file:", err)
+		httputils.HandleErrorResponse(w, r, err)
 		return
 	}
 
-	slog.Info("upload file", "location", location)
+	slog.Info("upload files", "files", uploaded)
 }
 
 func (c *TenantController) DownloadFile(w http.ResponseWriter, r *http.Request) {
-	contents, err := c.app.FileService.DownloadFile("1.pdf")
-	if err != nil {
-		fmt.Println("Error downloading file:", err)
-		return
-	}
-	w.Header().Set("Content-Disposition", "attachment; filename=1.pdf")
-	w.Header().Set("Content-Type", "application/pdf")
-	_, err = w.Write(contents)
-	if err != nil {
-		fmt.Println("Error writing file:", err)
-		return
-	}
+	// Stream the file, supporting conditional and range requests.
+	httputils.ServeFile(w, r, c.app.FileService, "1.pdf", httputils.WithAttachment(""))
 }
 
 func (c *TenantController) DeleteFile(w http.ResponseWriter, r *http.Request) {
 
-	err := c.app.FileService.DeleteFile("1.pdf")
+	err := c.app.FileService.DeleteFileContext(r.Context(), "1.pdf")
 	if err != nil {
 		fmt.Println("Error deleting file:", err)
 		return
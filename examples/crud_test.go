@@ -564,3 +564,21 @@ func TestSearchTenantsHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchTenantsHandler_QueryCount(t *testing.T) {
+	t.Parallel()
+
+	app := testutils.NewTestApp(t)
+	defer app.Close()
+
+	searchTenantsController := NewSearchTenantController(app.App)
+	app.TestRouter.Get("/tenants", searchTenantsController.SearchTenantsHandler)
+
+	app.AssertMaxQueries(t, 1, func() {
+		rr := app.MakeRequest(testutils.CreateGetRequest(t, "/tenants"))
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status 200 OK, got %d", rr.Code)
+		}
+	})
+}
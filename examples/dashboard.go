@@ -16,7 +16,7 @@ func NewDashboardController(app *app.App) *DashboardController {
 }
 
 func (c *DashboardController) Dashboard(w http.ResponseWriter, r *http.Request) {
-	err := c.app.RenderTemplate(w, "index.go.tmpl", nil)
+	err := c.app.RenderTemplateContext(r.Context(), w, "index.go.tmpl", nil)
 
 	if err != nil {
 		httputils.ServerErrorResponse(w, r, err)
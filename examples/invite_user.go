@@ -56,7 +56,7 @@ func (c *InviteUserController) InviteUser(w http.ResponseWriter, r *http.Request
 }
 
 func InviteUser(
-	_ context.Context,
+	ctx context.Context,
 	mailer mailutils.Mailer,
 	hostName string,
 	tenantID int64,
@@ -73,7 +73,7 @@ func InviteUser(
 		return err
 	}
 
-	mailer.Send(email, "invite.go.tmpl", map[string]string{
+	mailutils.SendAfterCommit(ctx, mailer, email, "invite.go.tmpl", map[string]string{
 		"URL": fmt.Sprintf("%s/register?code=%s", hostName, inviteToken),
 	})
 
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"embed"
@@ -10,6 +11,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"reflect"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-chi/chi/v5"
@@ -17,20 +20,32 @@ import (
 	"github.com/gurch101/gowebutils/pkg/authutils"
 	"github.com/gurch101/gowebutils/pkg/dbutils"
 	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/health"
 	"github.com/gurch101/gowebutils/pkg/httputils"
 	"github.com/gurch101/gowebutils/pkg/mailutils"
+	"github.com/gurch101/gowebutils/pkg/metrics"
 	"github.com/gurch101/gowebutils/pkg/parser"
+	"github.com/gurch101/gowebutils/pkg/realtime"
 	"github.com/gurch101/gowebutils/pkg/templateutils"
+	"github.com/gurch101/gowebutils/pkg/tracing"
 )
 
 const compressionLevel = 5
 
+// idempotencyCleanupInterval is how often expired idempotency keys are deleted.
+const idempotencyCleanupInterval = 10 * time.Minute
+
+// tracingShutdownTimeout is how long the remaining spans can take to export when the server stops.
+const tracingShutdownTimeout = 5 * time.Second
+
 var (
 	ErrEmailTemplatesNotFound  = errors.New("email templates not found")
+	ErrTemplateNotFound        = errors.New("template not found")
 	ErrGetUserExistsFnNotFound = errors.New(
 		"getUserExistsFn not found. This function is required for session validation")
 	ErrGetOrCreateUserFnNotFound = errors.New(
 		"getOrCreateUserFn not found. This function is required for user sign in and sign up")
+	ErrConflictingRouteCORS = errors.New("routes with the same path have different CORS configurations")
 )
 
 // App is the main application struct.
@@ -39,6 +54,8 @@ type App struct {
 	Cache             *Cache
 	FileService       fsutils.FileService
 	Mailer            mailutils.Mailer
+	Realtime          *realtime.Hub
+	Health            *health.Checker
 	htmlTemplateMap   map[string]*template.Template
 	getUserExistsFn   func(ctx context.Context, db dbutils.DB, user authutils.User) bool
 	getOrCreateUserFn func(
@@ -49,6 +66,13 @@ type App struct {
 	router            *chi.Mux
 	sessionMiddleware func(next http.Handler) http.Handler
 	sessionManager    *scs.SessionManager
+	rateLimitStore    httputils.RateLimitStore
+	idempotencyStore  httputils.IdempotencyStore
+	cors              *httputils.CORSConfig
+	preflightCORS     map[string]*httputils.CORSConfig
+	tracer            *tracing.Tracer
+	tls               *httputils.TLSConfig
+	secure            bool
 	config            *config
 }
 
@@ -115,7 +139,18 @@ type options struct {
 		db dbutils.DB,
 		email string,
 		tokenPayload map[string]any) (authutils.User, error)
-	router *chi.Mux
+	router               *chi.Mux
+	errorResponseOptions []httputils.ErrorResponseOption
+	rateLimitStore       httputils.RateLimitStore
+	idempotencyStore     httputils.IdempotencyStore
+	cors                 *httputils.CORSConfig
+	securityHeaders      *httputils.SecurityHeadersConfig
+	csrf                 *authutils.CSRFConfig
+	realtime             *realtime.Hub
+	metrics              *MetricsConfig
+	tracing              *tracing.Config
+	health               *health.Config
+	tls                  *httputils.TLSConfig
 }
 
 type Option func(options *options) error
@@ -195,16 +230,130 @@ func WithRouter(router *chi.Mux) Option {
 	}
 }
 
-func initDefaultRouter(sessionManager *scs.SessionManager) *chi.Mux {
+// WithErrorResponses configures how the httputils response helpers render errors.
+func WithErrorResponses(opts ...httputils.ErrorResponseOption) Option {
+	return func(options *options) error {
+		options.errorResponseOptions = opts
+
+		return nil
+	}
+}
+
+// WithRateLimitStore sets the store used by the rate limit middleware. Defaults to a SQLite store when
+// RATE_LIMIT_STORE is sqlite and an in-memory store otherwise.
+func WithRateLimitStore(store httputils.RateLimitStore) Option {
+	return func(options *options) error {
+		options.rateLimitStore = store
+
+		return nil
+	}
+}
+
+// WithIdempotencyStore sets the store used by routes added with WithIdempotency. Defaults to an in-memory store, or
+// a SQLite store on the idempotency_keys table when IDEMPOTENCY_STORE is sqlite.
+func WithIdempotencyStore(store httputils.IdempotencyStore) Option {
+	return func(options *options) error {
+		options.idempotencyStore = store
+
+		return nil
+	}
+}
+
+// WithCORS handles cross-origin requests to the routes added with AddPublicRoute and AddProtectedRoute* with the
+// given configuration. Static files aren't covered. Defaults to the configuration set by the CORS_* env vars.
+// See httputils.CORSConfigFromEnv.
+func WithCORS(config httputils.CORSConfig) Option {
+	return func(options *options) error {
+		options.cors = &config
+
+		return nil
+	}
+}
+
+// WithSecurityHeaders sets the security headers sent with every response by the default router. Defaults to the
+// configuration set by the SECURITY_HEADERS_ENABLED, HSTS_*, CSP* and *_POLICY env vars.
+// See httputils.SecurityHeadersConfigFromEnv.
+func WithSecurityHeaders(config httputils.SecurityHeadersConfig) Option {
+	return func(options *options) error {
+		options.securityHeaders = &config
+
+		return nil
+	}
+}
+
+// WithCSRF configures the CSRF protection of the default router. Enabled with the default configuration unless
+// CSRF_ENABLED is false.
+func WithCSRF(config authutils.CSRFConfig) Option {
+	return func(options *options) error {
+		options.csrf = &config
+
+		return nil
+	}
+}
+
+// WithRealtime configures the hub that pushes messages to browsers. Configured by the REALTIME_* environment
+// variables by default. Register App.Realtime.ServeSSE or App.Realtime.ServeWebSocket as a protected route to use it.
+func WithRealtime(config realtime.Config) Option {
+	return func(options *options) error {
+		options.realtime = realtime.NewHub(config)
+
+		return nil
+	}
+}
+
+// WithTracing records spans for requests, queries, file operations and emails and exports them with the given
+// configuration. Configured by the TRACING_* env vars by default. See tracing.ConfigFromEnv.
+func WithTracing(config tracing.Config) Option {
+	return func(options *options) error {
+		options.tracing = &config
+
+		return nil
+	}
+}
+
+// WithTLS serves HTTPS with the certificate in config. Configured by the TLS_* env vars when the certificate file
+// exists. See httputils.TLSConfigFromEnv.
+func WithTLS(config httputils.TLSConfig) Option {
+	return func(options *options) error {
+		options.tls = &config
+
+		return nil
+	}
+}
+
+func initDefaultRouter(
+	sessionManager *scs.SessionManager,
+	rateLimitStore httputils.RateLimitStore,
+	securityHeaders *httputils.SecurityHeadersConfig,
+	csrf *authutils.CSRFConfig,
+) *chi.Mux {
+	trustedProxies, err := httputils.ParseTrustedProxies(parser.ParseEnvString("TRUSTED_PROXIES", ""))
+	if err != nil {
+		panic(err)
+	}
+
 	router := chi.NewRouter()
-	router.Use(middleware.RealIP)
+	router.Use(httputils.RealIPMiddleware(trustedProxies))
 	router.Use(middleware.RequestID)
-	router.Use(httputils.RateLimitMiddleware)
+	router.Use(httputils.TracingMiddleware)
+	router.Use(httputils.MetricsMiddleware)
+
+	if securityHeaders != nil {
+		router.Use(httputils.NewSecurityHeadersMiddleware(*securityHeaders))
+	}
+
+	router.Use(httputils.RateLimitMiddlewareWithStore(rateLimitStore))
 	router.Use(middleware.RequestLogger(httputils.NewSlogLogFormatter(slog.Default())))
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.Compress(compressionLevel))
+	router.Use(httputils.QueryTrackingMiddleware)
+	router.Use(httputils.LoaderMiddleware)
 	router.Use(sessionManager.LoadAndSave)
 
+	if csrf != nil {
+		router.Use(authutils.NewCSRFMiddleware(sessionManager, *csrf))
+	}
+
 	return router
 }
 
@@ -242,39 +391,258 @@ func NewApp(opts ...Option) (*App, error) {
 		options.mailer = mailer
 	}
 
-	sessionManager := authutils.CreateSessionManager(options.db.WriteDB())
+	if options.tls == nil {
+		if config, ok := httputils.TLSConfigFromEnv(); ok {
+			options.tls = &config
+		}
+	}
+
+	// set SECURE_MODE when a proxy in front of the app terminates TLS
+	secure := parser.ParseEnvBool("SECURE_MODE", options.tls != nil)
+
+	sessionManager := authutils.CreateSessionManager(options.db.WriteDB(), secure)
 	sessionMiddleware := authutils.GetSessionMiddleware(sessionManager, options.getUserExistsFn, options.db)
 
+	if options.errorResponseOptions != nil {
+		httputils.ConfigureErrorResponses(options.errorResponseOptions...)
+	}
+
+	if options.rateLimitStore == nil {
+		if parser.ParseEnvString("RATE_LIMIT_STORE", "memory") == "sqlite" {
+			options.rateLimitStore = httputils.NewSQLiteRateLimitStore(options.db.WriteDB())
+		} else {
+			options.rateLimitStore = httputils.NewMemoryRateLimitStore()
+		}
+	}
+
+	if options.idempotencyStore == nil {
+		if parser.ParseEnvString("IDEMPOTENCY_STORE", "memory") == "sqlite" {
+			options.idempotencyStore = httputils.NewSQLiteIdempotencyStore(options.db.WriteDB())
+		} else {
+			options.idempotencyStore = httputils.NewMemoryIdempotencyStore()
+		}
+	}
+
+	options.db.RegisterMetrics(metrics.Default)
+
+	if options.realtime == nil {
+		options.realtime = realtime.NewHub(realtime.ConfigFromEnv())
+	}
+
+	if options.cors == nil {
+		if config, ok := httputils.CORSConfigFromEnv(); ok {
+			options.cors = &config
+		}
+	}
+
+	if options.securityHeaders == nil {
+		if config, ok := httputils.SecurityHeadersConfigFromEnv(); ok {
+			options.securityHeaders = &config
+		}
+	}
+
+	if options.securityHeaders != nil && !secure {
+		// HSTS would keep browsers from connecting over plain HTTP
+		options.securityHeaders.HSTSMaxAge = 0
+	}
+
+	if options.tracing == nil {
+		if config, ok := tracing.ConfigFromEnv(); ok {
+			options.tracing = &config
+		}
+	}
+
+	var tracer *tracing.Tracer
+	if options.tracing != nil {
+		tracer = tracing.NewTracer(*options.tracing)
+		tracing.SetDefault(tracer)
+	}
+
+	if options.health == nil {
+		config := health.ConfigFromEnv()
+		options.health = &config
+	}
+
+	if options.metrics == nil {
+		if config, ok := MetricsConfigFromEnv(); ok {
+			options.metrics = &config
+		}
+	}
+
+	if options.csrf == nil && parser.ParseEnvBool("CSRF_ENABLED", true) {
+		options.csrf = &authutils.CSRFConfig{}
+	}
+
+	if options.csrf != nil && options.securityHeaders != nil && options.securityHeaders.CSPReportPath != "" {
+		// browsers don't send CSRF tokens with violation reports
+		options.csrf.ExemptPaths = append(options.csrf.ExemptPaths, options.securityHeaders.CSPReportPath)
+	}
+
 	if options.router == nil {
-		options.router = initDefaultRouter(sessionManager)
+		options.router = initDefaultRouter(sessionManager, options.rateLimitStore, options.securityHeaders, options.csrf)
+	}
+
+	if options.securityHeaders != nil && options.securityHeaders.CSPReportPath != "" {
+		options.router.Post(options.securityHeaders.CSPReportPath, httputils.CSPReportHandler)
 	}
 
 	fileServer := http.FileServer(http.Dir("./web/static/"))
 	options.router.Handle("/static/*", http.StripPrefix("/static", fileServer))
 
-	return &App{
+	app := &App{
 		db:                options.db,
 		Cache:             NewCache(),
 		FileService:       options.fileService,
 		Mailer:            options.mailer,
+		Realtime:          options.realtime,
+		Health:            health.NewChecker(*options.health),
 		htmlTemplateMap:   options.htmlTemplateMap,
 		getUserExistsFn:   options.getUserExistsFn,
 		getOrCreateUserFn: options.getOrCreateUserFn,
 		router:            options.router,
 		sessionMiddleware: sessionMiddleware,
 		sessionManager:    sessionManager,
+		rateLimitStore:    options.rateLimitStore,
+		idempotencyStore:  options.idempotencyStore,
+		cors:              options.cors,
+		preflightCORS:     make(map[string]*httputils.CORSConfig),
+		tracer:            tracer,
+		tls:               options.tls,
+		secure:            secure,
 		config:            newConfig(),
-	}, nil
+	}
+
+	app.registerHealthChecks()
+	app.mountHealth()
+
+	if options.metrics != nil {
+		app.mountMetrics(*options.metrics)
+	}
+
+	return app, nil
+}
+
+// RouteOption configures a single route.
+type RouteOption func(*routeOptions)
+
+type routeOptions struct {
+	unitOfWork        bool
+	rateLimitPolicies []httputils.RateLimitPolicy
+	idempotent        bool
+	cors              *httputils.CORSConfig
+}
+
+// WithUnitOfWork runs each request to the route in a single transaction that is committed
+// on 2xx/3xx responses and rolled back otherwise. See httputils.UnitOfWorkMiddleware.
+func WithUnitOfWork() RouteOption {
+	return func(o *routeOptions) {
+		o.unitOfWork = true
+	}
+}
+
+// WithRateLimit limits requests to the route with the given policies in addition to the global limit.
+// Policies keyed by user, such as authutils.RateLimitByUser, only apply to protected routes.
+func WithRateLimit(policies ...httputils.RateLimitPolicy) RouteOption {
+	return func(o *routeOptions) {
+		o.rateLimitPolicies = append(o.rateLimitPolicies, policies...)
+	}
+}
+
+// WithIdempotency replays the stored response to retried requests with the same Idempotency-Key header
+// instead of running the handler again. Keys are scoped to the signed in user, so it only applies to
+// protected routes. See httputils.NewIdempotencyMiddleware.
+func WithIdempotency() RouteOption {
+	return func(o *routeOptions) {
+		o.idempotent = true
+	}
+}
+
+// WithRouteCORS handles cross-origin requests to the route with the given configuration instead of the
+// one set by WithCORS. Preflight requests are answered per path, so every route with the same path must have
+// the same configuration; adding one with a different configuration panics with ErrConflictingRouteCORS.
+func WithRouteCORS(config httputils.CORSConfig) RouteOption {
+	return func(o *routeOptions) {
+		o.cors = &config
+	}
+}
+
+func newRouteOptions(opts []RouteOption) *routeOptions {
+	options := &routeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+// routeMiddleware returns the middleware enabled by the given route options.
+func (a *App) routeMiddleware(options *routeOptions) []func(http.Handler) http.Handler {
+	var routeMiddleware []func(http.Handler) http.Handler
+
+	if len(options.rateLimitPolicies) > 0 {
+		routeMiddleware = append(routeMiddleware, httputils.NewRateLimitMiddleware(a.rateLimitStore, options.rateLimitPolicies...))
+	}
+
+	// responses are stored after the unit of work is committed and released, since the write pool has a
+	// single connection
+	if options.idempotent {
+		routeMiddleware = append(routeMiddleware, httputils.NewIdempotencyMiddleware(a.idempotencyStore, httputils.IdempotencyConfig{
+			Scope: authutils.IdempotencyScopeByUser,
+		}))
+	}
+
+	if options.unitOfWork {
+		routeMiddleware = append(routeMiddleware, httputils.UnitOfWorkMiddleware(a.db))
+	}
+
+	return routeMiddleware
+}
+
+// corsMiddleware returns the CORS middleware of a route and registers a handler for the preflight requests of
+// its path, which don't carry credentials and so must not go through the session middleware.
+func (a *App) corsMiddleware(path string, options *routeOptions) []func(http.Handler) http.Handler {
+	config := a.cors
+	if options.cors != nil {
+		config = options.cors
+	}
+
+	if config == nil {
+		return nil
+	}
+
+	cors := httputils.NewCORSMiddleware(*config)
+
+	if registered, ok := a.preflightCORS[path]; ok {
+		if !reflect.DeepEqual(*registered, *config) {
+			panic(fmt.Errorf("%w: %s", ErrConflictingRouteCORS, path))
+		}
+
+		return []func(http.Handler) http.Handler{cors}
+	}
+
+	a.preflightCORS[path] = config
+
+	a.router.With(cors).Options(path, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return []func(http.Handler) http.Handler{cors}
 }
 
 // AddProtectedRoute adds a route that requires a valid session cookie or jwt to the App.
-func (a *App) AddProtectedRoute(method, path string, handler http.HandlerFunc) {
-	a.router.With(a.sessionMiddleware, middleware.NoCache).Method(method, path, handler)
+func (a *App) AddProtectedRoute(method, path string, handler http.HandlerFunc, opts ...RouteOption) {
+	options := newRouteOptions(opts)
+
+	allMiddleware := a.corsMiddleware(path, options)
+	allMiddleware = append(allMiddleware, a.sessionMiddleware, middleware.NoCache)
+	allMiddleware = append(allMiddleware, a.routeMiddleware(options)...)
+
+	a.router.With(allMiddleware...).Method(method, path, handler)
 }
 
 // AddProtectedRouteWithMiddleware adds a route with the given middleware to the App.
 func (a *App) AddProtectedRouteWithMiddleware(method, path string, handler http.HandlerFunc, otherMiddleware ...func(http.Handler) http.Handler) {
-	allMiddleware := make([]func(http.Handler) http.Handler, 0)
+	allMiddleware := a.corsMiddleware(path, &routeOptions{})
 	allMiddleware = append(allMiddleware, a.sessionMiddleware)
 	allMiddleware = append(allMiddleware, middleware.NoCache)
 	allMiddleware = append(allMiddleware, otherMiddleware...)
@@ -283,16 +651,20 @@ func (a *App) AddProtectedRouteWithMiddleware(method, path string, handler http.
 }
 
 func (a *App) AddProtectedRouteWithPermission(method, path string, handler http.HandlerFunc, permission string) {
-	a.router.With(
-		a.sessionMiddleware,
-		middleware.NoCache,
-		authutils.RequirePermission(permission),
-	).Method(method, path, handler)
+	allMiddleware := a.corsMiddleware(path, &routeOptions{})
+	allMiddleware = append(allMiddleware, a.sessionMiddleware, middleware.NoCache, authutils.RequirePermission(permission))
+
+	a.router.With(allMiddleware...).Method(method, path, handler)
 }
 
 // AddPublicRoute adds a route that does not require a valid session cookie or jwt to the App.
-func (a *App) AddPublicRoute(method, path string, handler http.HandlerFunc) {
-	a.router.Method(method, path, handler)
+func (a *App) AddPublicRoute(method, path string, handler http.HandlerFunc, opts ...RouteOption) {
+	options := newRouteOptions(opts)
+
+	allMiddleware := a.corsMiddleware(path, options)
+	allMiddleware = append(allMiddleware, a.routeMiddleware(options)...)
+
+	a.router.With(allMiddleware...).Method(method, path, handler)
 }
 
 // GetEnvVarString returns the value of the environment variable with the given key.
@@ -317,16 +689,66 @@ func (a *App) Close() {
 	a.db.Close()
 }
 
-// RenderTemplate renders an HTML template with the given name and data.
+// RenderTemplate renders an HTML template with the given name and data without a request, so the cspNonce,
+// csrfToken and csrfField functions return empty strings.
+//
+// Deprecated: Use RenderTemplateContext with the request context. Inline scripts and styles rendered without a
+// nonce are blocked by the default Content-Security-Policy.
 func (a *App) RenderTemplate(wr io.Writer, name string, data any) error {
-	err := a.htmlTemplateMap[name].ExecuteTemplate(wr, name, data)
+	return a.RenderTemplateContext(context.Background(), wr, name, data)
+}
+
+// RenderTemplateContext renders an HTML template with the given name and data. Templates can use the cspNonce,
+// csrfToken and csrfField functions to read the Content-Security-Policy nonce and CSRF token of the request
+// in ctx. Nothing is written to wr if the template fails.
+func (a *App) RenderTemplateContext(ctx context.Context, wr io.Writer, name string, data any) error {
+	ctx, span := tracing.Start(ctx, "template.render", tracing.WithAttributes(slog.String("template.name", name)))
+	defer span.End()
+
+	err := a.renderTemplate(ctx, wr, name, data)
+	span.RecordError(err)
+
+	return err
+}
+
+func (a *App) renderTemplate(ctx context.Context, wr io.Writer, name string, data any) error {
+	tmpl, ok := a.htmlTemplateMap[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	tmpl, err := templateutils.WithFuncs(tmpl, template.FuncMap{
+		templateutils.CSPNonceFunc:  func() string { return httputils.CSPNonce(ctx) },
+		templateutils.CSRFTokenFunc: func() string { return authutils.CSRFToken(ctx) },
+		templateutils.CSRFFieldFunc: func() template.HTML { return authutils.CSRFField(ctx) },
+	})
+	if err != nil {
+		return err
+	}
+
+	// render to a buffer so that session changes made by the template, such as creating the CSRF token,
+	// are saved before the response is written
+	var buf bytes.Buffer
+
+	err = tmpl.ExecuteTemplate(&buf, name, data)
 	if err != nil {
 		return fmt.Errorf("error executing template: %w", err)
 	}
 
+	_, err = buf.WriteTo(wr)
+	if err != nil {
+		return fmt.Errorf("error writing template: %w", err)
+	}
+
 	return nil
 }
 
+// SecureMode returns true if the app is served over HTTPS, either by the app itself or by a proxy in front of it.
+// Cookies set by the app are only sent over HTTPS in secure mode.
+func (a *App) SecureMode() bool {
+	return a.secure
+}
+
 func (a *App) DB() *dbutils.DBPool {
 	return a.db
 }
@@ -340,14 +762,40 @@ func (a *App) Start() error {
 				authutils.User, error,
 			) {
 				return a.getOrCreateUserFn(ctx, a.DB(), email, inviteTokenPayload)
-			})
-		a.AddPublicRoute("GET", "/login", oidcController.LoginHandler)
-		a.AddPublicRoute("GET", "/register", oidcController.RegisterHandler)
-		a.AddPublicRoute("GET", "/auth/callback", oidcController.AuthCallback)
+			}, a.secure)
+		var authRouteOptions []RouteOption
+		if policy, ok := httputils.AuthRateLimitPolicy(); ok {
+			authRouteOptions = append(authRouteOptions, WithRateLimit(policy))
+		}
+
+		a.AddPublicRoute("GET", "/login", oidcController.LoginHandler, authRouteOptions...)
+		a.AddPublicRoute("GET", "/register", oidcController.RegisterHandler, authRouteOptions...)
+		a.AddPublicRoute("GET", "/auth/callback", oidcController.AuthCallback, authRouteOptions...)
 		a.AddProtectedRoute("GET", "/logout", oidcController.LogoutHandler)
 	}
 
-	err := httputils.ServeHTTP(a.router, logger)
+	httputils.StartIdempotencyCleanup(context.Background(), a.idempotencyStore, idempotencyCleanupInterval)
+
+	serverOptions := []httputils.ServerOption{
+		httputils.WithOnShutdownSignal(a.Health.Shutdown),
+		httputils.WithOnShutdown(a.Realtime.Close),
+	}
+
+	if a.tls != nil {
+		serverOptions = append(serverOptions, httputils.WithTLS(*a.tls))
+	}
+
+	err := httputils.ServeHTTP(a.router, logger, serverOptions...)
+
+	if a.tracer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+
+		if shutdownErr := a.tracer.Shutdown(ctx); shutdownErr != nil {
+			slog.Error("failed to export remaining spans", "error", shutdownErr)
+		}
+	}
+
 	if err != nil {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
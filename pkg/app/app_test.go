@@ -0,0 +1,145 @@
+package app_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gurch101/gowebutils/pkg/app"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestCORSRoutes(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	router := chi.NewRouter()
+
+	testApp, err := app.NewApp(
+		app.WithDB(db),
+		app.WithRouter(router),
+		app.WithCORS(httputils.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	testApp.AddProtectedRoute(http.MethodPatch, "/api/users/{id}", handler)
+	testApp.AddPublicRoute(http.MethodGet, "/api/public", handler,
+		app.WithRouteCORS(httputils.CORSConfig{AllowedOrigins: []string{"https://partner.example.com"}}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		origin      string
+		preflight   bool
+		status      int
+		allowOrigin string
+	}{
+		{
+			name:        "preflight to a protected route doesn't require a session",
+			method:      http.MethodOptions,
+			path:        "/api/users/1",
+			origin:      "https://app.example.com",
+			preflight:   true,
+			status:      http.StatusNoContent,
+			allowOrigin: "https://app.example.com",
+		},
+		{
+			name:        "route configuration",
+			method:      http.MethodGet,
+			path:        "/api/public",
+			origin:      "https://partner.example.com",
+			status:      http.StatusOK,
+			allowOrigin: "https://partner.example.com",
+		},
+		{
+			name:   "route configuration replaces the app configuration",
+			method: http.MethodGet,
+			path:   "/api/public",
+			origin: "https://app.example.com",
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Origin", tt.origin)
+
+			if tt.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, r)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("expected Access-Control-Allow-Origin %q, got %q", tt.allowOrigin, got)
+			}
+		})
+	}
+}
+
+func TestCORSRoutesWithSamePath(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	router := chi.NewRouter()
+
+	testApp, err := app.NewApp(
+		app.WithDB(db),
+		app.WithRouter(router),
+		app.WithCORS(httputils.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	partner := httputils.CORSConfig{AllowedOrigins: []string{"https://partner.example.com"}}
+
+	testApp.AddPublicRoute(http.MethodGet, "/api/products", handler, app.WithRouteCORS(partner))
+	testApp.AddPublicRoute(http.MethodPost, "/api/products", handler, app.WithRouteCORS(partner))
+
+	r := httptest.NewRequest(http.MethodOptions, "/api/products", nil)
+	r.Header.Set("Origin", "https://partner.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, r)
+
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://partner.example.com" {
+		t.Errorf("expected the route configuration to answer the preflight, got %q", got)
+	}
+
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, app.ErrConflictingRouteCORS) {
+			t.Errorf("expected a panic with ErrConflictingRouteCORS, got %v", err)
+		}
+	}()
+
+	testApp.AddProtectedRoute(http.MethodDelete, "/api/products", handler)
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+
+	"github.com/gurch101/gowebutils/pkg/metrics"
 )
 
 type Cache struct {
@@ -26,6 +28,14 @@ var (
 	ErrContextDone = errors.New("context canceled or timed out")
 )
 
+//nolint:gochecknoglobals
+var (
+	cacheHits = metrics.Default.NewCounter("cache_hits_total",
+		"Number of cache lookups that returned a value.").WithLabelValues()
+	cacheMisses = metrics.Default.NewCounter("cache_misses_total",
+		"Number of cache lookups that didn't find a key or whose lazy initialization failed.").WithLabelValues()
+)
+
 func NewCache() *Cache {
 	return &Cache{
 		store: make(map[any]*entry),
@@ -60,6 +70,8 @@ func (c *Cache) Get(key any) (any, error) {
 	c.mu.RUnlock()
 
 	if !ok {
+		cacheMisses.Inc()
+
 		return nil, ErrKeyNotFound
 	}
 
@@ -85,9 +97,13 @@ func (c *Cache) Get(key any) (any, error) {
 	}
 
 	if cacheEntry.err != nil {
+		cacheMisses.Inc()
+
 		return nil, cacheEntry.err
 	}
 
+	cacheHits.Inc()
+
 	return cacheEntry.value, nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gurch101/gowebutils/pkg/app"
+	"github.com/gurch101/gowebutils/pkg/metrics"
 )
 
 func TestPutAndGet(t *testing.T) {
@@ -100,6 +101,30 @@ func TestLazyInitError(t *testing.T) {
 	}
 }
 
+func TestLazyInitErrorCountsAsMiss(t *testing.T) {
+	hits := metrics.Default.NewCounter("cache_hits_total", "").WithLabelValues()
+	misses := metrics.Default.NewCounter("cache_misses_total", "").WithLabelValues()
+	c := app.NewCache()
+
+	_ = c.Put("fail", func() (any, error) {
+		//nolint:err113
+		return nil, errors.New("fail init")
+	})
+
+	hitsBefore, missesBefore := hits.Value(), misses.Value()
+
+	_, _ = c.Get("fail")
+	_, _ = c.Get("fail")
+
+	if got := hits.Value() - hitsBefore; got != 0 {
+		t.Errorf("expected no hits, got %v", got)
+	}
+
+	if got := misses.Value() - missesBefore; got != 2 {
+		t.Errorf("expected 2 misses, got %v", got)
+	}
+}
+
 func TestConcurrentLazyInit(t *testing.T) {
 	c := app.NewCache()
 	key := "concurrent"
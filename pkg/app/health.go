@@ -0,0 +1,82 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gurch101/gowebutils/pkg/authutils"
+	"github.com/gurch101/gowebutils/pkg/health"
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+const (
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+)
+
+const defaultMigrationsDir = "db/migrations"
+
+const defaultMinFreeDiskMB = 100
+
+// WithHealth configures the checks served at /healthz and /readyz. Configured by the HEALTH_* env vars by default.
+// See health.ConfigFromEnv.
+func WithHealth(config health.Config) Option {
+	return func(options *options) error {
+		options.health = &config
+
+		return nil
+	}
+}
+
+// registerHealthChecks adds readiness checks for the dependencies the app was created with.
+func (a *App) registerHealthChecks() {
+	a.Health.AddReadinessCheck("db.read", health.PingDB(a.db.ReadDB()))
+	a.Health.AddReadinessCheck("db.write", health.WritableDB(a.db.WriteDB()))
+
+	migrationsDir := parser.ParseEnvString("MIGRATIONS_DIR", defaultMigrationsDir)
+	if _, err := os.Stat(migrationsDir); err == nil {
+		a.Health.AddReadinessCheck("migrations", health.MigrationsApplied(a.db.ReadDB(), migrationsDir))
+	}
+
+	if a.FileService != nil {
+		a.Health.AddReadinessCheck("files", health.FileServiceReachable(a.FileService))
+	}
+
+	if host := parser.ParseEnvString("SMTP_HOST", ""); host != "" {
+		addr := net.JoinHostPort(host, parser.ParseEnvStringPanic("SMTP_PORT"))
+		a.Health.AddReadinessCheck("smtp", health.SMTPDial(addr))
+	}
+
+	if dbFilePath := parser.ParseEnvString("DB_FILEPATH", ""); dbFilePath != "" {
+		minFreeMB, err := parser.ParseEnvInt("HEALTH_MIN_FREE_DISK_MB", defaultMinFreeDiskMB)
+		if err != nil {
+			panic(err)
+		}
+
+		//nolint: gosec
+		a.Health.AddReadinessCheck("disk", health.DiskSpace(filepath.Dir(dbFilePath), uint64(minFreeMB)<<20))
+	}
+}
+
+// mountHealth adds the public liveness and readiness routes. Only signed in admins see the result of each check.
+func (a *App) mountHealth() {
+	a.AddPublicRoute(http.MethodGet, livenessPath, a.Health.LivenessHandler(a.isAdminRequest))
+	a.AddPublicRoute(http.MethodGet, readinessPath, a.Health.ReadinessHandler(a.isAdminRequest))
+}
+
+// isAdminRequest returns true if r has the session of an admin.
+func (a *App) isAdminRequest(r *http.Request) bool {
+	// probes don't send cookies, so don't load sessions for them
+	if _, err := r.Cookie(a.sessionManager.Cookie.Name); err != nil {
+		return false
+	}
+
+	user, ok := a.sessionManager.Get(r.Context(), "user").(authutils.User)
+	if !ok || !user.IsAdmin {
+		return false
+	}
+
+	return a.getUserExistsFn == nil || a.getUserExistsFn(r.Context(), a.db, user)
+}
@@ -0,0 +1,69 @@
+package app_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gurch101/gowebutils/pkg/app"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/health"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestHealthRoutes(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	router := chi.NewRouter()
+
+	testApp, err := app.NewApp(
+		app.WithDB(db),
+		app.WithRouter(router),
+		app.WithFileService(testutils.NewMockFileService()),
+		app.WithHealth(health.Config{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, readiness := testApp.Health.Names()
+	for _, name := range []string{"db.read", "db.write", "files"} {
+		if !slices.Contains(readiness, name) {
+			t.Errorf("expected a %s readiness check, got %v", name, readiness)
+		}
+	}
+
+	get := func(path string) (int, health.Report) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var report health.Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+
+		return w.Code, report
+	}
+
+	if status, report := get("/readyz"); status != http.StatusOK || report.Checks != nil {
+		t.Errorf("expected a 200 without details, got %d %+v", status, report)
+	}
+
+	testApp.Health.AddReadinessCheck("queue", func(context.Context) error { return errors.New("down") })
+
+	if status, report := get("/readyz"); status != http.StatusServiceUnavailable || report.Checks != nil {
+		t.Errorf("expected a 503 without details, got %d %+v", status, report)
+	}
+
+	if status, _ := get("/healthz"); status != http.StatusOK {
+		t.Errorf("expected liveness to pass, got %d", status)
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/authutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/metrics"
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+const defaultMetricsPath = "/metrics"
+
+// MetricsConfig configures the route that serves metrics in the Prometheus text format.
+type MetricsConfig struct {
+	// Path of the route. Defaults to /metrics.
+	Path string
+	// Token is the bearer token that scrapers must send. Without a token, only signed in admins can read metrics.
+	Token string
+	// Registry to serve. Defaults to metrics.Default, which holds the built-in instrumentation.
+	Registry *metrics.Registry
+}
+
+// MetricsConfigFromEnv returns the metrics configuration set by the METRICS_* env vars. ok is false unless
+// METRICS_ENABLED is true.
+func MetricsConfigFromEnv() (MetricsConfig, bool) {
+	if !parser.ParseEnvBool("METRICS_ENABLED", false) {
+		return MetricsConfig{}, false
+	}
+
+	return MetricsConfig{
+		Path:     parser.ParseEnvString("METRICS_PATH", defaultMetricsPath),
+		Token:    parser.ParseEnvString("METRICS_TOKEN", ""),
+		Registry: nil,
+	}, true
+}
+
+// WithMetrics mounts a protected route that serves metrics. Mounted with the configuration set by the METRICS_*
+// env vars when METRICS_ENABLED is true. See MetricsConfigFromEnv.
+func WithMetrics(config MetricsConfig) Option {
+	return func(options *options) error {
+		options.metrics = &config
+
+		return nil
+	}
+}
+
+func (a *App) mountMetrics(config MetricsConfig) {
+	if config.Path == "" {
+		config.Path = defaultMetricsPath
+	}
+
+	if config.Registry == nil {
+		config.Registry = metrics.Default
+	}
+
+	handler := config.Registry.Handler().ServeHTTP
+
+	if config.Token == "" {
+		a.AddProtectedRouteWithMiddleware(http.MethodGet, config.Path, handler, authutils.IsAdmin)
+
+		return
+	}
+
+	a.AddPublicRoute(http.MethodGet, config.Path, requireBearerToken(config.Token, handler))
+}
+
+// requireBearerToken responds with 401 Unauthorized unless the request has the given bearer token.
+func requireBearerToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actual, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(actual), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			httputils.UnauthorizedResponse(w, r)
+
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,82 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gurch101/gowebutils/pkg/app"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/metrics"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestMetricsRoute(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	registry := metrics.NewRegistry()
+	registry.NewCounter("jobs_total", "").WithLabelValues().Inc()
+
+	router := chi.NewRouter()
+
+	_, err := app.NewApp(
+		app.WithDB(db),
+		app.WithRouter(router),
+		app.WithMetrics(app.MetricsConfig{Path: "/internal/metrics", Token: "secret", Registry: registry}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		status        int
+	}{
+		{
+			name:          "valid token",
+			path:          "/internal/metrics",
+			authorization: "Bearer secret",
+			status:        http.StatusOK,
+		},
+		{
+			name:          "invalid token",
+			path:          "/internal/metrics",
+			authorization: "Bearer wrong",
+			status:        http.StatusUnauthorized,
+		},
+		{
+			name:   "missing token",
+			path:   "/internal/metrics",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, r)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			if tt.status == http.StatusOK && !strings.Contains(rr.Body.String(), "jobs_total 1\n") {
+				t.Errorf("unexpected body %s", rr.Body.String())
+			}
+		})
+	}
+}
@@ -29,3 +29,10 @@ func GetUserFromContext(ctx context.Context) User {
 
 	return user
 }
+
+// UserFromContext retrieves the User struct from the request context and reports whether there is one.
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userContextKey).(User)
+
+	return user, ok
+}
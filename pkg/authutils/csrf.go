@@ -0,0 +1,203 @@
+package authutils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+// ErrCSRFTokenMissing is returned when a request that changes state doesn't include a CSRF token.
+var ErrCSRFTokenMissing = errors.New("missing CSRF token")
+
+// ErrCSRFTokenInvalid is returned when a request's CSRF token doesn't match the session's token.
+var ErrCSRFTokenInvalid = errors.New("invalid CSRF token")
+
+const (
+	csrfSessionKey   = "csrf_token"
+	csrfContextKey   = contextKey("csrf")
+	csrfTokenBytes   = 32
+	defaultCSRFField = "csrf_token"
+	// maxCSRFFormBytes is the size of the form bodies read for the token. Larger forms must send the header.
+	maxCSRFFormBytes = 1_048_576
+)
+
+// DefaultCSRFHeader is the header SPAs send the CSRF token in.
+const DefaultCSRFHeader = "X-CSRF-Token"
+
+// CSRFConfig configures NewCSRFMiddleware.
+type CSRFConfig struct {
+	// HeaderName is the request header holding the token. Defaults to X-CSRF-Token.
+	HeaderName string
+	// FieldName is the form field holding the token. Defaults to csrf_token.
+	FieldName string
+	// CookieName is the cookie the token is exposed in so that JavaScript can copy it to HeaderName.
+	// Defaults to csrf_token.
+	CookieName string
+	// ExemptPaths are paths that aren't checked, such as webhooks.
+	ExemptPaths []string
+}
+
+type csrf struct {
+	sessionManager *scs.SessionManager
+	config         CSRFConfig
+}
+
+// NewCSRFMiddleware protects session-authenticated requests from cross-site request forgery. The token is stored
+// in the session and must be sent with every POST, PUT, PATCH and DELETE request in the HeaderName header, or in
+// the FieldName field of application/x-www-form-urlencoded bodies. Multipart bodies aren't read so that uploads
+// can be streamed, so they must send the header. Requests without the session cookie, such as webhooks, and
+// requests with a bearer token don't use the session and aren't checked. Failed checks get a 403 response.
+// Must run after sessionManager.LoadAndSave.
+func NewCSRFMiddleware(sessionManager *scs.SessionManager, config CSRFConfig) func(next http.Handler) http.Handler {
+	if config.HeaderName == "" {
+		config.HeaderName = DefaultCSRFHeader
+	}
+
+	if config.FieldName == "" {
+		config.FieldName = defaultCSRFField
+	}
+
+	if config.CookieName == "" {
+		config.CookieName = defaultCSRFField
+	}
+
+	c := &csrf{sessionManager: sessionManager, config: config}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(context.WithValue(r.Context(), csrfContextKey, c))
+
+			if isSafeMethod(r.Method) {
+				c.setCookie(w, r)
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if !slices.Contains(c.config.ExemptPaths, r.URL.Path) && !hasBearerToken(r) && c.hasSessionCookie(r) {
+				if err := c.verify(w, r); err != nil {
+					httputils.InvalidCSRFTokenResponse(w, r, err)
+
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFToken returns the session's CSRF token, creating it if needed. Returns an empty string if the
+// CSRF middleware isn't installed.
+func CSRFToken(ctx context.Context) string {
+	c, ok := ctx.Value(csrfContextKey).(*csrf)
+	if !ok {
+		return ""
+	}
+
+	return c.token(ctx)
+}
+
+// CSRFField returns a hidden form input holding the session's CSRF token.
+func CSRFField(ctx context.Context) template.HTML {
+	c, ok := ctx.Value(csrfContextKey).(*csrf)
+	if !ok {
+		return ""
+	}
+
+	//nolint: gosec
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(c.config.FieldName) +
+		`" value="` + template.HTMLEscapeString(c.token(ctx)) + `">`)
+}
+
+func (c *csrf) token(ctx context.Context) string {
+	if token := c.sessionManager.GetString(ctx, csrfSessionKey); token != "" {
+		return token
+	}
+
+	b := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	c.sessionManager.Put(ctx, csrfSessionKey, token)
+
+	return token
+}
+
+// setCookie exposes the token to JavaScript once the user is signed in, or once a token was created for a form.
+func (c *csrf) setCookie(w http.ResponseWriter, r *http.Request) {
+	if !c.sessionManager.Exists(r.Context(), "user") && !c.sessionManager.Exists(r.Context(), csrfSessionKey) {
+		return
+	}
+
+	token := c.token(r.Context())
+	if cookie, err := r.Cookie(c.config.CookieName); err == nil && cookie.Value == token {
+		return
+	}
+
+	//nolint: exhaustruct
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.config.CookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   c.sessionManager.Cookie.Secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func (c *csrf) verify(w http.ResponseWriter, r *http.Request) error {
+	sent := r.Header.Get(c.config.HeaderName)
+	if sent == "" && isURLEncodedForm(r) {
+		// the parsed form is kept in r.PostForm for the handler
+		r.Body = http.MaxBytesReader(w, r.Body, maxCSRFFormBytes)
+		if err := r.ParseForm(); err != nil {
+			return ErrCSRFTokenMissing
+		}
+
+		sent = r.PostForm.Get(c.config.FieldName)
+	}
+
+	if sent == "" {
+		return ErrCSRFTokenMissing
+	}
+
+	token := c.sessionManager.GetString(r.Context(), csrfSessionKey)
+	if token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+		return ErrCSRFTokenInvalid
+	}
+
+	return nil
+}
+
+// hasSessionCookie returns true if r sends the session cookie. Cross-site requests can only act as the user if
+// it does.
+func (c *csrf) hasSessionCookie(r *http.Request) bool {
+	_, err := r.Cookie(c.sessionManager.Cookie.Name)
+
+	return err == nil
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions || method == http.MethodTrace
+}
+
+func hasBearerToken(r *http.Request) bool {
+	scheme, _, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+
+	return ok && strings.EqualFold(scheme, "Bearer")
+}
+
+func isURLEncodedForm(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get(httputils.ContentTypeHeader), "application/x-www-form-urlencoded")
+}
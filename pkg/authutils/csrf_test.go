@@ -0,0 +1,202 @@
+package authutils_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/gurch101/gowebutils/pkg/authutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	t.Parallel()
+
+	sessionManager := scs.New()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /form", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, string(authutils.CSRFField(r.Context())))
+	})
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, authutils.CSRFToken(r.Context()))
+	})
+	mux.HandleFunc("POST /", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST /uploads", func(w http.ResponseWriter, r *http.Request) {
+		// the body must still be unread so that uploads can be streamed
+		if _, err := r.MultipartReader(); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := sessionManager.LoadAndSave(authutils.NewCSRFMiddleware(sessionManager, authutils.CSRFConfig{
+		ExemptPaths: []string{"/webhooks"},
+	})(mux))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/token", nil))
+
+	token := rr.Body.String()
+	sessionCookie := rr.Result().Cookies()[0]
+
+	rr = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/form", nil)
+	r.AddCookie(sessionCookie)
+	handler.ServeHTTP(rr, r)
+
+	if expected := `<input type="hidden" name="csrf_token" value="` + token + `">`; rr.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, rr.Body.String())
+	}
+
+	if cookie := findCookie(rr.Result().Cookies(), "csrf_token"); cookie == nil || cookie.Value != token || cookie.HttpOnly {
+		t.Errorf("expected the token to be readable from the csrf_token cookie, got %v", cookie)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		header      map[string]string
+		noSession   bool
+		status      int
+		message     string
+	}{
+		{
+			name:        "form field",
+			path:        "/",
+			contentType: "application/x-www-form-urlencoded",
+			body:        url.Values{"csrf_token": {token}}.Encode(),
+			status:      http.StatusOK,
+		},
+		{
+			name:        "header",
+			path:        "/",
+			contentType: "application/json",
+			body:        `{}`,
+			header:      map[string]string{"X-CSRF-Token": token},
+			status:      http.StatusOK,
+		},
+		{
+			name:        "missing token",
+			path:        "/",
+			contentType: "application/json",
+			body:        `{}`,
+			status:      http.StatusForbidden,
+			message:     "missing CSRF token",
+		},
+		{
+			name:        "invalid token",
+			path:        "/",
+			contentType: "application/x-www-form-urlencoded",
+			body:        url.Values{"csrf_token": {"nope"}}.Encode(),
+			status:      http.StatusForbidden,
+			message:     "invalid CSRF token",
+		},
+		{
+			name:        "bearer token",
+			path:        "/",
+			contentType: "application/json",
+			body:        `{}`,
+			header:      map[string]string{"Authorization": "Bearer abc"},
+			status:      http.StatusOK,
+		},
+		{
+			name:        "multipart header",
+			path:        "/uploads",
+			contentType: "multipart/form-data; boundary=boundary",
+			body:        "--boundary--\r\n",
+			header:      map[string]string{"X-CSRF-Token": token},
+			status:      http.StatusOK,
+		},
+		{
+			name:        "multipart field",
+			path:        "/uploads",
+			contentType: "multipart/form-data; boundary=boundary",
+			body:        "--boundary\r\nContent-Disposition: form-data; name=\"csrf_token\"\r\n\r\n" + token + "\r\n--boundary--\r\n",
+			status:      http.StatusForbidden,
+			message:     "missing CSRF token",
+		},
+		{
+			name:        "without session",
+			path:        "/",
+			contentType: "application/json",
+			body:        `{}`,
+			noSession:   true,
+			status:      http.StatusOK,
+		},
+		{
+			name:        "exempt path",
+			path:        "/webhooks",
+			contentType: "application/json",
+			body:        `{}`,
+			status:      http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			if !tt.noSession {
+				r.AddCookie(sessionCookie)
+			}
+
+			for key, value := range tt.header {
+				r.Header.Set(key, value)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			if tt.message == "" {
+				return
+			}
+
+			var response httputils.ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Errors[0].Code != httputils.CodeInvalidCSRFToken || response.Errors[0].Message != tt.message {
+				t.Errorf("unexpected error %+v", response.Errors[0])
+			}
+		})
+	}
+}
+
+func TestCSRFToken_WithoutMiddleware(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authutils.CSRFToken(r.Context()) != "" || authutils.CSRFField(r.Context()) != "" {
+		t.Error("expected an empty token")
+	}
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+
+	return nil
+}
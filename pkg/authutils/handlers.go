@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
@@ -98,9 +97,12 @@ func createOauthConfig(
 
 type GetOrCreateUser func(ctx context.Context, email string, inviteTokenPayload map[string]any) (User, error)
 
+// CreateOidcController creates the login, registration and logout handlers from the OIDC_* env vars. secure
+// should be true when the app is served over HTTPS so that the state cookie is only sent over HTTPS.
 func CreateOidcController(
 	sessionManager *scs.SessionManager,
 	getOrCreateUserFn GetOrCreateUser,
+	secure bool,
 ) *OidcController {
 	///nolint: exhaustruct
 	gob.Register(User{})
@@ -121,14 +123,7 @@ func CreateOidcController(
 
 	redirectURL := parser.ParseEnvString("REDIRECT_URL", "/")
 
-	secureSessionCookie := false
-
-	_, err = os.Stat("./tls/cert.pem")
-	if err == nil {
-		secureSessionCookie = true
-	}
-
-	return &OidcController{sessionManager: sessionManager, getOrCreateUserFn: getOrCreateUserFn, oauth2Config: config, redirectURL: redirectURL, secureStateSessionCookie: secureSessionCookie}
+	return &OidcController{sessionManager: sessionManager, getOrCreateUserFn: getOrCreateUserFn, oauth2Config: config, redirectURL: redirectURL, secureStateSessionCookie: secure}
 }
 
 func NewOidcController(
@@ -248,6 +243,7 @@ func (c *OidcController) AuthCallback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	c.sessionManager.Put(r.Context(), "user", user)
+	c.sessionManager.Remove(r.Context(), csrfSessionKey)
 
 	http.Redirect(w, r, c.redirectURL, http.StatusTemporaryRedirect)
 }
@@ -0,0 +1,9 @@
+package authutils
+
+import "net/http"
+
+// IdempotencyScopeByUser scopes idempotency keys to the signed in user. Requests without a user aren't made
+// idempotent, so it should be used on protected routes.
+func IdempotencyScopeByUser(r *http.Request) string {
+	return RateLimitByUser(r)
+}
@@ -0,0 +1,28 @@
+package authutils
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// RateLimitByUser is an httputils.RateLimitKeyFunc that limits requests by the signed in user.
+// Requests without a user are not limited, so it should be used on protected routes.
+func RateLimitByUser(r *http.Request) string {
+	user, ok := r.Context().Value(userContextKey).(User)
+	if !ok {
+		return ""
+	}
+
+	return strconv.FormatInt(user.ID, 10)
+}
+
+// RateLimitByTenant is an httputils.RateLimitKeyFunc that limits requests by the tenant of the signed in user.
+// Requests without a user are not limited, so it should be used on protected routes.
+func RateLimitByTenant(r *http.Request) string {
+	user, ok := r.Context().Value(userContextKey).(User)
+	if !ok {
+		return ""
+	}
+
+	return strconv.FormatInt(user.TenantID, 10)
+}
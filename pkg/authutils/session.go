@@ -3,7 +3,6 @@ package authutils
 import (
 	"database/sql"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/alexedwards/scs/sqlite3store"
@@ -12,20 +11,15 @@ import (
 
 const sessionTimeout = 12 * time.Hour
 
-func CreateSessionManager(db *sql.DB) *scs.SessionManager {
-	secureSessionCookie := false
-
-	_, err := os.Stat("./tls/cert.pem")
-	if err == nil {
-		secureSessionCookie = true
-	}
-
+// CreateSessionManager creates a session manager that stores sessions in db. secure should be true when the app
+// is served over HTTPS so that the session cookie is only sent over HTTPS.
+func CreateSessionManager(db *sql.DB, secure bool) *scs.SessionManager {
 	sessionManager := scs.New()
 	sessionManager.Store = sqlite3store.New(db)
 	sessionManager.Lifetime = sessionTimeout
-	sessionManager.Cookie.Secure = secureSessionCookie
+	sessionManager.Cookie.Secure = secure
 
-	if secureSessionCookie {
+	if secure {
 		sessionManager.Cookie.SameSite = http.SameSiteStrictMode
 	}
 
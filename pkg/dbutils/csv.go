@@ -0,0 +1,475 @@
+package dbutils
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/validation"
+)
+
+var (
+	ErrCSVMalformed     = errors.New("malformed csv")
+	ErrCSVInvalidHeader = errors.New("invalid csv header")
+	ErrCSVTooManyRows   = errors.New("too many csv rows")
+)
+
+const (
+	defaultCSVChunkSize = 500
+	defaultCSVMaxRows   = 10_000
+	// maxCSVRowErrors caps the number of row errors collected before an import stops validating.
+	maxCSVRowErrors = 100
+)
+
+// ExportCSV runs qb and streams the result to w as CSV. The header row contains the selected
+// column names or aliases and is written even if there are no rows. Text cells that spreadsheets
+// would evaluate as formulas are prefixed with a single quote.
+func ExportCSV(ctx context.Context, w io.Writer, qb *QueryBuilder) error {
+	query, args := qb.Build()
+
+	rows, err := qb.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query builder exec error: %w", err)
+	}
+
+	defer fsutils.CloseAndPanic(rows)
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("failed to get csv columns: %w", err)
+	}
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(columns); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	values := make([]any, len(columns))
+	record := make([]string, len(columns))
+
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return WrapDBError(err)
+		}
+
+		for i, value := range values {
+			record[i] = formatCSVValue(value)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query builder rows error: %w", err)
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	return nil
+}
+
+func formatCSVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return escapeCSVFormula(string(v))
+	case string:
+		return escapeCSVFormula(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return escapeCSVFormula(fmt.Sprint(v))
+	}
+}
+
+// escapeCSVFormula prefixes text that spreadsheets would evaluate as a formula with a single quote.
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// CSVRecord is a row of an imported CSV file keyed by header. The typed getters return the zero
+// value for empty cells and record an error for cells that cannot be converted.
+type CSVRecord struct {
+	// Line is the line number in the file where the row starts, e.g. 2 for the first row after the header.
+	Line   int
+	values map[string]string
+	errors []validation.Error
+}
+
+// NewCSVRecord creates a record from a header and a row of values.
+func NewCSVRecord(line int, header, values []string) *CSVRecord {
+	record := &CSVRecord{Line: line, values: make(map[string]string, len(header))}
+
+	for i, column := range header {
+		if i < len(values) {
+			record.values[column] = strings.TrimSpace(values[i])
+		}
+	}
+
+	return record
+}
+
+// Errors returns the conversion errors recorded by the typed getters.
+func (r *CSVRecord) Errors() []validation.Error {
+	return r.errors
+}
+
+// String returns the value of column.
+func (r *CSVRecord) String(column string) string {
+	return r.values[column]
+}
+
+// Int returns the value of column as an int.
+func (r *CSVRecord) Int(column string) int {
+	return int(parseCSVValue(r, column, "must be an integer", func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 0)
+	}))
+}
+
+// Int64 returns the value of column as an int64.
+func (r *CSVRecord) Int64(column string) int64 {
+	return parseCSVValue(r, column, "must be an integer", func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// Float64 returns the value of column as a float64.
+func (r *CSVRecord) Float64(column string) float64 {
+	return parseCSVValue(r, column, "must be a number", func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// Bool returns the value of column as a bool. Accepts true/false and 1/0.
+func (r *CSVRecord) Bool(column string) bool {
+	return parseCSVValue(r, column, "must be true or false", strconv.ParseBool)
+}
+
+// Decimal returns the value of column as a decimal.
+func (r *CSVRecord) Decimal(column string) decimal.Decimal {
+	return parseCSVValue(r, column, "must be a decimal number", decimal.Parse)
+}
+
+// Time returns the value of column parsed as RFC 3339.
+func (r *CSVRecord) Time(column string) time.Time {
+	return parseCSVValue(r, column, "must be an RFC 3339 timestamp", func(s string) (time.Time, error) {
+		return time.Parse(time.RFC3339, s)
+	})
+}
+
+// Duration returns the value of column parsed with time.ParseDuration.
+func (r *CSVRecord) Duration(column string) time.Duration {
+	return parseCSVValue(r, column, "must be a duration", time.ParseDuration)
+}
+
+// JSON returns the value of column as raw JSON.
+func (r *CSVRecord) JSON(column string) json.RawMessage {
+	return parseCSVValue(r, column, "must be valid JSON", func(s string) (json.RawMessage, error) {
+		var raw json.RawMessage
+
+		err := json.Unmarshal([]byte(s), &raw)
+
+		return raw, err
+	})
+}
+
+// mergeErrors appends validationErrors for fields that do not already have a conversion error.
+func (r *CSVRecord) mergeErrors(validationErrors []validation.Error) []validation.Error {
+	merged := slices.Clone(r.errors)
+
+	for _, validationErr := range validationErrors {
+		if !slices.ContainsFunc(r.errors, func(e validation.Error) bool { return e.Field == validationErr.Field }) {
+			merged = append(merged, validationErr)
+		}
+	}
+
+	return merged
+}
+
+func parseCSVValue[T any](r *CSVRecord, column, message string, parse func(string) (T, error)) T {
+	var zero T
+
+	value := r.values[column]
+	if value == "" {
+		return zero
+	}
+
+	parsed, err := parse(value)
+	if err != nil {
+		r.errors = append(r.errors, validation.Error{Field: column, Code: validation.CodeInvalid, Message: message})
+
+		return zero
+	}
+
+	return parsed
+}
+
+// CSVRowError lists the errors of a row of an imported CSV file.
+type CSVRowError struct {
+	Line   int                `json:"line"`
+	Errors []validation.Error `json:"errors"`
+}
+
+// CSVImportError is returned by ImportCSV when one or more rows are invalid. No rows are imported.
+type CSVImportError struct {
+	Rows []CSVRowError
+}
+
+func (e CSVImportError) Error() string {
+	return fmt.Sprintf("csv import failed: %d invalid rows", len(e.Rows))
+}
+
+// CSVImportOption configures ImportCSV.
+type CSVImportOption func(*csvImportOptions)
+
+type csvImportOptions struct {
+	chunkSize int
+	maxRows   int
+	columns   []string
+}
+
+// WithCSVChunkSize sets the number of rows inserted per savepoint. Defaults to 500.
+func WithCSVChunkSize(chunkSize int) CSVImportOption {
+	return func(options *csvImportOptions) {
+		options.chunkSize = chunkSize
+	}
+}
+
+// WithCSVMaxRows sets the maximum number of rows that can be imported. Defaults to 10,000.
+func WithCSVMaxRows(maxRows int) CSVImportOption {
+	return func(options *csvImportOptions) {
+		options.maxRows = maxRows
+	}
+}
+
+// WithCSVColumns restricts the header to the given columns. Unknown columns are rejected.
+func WithCSVColumns(columns ...string) CSVImportOption {
+	return func(options *csvImportOptions) {
+		options.columns = columns
+	}
+}
+
+// ImportCSV reads a CSV file with a header row from r. Each row is converted to a T and
+// validated by parse, then the valid rows are passed to insert in chunks inside a single
+// transaction. If any row fails to parse, validate or insert, the transaction is rolled back
+// and a CSVImportError listing the failed rows is returned. Returns the number of imported rows.
+func ImportCSV[T any](
+	ctx context.Context,
+	db DB,
+	r io.Reader,
+	parse func(record *CSVRecord) (T, []validation.Error),
+	insert func(ctx context.Context, tx DB, row T) error,
+	opts ...CSVImportOption,
+) (int, error) {
+	options := csvImportOptions{chunkSize: defaultCSVChunkSize, maxRows: defaultCSVMaxRows}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := readCSVHeader(reader, options.columns)
+	if err != nil {
+		return 0, err
+	}
+
+	imported := 0
+
+	err = WithTransaction(ctx, db, func(tx DB) error {
+		importer := csvImporter[T]{tx: tx, insert: insert}
+
+		for rowCount := 1; ; rowCount++ {
+			values, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrCSVMalformed, err)
+			}
+
+			if rowCount > options.maxRows {
+				return fmt.Errorf("%w: the maximum is %d", ErrCSVTooManyRows, options.maxRows)
+			}
+
+			// quoted values can span lines, so the row number isn't the line number
+			line, _ := reader.FieldPos(0)
+
+			record := NewCSVRecord(line, header, values)
+
+			row, validationErrors := parse(record)
+			if rowErrors := record.mergeErrors(validationErrors); len(rowErrors) > 0 {
+				importer.rowErrors = append(importer.rowErrors, CSVRowError{Line: line, Errors: rowErrors})
+			} else if len(importer.rowErrors) == 0 {
+				importer.chunk = append(importer.chunk, csvRow[T]{line: line, row: row})
+			}
+
+			if len(importer.rowErrors) >= maxCSVRowErrors {
+				break
+			}
+
+			if len(importer.chunk) >= options.chunkSize {
+				if err := importer.flush(ctx); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := importer.flush(ctx); err != nil {
+			return err
+		}
+
+		if len(importer.rowErrors) > 0 {
+			return CSVImportError{Rows: importer.rowErrors}
+		}
+
+		imported = importer.inserted
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+func readCSVHeader(reader *csv.Reader, columns []string) ([]string, error) {
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrCSVInvalidHeader)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCSVMalformed, err)
+	}
+
+	header = slices.Clone(header)
+
+	for i, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		header[i] = column
+
+		if column == "" {
+			return nil, fmt.Errorf("%w: column %d is empty", ErrCSVInvalidHeader, i+1)
+		}
+
+		if slices.Contains(header[:i], column) {
+			return nil, fmt.Errorf("%w: duplicate column %s", ErrCSVInvalidHeader, column)
+		}
+
+		if len(columns) > 0 && !slices.Contains(columns, column) {
+			return nil, fmt.Errorf("%w: unknown column %s", ErrCSVInvalidHeader, column)
+		}
+	}
+
+	return header, nil
+}
+
+type csvRow[T any] struct {
+	line int
+	row  T
+}
+
+type csvImporter[T any] struct {
+	tx        DB
+	insert    func(ctx context.Context, tx DB, row T) error
+	chunk     []csvRow[T]
+	rowErrors []CSVRowError
+	inserted  int
+}
+
+// flush inserts the buffered rows in a savepoint. Validation and constraint errors are
+// reported against the row that caused them; any other error aborts the import.
+func (i *csvImporter[T]) flush(ctx context.Context) error {
+	if len(i.chunk) == 0 || len(i.rowErrors) > 0 {
+		i.chunk = i.chunk[:0]
+
+		return nil
+	}
+
+	var failed *CSVRowError
+
+	err := WithTransaction(ctx, i.tx, func(tx DB) error {
+		for _, row := range i.chunk {
+			if err := i.insert(ctx, tx, row.row); err != nil {
+				if rowError, ok := csvRowError(row.line, err); ok {
+					failed = &rowError
+				}
+
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	inserted := len(i.chunk)
+	i.chunk = i.chunk[:0]
+
+	if failed != nil {
+		i.rowErrors = append(i.rowErrors, *failed)
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	i.inserted += inserted
+
+	return nil
+}
+
+func csvRowError(line int, err error) (CSVRowError, bool) {
+	var validationErr validation.ValidationError
+
+	var singleValidationErr validation.Error
+
+	switch {
+	case errors.As(err, &singleValidationErr):
+		return CSVRowError{Line: line, Errors: []validation.Error{singleValidationErr}}, true
+	case errors.As(err, &validationErr):
+		return CSVRowError{Line: line, Errors: validationErr.Errors}, true
+	case errors.Is(err, ErrUniqueConstraint):
+		return CSVRowError{Line: line, Errors: []validation.Error{{Code: validation.CodeAlreadyExists, Message: "row already exists"}}}, true
+	case errors.Is(err, ErrForeignKeyConstraint), errors.Is(err, ErrCheckConstraint), errors.Is(err, ErrNotNullConstraint):
+		return CSVRowError{Line: line, Errors: []validation.Error{{Code: validation.CodeInvalid, Message: err.Error()}}}, true
+	default:
+		return CSVRowError{}, false
+	}
+}
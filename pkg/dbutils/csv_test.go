@@ -0,0 +1,247 @@
+package dbutils_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+	"github.com/gurch101/gowebutils/pkg/validation"
+)
+
+func TestExportCSV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    string
+		qb       func(db dbutils.DB) *dbutils.QueryBuilder
+		expected string
+	}{
+		{
+			name: "rows",
+			qb: func(db dbutils.DB) *dbutils.QueryBuilder {
+				return dbutils.NewQueryBuilder(db).
+					Select("id", "tenant_name AS tenantName", "is_active AS isActive", "role_id AS roleId").
+					From("tenants").
+					OrderBy("id")
+			},
+			expected: "id,tenantName,isActive,roleId\n1,Acme,true,\n2,Flancrest Enterprises,true,\n",
+		},
+		{
+			name: "no rows",
+			qb: func(db dbutils.DB) *dbutils.QueryBuilder {
+				return dbutils.NewQueryBuilder(db).Select("id", "tenant_name AS tenantName").From("tenants").Where("id = ?", 0)
+			},
+			expected: "id,tenantName\n",
+		},
+		{
+			name:  "formulas",
+			setup: "UPDATE tenants SET tenant_name = '=HYPERLINK(\"https://example.com\")' WHERE id = 1",
+			qb: func(db dbutils.DB) *dbutils.QueryBuilder {
+				return dbutils.NewQueryBuilder(db).Select("tenant_name AS tenantName", "-1 AS balance").From("tenants").Where("id = ?", 1)
+			},
+			expected: "tenantName,balance\n\"'=HYPERLINK(\"\"https://example.com\"\")\",-1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := testutils.SetupTestDB(t)
+			defer fsutils.CloseAndPanic(db)
+
+			if tt.setup != "" {
+				if _, err := db.Exec(tt.setup); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var buf bytes.Buffer
+
+			if err := dbutils.ExportCSV(context.Background(), &buf, tt.qb(db)); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+type csvTenant struct {
+	Name  string
+	Email string
+	Plan  string
+}
+
+func parseCSVTenant(record *dbutils.CSVRecord) (csvTenant, []validation.Error) {
+	tenant := csvTenant{Name: record.String("tenantName"), Email: record.String("contactEmail"), Plan: record.String("plan")}
+
+	v := validation.NewValidator()
+	v.Required(tenant.Name, "tenantName", "Tenant Name is required")
+	v.Email(tenant.Email, "contactEmail", "Contact Email must be a valid email address")
+
+	return tenant, v.Errors
+}
+
+func insertCSVTenant(ctx context.Context, tx dbutils.DB, tenant csvTenant) error {
+	_, err := dbutils.Insert(ctx, tx, "tenants", map[string]any{
+		"tenant_name":   tenant.Name,
+		"contact_email": tenant.Email,
+		"plan":          tenant.Plan,
+	})
+
+	return err
+}
+
+func countTenants(t *testing.T, db dbutils.DB) int {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM tenants").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestImportCSV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		csv       string
+		imported  int
+		tenants   int
+		err       error
+		rowErrors []dbutils.CSVRowError
+	}{
+		{
+			name:     "imports all rows in chunks",
+			csv:      "tenantName,contactEmail,plan\nA,a@a.com,free\nB,b@b.com,free\nC,c@c.com,paid\n",
+			imported: 3,
+			tenants:  5,
+		},
+		{
+			name:    "validation errors reject the file",
+			csv:     "tenantName,contactEmail,plan\nA,a@a.com,free\n,bad,free\nC,c@c.com,paid\n",
+			tenants: 2,
+			rowErrors: []dbutils.CSVRowError{{Line: 3, Errors: []validation.Error{
+				{Field: "tenantName", Code: validation.CodeRequired, Message: "Tenant Name is required"},
+				{Field: "contactEmail", Code: validation.CodeInvalidEmail, Message: "Contact Email must be a valid email address"},
+			}}},
+		},
+		{
+			name:      "constraint errors are reported against the row",
+			csv:       "tenantName,contactEmail,plan\nA,a@a.com,free\nB,b@b.com,free\nAcme,c@c.com,paid\n",
+			tenants:   2,
+			rowErrors: []dbutils.CSVRowError{{Line: 4, Errors: []validation.Error{{Code: validation.CodeAlreadyExists, Message: "row already exists"}}}},
+		},
+		{
+			name:    "line numbers of rows after multiline values",
+			csv:     "tenantName,contactEmail,plan\n\"A\nInc\",a@a.com,free\n,b@b.com,free\n",
+			tenants: 2,
+			rowErrors: []dbutils.CSVRowError{{Line: 4, Errors: []validation.Error{
+				{Field: "tenantName", Code: validation.CodeRequired, Message: "Tenant Name is required"},
+			}}},
+		},
+		{
+			name:    "unknown column",
+			csv:     "tenantName,contactEmail,plan,secret\nA,a@a.com,free,x\n",
+			tenants: 2,
+			err:     dbutils.ErrCSVInvalidHeader,
+		},
+		{
+			name:    "too many rows",
+			csv:     "tenantName,contactEmail,plan\nA,a@a.com,free\nB,b@b.com,free\nC,c@c.com,paid\nD,d@d.com,paid\n",
+			tenants: 2,
+			err:     dbutils.ErrCSVTooManyRows,
+		},
+		{
+			name:    "malformed row",
+			csv:     "tenantName,contactEmail,plan\nA,a@a.com\n",
+			tenants: 2,
+			err:     dbutils.ErrCSVMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := testutils.SetupTestDB(t)
+			defer fsutils.CloseAndPanic(db)
+
+			imported, err := dbutils.ImportCSV(
+				context.Background(),
+				db,
+				strings.NewReader(tt.csv),
+				parseCSVTenant,
+				insertCSVTenant,
+				dbutils.WithCSVChunkSize(2),
+				dbutils.WithCSVMaxRows(3),
+				dbutils.WithCSVColumns("tenantName", "contactEmail", "plan"),
+			)
+
+			var importErr dbutils.CSVImportError
+
+			switch {
+			case tt.rowErrors != nil:
+				if !errors.As(err, &importErr) {
+					t.Fatalf("expected a CSVImportError, got %v", err)
+				}
+
+				if !reflect.DeepEqual(importErr.Rows, tt.rowErrors) {
+					t.Errorf("expected row errors %+v, got %+v", tt.rowErrors, importErr.Rows)
+				}
+			case !errors.Is(err, tt.err):
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if imported != tt.imported {
+				t.Errorf("expected %d imported rows, got %d", tt.imported, imported)
+			}
+
+			if count := countTenants(t, db); count != tt.tenants {
+				t.Errorf("expected %d tenants, got %d", tt.tenants, count)
+			}
+		})
+	}
+}
+
+func TestCSVRecord(t *testing.T) {
+	t.Parallel()
+
+	record := dbutils.NewCSVRecord(2,
+		[]string{"count", "active", "price", "settings", "createdAt", "name"},
+		[]string{"x", "1", "1.50", "{bad", "2024-01-02T03:04:05Z", " jane "})
+
+	if record.Int64("count") != 0 || !record.Bool("active") || record.Decimal("price").String() != "1.50" {
+		t.Error("unexpected typed values")
+	}
+
+	if record.JSON("settings") != nil || record.Time("createdAt").Year() != 2024 || record.String("name") != "jane" {
+		t.Error("unexpected typed values")
+	}
+
+	if record.Int64("missing") != 0 {
+		t.Error("expected missing columns to return the zero value")
+	}
+
+	expected := []validation.Error{
+		{Field: "count", Code: validation.CodeInvalid, Message: "must be an integer"},
+		{Field: "settings", Code: validation.CodeInvalid, Message: "must be valid JSON"},
+	}
+
+	if !reflect.DeepEqual(record.Errors(), expected) {
+		t.Errorf("expected %v, got %v", expected, record.Errors())
+	}
+}
@@ -25,17 +25,23 @@ type DB interface {
 
 // WithTransaction manages transactions and supports nesting using savepoints.
 func WithTransaction(ctx context.Context, db DB, callback func(tx DB) error) error {
-	if dbpool, ok := db.(*DBPool); ok {
-		return WithTransaction(ctx, dbpool.WriteDB(), callback)
-	}
-
 	depth := getTransactionDepth(ctx)
 
 	// Check if we're already in a transaction
-	if tx, ok := db.(*sql.Tx); ok {
+	if tx, ok := asTx(db); ok {
+		return handleSavepoint(ctx, tx, callback, depth+1)
+	}
+
+	// join the request-scoped unit of work, if any. Beginning another transaction on the
+	// single write connection would block until the unit of work finishes.
+	if tx, ok := TxFromContext(ctx); ok {
 		return handleSavepoint(ctx, tx, callback, depth+1)
 	}
 
+	if dbpool, ok := db.(*DBPool); ok {
+		return WithTransaction(ctx, dbpool.WriteDB(), callback)
+	}
+
 	// Otherwise, start a new transaction
 	tx, err := beginTransaction(ctx, db)
 	if err != nil {
@@ -53,7 +59,7 @@ func WithTransaction(ctx context.Context, db DB, callback func(tx DB) error) err
 
 	ctx = setTransactionDepth(ctx, depth+1)
 
-	err = callback(tx)
+	err = callback(trackTx(ctx, tx))
 	if err != nil {
 		return err
 	}
@@ -66,6 +72,18 @@ func WithTransaction(ctx context.Context, db DB, callback func(tx DB) error) err
 	return nil
 }
 
+// asTx returns the transaction underlying db, if any.
+func asTx(db DB) (*sql.Tx, bool) {
+	switch tx := db.(type) {
+	case *sql.Tx:
+		return tx, true
+	case trackedTx:
+		return tx.Tx, true
+	default:
+		return nil, false
+	}
+}
+
 // beginTransaction starts a new SQL transaction.
 func beginTransaction(ctx context.Context, db DB) (*sql.Tx, error) {
 	dbConn, ok := db.(*sql.DB)
@@ -92,7 +110,7 @@ func handleSavepoint(ctx context.Context, tx *sql.Tx, callback func(tx DB) error
 	// Increment depth for nested transaction
 	ctx = setTransactionDepth(ctx, depth)
 
-	err := callback(tx)
+	err := callback(trackTx(ctx, tx))
 	if err != nil {
 		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rbErr != nil {
 			slog.ErrorContext(ctx, "db error", "message", fmt.Errorf("failed to rollback savepoint: %w", rbErr))
@@ -0,0 +1,136 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// DeleteBuilder builds DELETE statements.
+type DeleteBuilder struct {
+	table      string
+	conditions conditions
+	returning  []string
+	db         DB
+}
+
+// NewDeleteBuilder creates a new DeleteBuilder instance which can be used to build and execute DELETE statements.
+func NewDeleteBuilder(db DB) *DeleteBuilder {
+	return &DeleteBuilder{
+		conditions: conditions{clauses: []string{}, args: []interface{}{}},
+		returning:  []string{},
+		db:         db,
+	}
+}
+
+// From sets the table to delete from.
+func (dlb *DeleteBuilder) From(table string) *DeleteBuilder {
+	dlb.table = table
+
+	return dlb
+}
+
+// Where adds a WHERE clause to the statement. See QueryBuilder.Where.
+func (dlb *DeleteBuilder) Where(condition string, args ...any) *DeleteBuilder {
+	dlb.conditions.where(condition, args)
+
+	return dlb
+}
+
+// WhereLike adds a WHERE clause to the statement with a LIKE pattern.
+func (dlb *DeleteBuilder) WhereLike(condition string, patternType QueryOperator, value *string) *DeleteBuilder {
+	dlb.conditions.whereLike(condition, "", patternType, value)
+
+	return dlb
+}
+
+// AndWhere adds a WHERE clause to the statement with an AND conjunction.
+func (dlb *DeleteBuilder) AndWhere(condition string, args ...any) *DeleteBuilder {
+	dlb.conditions.whereWithConjunction(condition, "AND", args)
+
+	return dlb
+}
+
+// AndWhereLike adds a WHERE clause to the statement with an AND conjunction and a LIKE pattern.
+func (dlb *DeleteBuilder) AndWhereLike(condition string, patternType QueryOperator, value *string) *DeleteBuilder {
+	dlb.conditions.whereLike(condition, "AND", patternType, value)
+
+	return dlb
+}
+
+// OrWhere adds a WHERE clause to the statement with an OR conjunction.
+func (dlb *DeleteBuilder) OrWhere(condition string, args ...any) *DeleteBuilder {
+	dlb.conditions.whereWithConjunction(condition, "OR", args)
+
+	return dlb
+}
+
+// OrWhereLike adds a WHERE clause to the statement with an OR conjunction and a LIKE pattern.
+func (dlb *DeleteBuilder) OrWhereLike(condition string, patternType QueryOperator, value *string) *DeleteBuilder {
+	dlb.conditions.whereLike(condition, "OR", patternType, value)
+
+	return dlb
+}
+
+// Returning sets the columns returned by the statement.
+func (dlb *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	dlb.returning = append(dlb.returning, columns...)
+
+	return dlb
+}
+
+// Build generates the SQL statement and returns it along with the arguments.
+func (dlb *DeleteBuilder) Build() (string, []interface{}) {
+	if dlb.table == "" {
+		panic("Table not specified")
+	}
+
+	query := strings.Builder{}
+
+	query.WriteString("DELETE FROM ")
+	query.WriteString(dlb.table)
+
+	dlb.conditions.writeTo(&query)
+	writeReturning(&query, dlb.returning)
+
+	args := make([]interface{}, 0, len(dlb.conditions.args))
+	args = append(args, dlb.conditions.args...)
+
+	return query.String(), args
+}
+
+// Exec executes the statement.
+func (dlb *DeleteBuilder) Exec() (sql.Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+
+	return dlb.ExecContext(ctx)
+}
+
+// ExecContext executes the statement with the given context. ErrNoDeleteFilters is returned
+// if no WHERE conditions were added, since conditions with nil arguments are skipped.
+func (dlb *DeleteBuilder) ExecContext(ctx context.Context) (sql.Result, error) {
+	if dlb.conditions.isEmpty() {
+		return nil, ErrNoDeleteFilters
+	}
+
+	query, args := dlb.Build()
+
+	result, err := dlb.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, WrapDBError(err)
+	}
+
+	return result, nil
+}
+
+// QueryContext executes the statement and calls the callback function for each row returned by RETURNING.
+func (dlb *DeleteBuilder) QueryContext(ctx context.Context, callback func(*sql.Rows) error) error {
+	if dlb.conditions.isEmpty() {
+		return ErrNoDeleteFilters
+	}
+
+	query, args := dlb.Build()
+
+	return queryRows(ctx, dlb.db, query, args, callback)
+}
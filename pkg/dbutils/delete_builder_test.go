@@ -0,0 +1,76 @@
+package dbutils_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestDeleteBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	query, args := dbutils.NewDeleteBuilder(nil).
+		From("users").
+		Where("tenant_id = ?", 1).
+		OrWhere("email = ?", "john@acme.com").
+		Returning("id").
+		Build()
+
+	expectedQuery := "DELETE FROM users WHERE (tenant_id = ?) OR (email = ?) RETURNING id"
+	expectedArgs := []interface{}{1, "john@acme.com"}
+
+	if query != expectedQuery {
+		t.Errorf("Expected query %q, got %q", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestDeleteBuilder_ExecContextWithoutFilters(t *testing.T) {
+	t.Parallel()
+
+	_, err := dbutils.NewDeleteBuilder(nil).From("users").ExecContext(context.Background())
+	if !errors.Is(err, dbutils.ErrNoDeleteFilters) {
+		t.Errorf("Expected ErrNoDeleteFilters, got %v", err)
+	}
+}
+
+func TestDeleteBuilder_QueryContext(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	var ids []int64
+
+	err := dbutils.NewDeleteBuilder(db).
+		From("users").
+		Where("tenant_id = ?", 1).
+		Returning("id").
+		QueryContext(context.Background(), func(rows *sql.Rows) error {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err //nolint: wrapcheck
+			}
+
+			ids = append(ids, id)
+
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("Expected ids [1 2], got %v", ids)
+	}
+}
@@ -0,0 +1,347 @@
+package dbutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+)
+
+const (
+	historyTableSuffix     = "_history"
+	historyIDColumn        = "history_id"
+	historyOperationColumn = "history_operation"
+	historyValidToColumn   = "history_valid_to"
+	historyTimeFormat      = "2006-01-02 15:04:05"
+)
+
+// ErrHistoryUnsupportedTable is returned when history is enabled on a table without id, version and updated_at columns.
+var ErrHistoryUnsupportedTable = errors.New("history requires id, version and updated_at columns")
+
+// FieldDiff describes a column whose value differs between two versions of a record.
+type FieldDiff struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type tableColumn struct {
+	name     string
+	dataType string
+}
+
+// HistoryTableName returns the name of the table that stores prior versions of rows in tableName.
+func HistoryTableName(tableName string) string {
+	return tableName + historyTableSuffix
+}
+
+// EnableHistory creates a {table}_history table along with triggers that copy the prior
+// row into it whenever a row in tableName is updated or deleted. It is safe to call on every startup:
+// columns added to tableName since the last call are added to the history table and the triggers are recreated.
+func EnableHistory(ctx context.Context, db DB, tableName string) error {
+	columns, err := getTableColumns(ctx, db, tableName)
+	if err != nil {
+		return err
+	}
+
+	if !hasColumns(columns, "id", "version", "updated_at") {
+		return fmt.Errorf("%w: %s", ErrHistoryUnsupportedTable, tableName)
+	}
+
+	historyColumns, err := getTableColumns(ctx, db, HistoryTableName(tableName))
+	if err != nil && !errors.Is(err, ErrNoSuchTable) {
+		return err
+	}
+
+	statements := buildHistoryStatements(tableName, columns, historyColumns)
+
+	return WithTransaction(ctx, db, func(tx DB) error {
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return WrapDBError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetByIDAsOf gets a record as it existed at the provided point in time. The record is read
+// from the live table if it has not changed since asOf, otherwise from its history table.
+func GetByIDAsOf(ctx context.Context, db DB, tableName string, id int64, asOf time.Time, fields map[string]any) error {
+	if id < 0 {
+		return ErrRecordNotFound
+	}
+
+	timestamp := asOf.UTC().Format(historyTimeFormat)
+
+	return getHistoricalRecord(ctx, db, tableName, fields,
+		"id = ? AND updated_at <= ?", []any{id, timestamp},
+		fmt.Sprintf("id = ? AND updated_at <= ? AND %s > ?", historyValidToColumn), []any{id, timestamp, timestamp},
+	)
+}
+
+// GetByIDAtVersion gets a record as it existed at the provided version.
+func GetByIDAtVersion(ctx context.Context, db DB, tableName string, id int64, version int64, fields map[string]any) error {
+	if id < 0 || version < 0 {
+		return ErrRecordNotFound
+	}
+
+	return getHistoricalRecord(ctx, db, tableName, fields,
+		"id = ? AND version = ?", []any{id, version},
+		"id = ? AND version = ?", []any{id, version},
+	)
+}
+
+// DiffVersions returns the columns that changed between two versions of a record.
+// Bookkeeping columns (id, version, created_at, updated_at) are not compared.
+func DiffVersions(ctx context.Context, db DB, tableName string, id int64, fromVersion, toVersion int64) ([]FieldDiff, error) {
+	columns, err := getTableColumns(ctx, db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := getVersionValues(ctx, db, tableName, id, fromVersion, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := getVersionValues(ctx, db, tableName, id, toVersion, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []FieldDiff{}
+
+	for _, column := range columns {
+		if isBookkeepingColumn(column.name) {
+			continue
+		}
+
+		if !reflect.DeepEqual(from[column.name], to[column.name]) {
+			diffs = append(diffs, FieldDiff{Field: column.name, From: from[column.name], To: to[column.name]})
+		}
+	}
+
+	return diffs, nil
+}
+
+// RevertToVersion restores the columns of a record to the values they held at targetVersion.
+// The revert is applied with UpdateByID, so currentVersion must match the live row or
+// ErrEditConflict is returned, and the revert itself produces a new version.
+func RevertToVersion(ctx context.Context, db DB, tableName string, id int64, currentVersion, targetVersion int64) error {
+	columns, err := getTableColumns(ctx, db, tableName)
+	if err != nil {
+		return err
+	}
+
+	values, err := getVersionValues(ctx, db, tableName, id, targetVersion, columns)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]any, len(values))
+
+	for column, value := range values {
+		if !isBookkeepingColumn(column) {
+			fields[column] = value
+		}
+	}
+
+	return UpdateByID(ctx, db, tableName, id, currentVersion, fields)
+}
+
+func getHistoricalRecord(
+	ctx context.Context,
+	db DB,
+	tableName string,
+	fields map[string]any,
+	liveCondition string,
+	liveArgs []any,
+	historyCondition string,
+	historyArgs []any,
+) error {
+	projection := make([]string, 0, len(fields))
+	dest := make([]any, 0, len(fields))
+
+	for field, fieldDest := range fields {
+		projection = append(projection, field)
+		dest = append(dest, fieldDest)
+	}
+
+	columns := strings.Join(projection, ",")
+
+	// #nosec G201 - tableName and fields are not user input in normal usage
+	query := fmt.Sprintf(
+		"SELECT %s FROM (SELECT %s, version AS history_sort FROM %s WHERE %s "+
+			"UNION ALL SELECT %s, version AS history_sort FROM %s WHERE %s) ORDER BY history_sort DESC LIMIT 1",
+		columns,
+		columns, tableName, liveCondition,
+		columns, HistoryTableName(tableName), historyCondition,
+	)
+
+	args := make([]any, 0, len(liveArgs)+len(historyArgs))
+	args = append(args, liveArgs...)
+	args = append(args, historyArgs...)
+
+	ctx, cancel := context.WithTimeout(ctx, getTimeout)
+	defer cancel()
+
+	err := db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	if err != nil {
+		return WrapDBError(err)
+	}
+
+	return nil
+}
+
+func getVersionValues(
+	ctx context.Context,
+	db DB,
+	tableName string,
+	id int64,
+	version int64,
+	columns []tableColumn,
+) (map[string]any, error) {
+	values := make([]any, len(columns))
+	fields := make(map[string]any, len(columns))
+
+	for i, column := range columns {
+		fields[column.name] = &values[i]
+	}
+
+	err := GetByIDAtVersion(ctx, db, tableName, id, version, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]any, len(columns))
+
+	for i, column := range columns {
+		if bytes, ok := values[i].([]byte); ok {
+			result[column.name] = string(bytes)
+		} else {
+			result[column.name] = values[i]
+		}
+	}
+
+	return result, nil
+}
+
+func getTableColumns(ctx context.Context, db DB, tableName string) ([]tableColumn, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", tableName))
+	if err != nil {
+		return nil, WrapDBError(err)
+	}
+
+	defer fsutils.CloseAndPanic(rows)
+
+	var columns []tableColumn
+
+	for rows.Next() {
+		var (
+			cid        int
+			column     tableColumn
+			notNull    int
+			dfltValue  any
+			primaryKey int
+		)
+
+		if err := rows.Scan(&cid, &column.name, &column.dataType, &notNull, &dfltValue, &primaryKey); err != nil {
+			return nil, WrapDBError(err)
+		}
+
+		columns = append(columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, WrapDBError(err)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchTable, tableName)
+	}
+
+	return columns, nil
+}
+
+// isBookkeepingColumn returns true for columns maintained by the CRUD helpers that are never diffed or reverted.
+func isBookkeepingColumn(name string) bool {
+	return name == "id" || name == "version" || name == "created_at" || name == "updated_at"
+}
+
+func hasColumns(columns []tableColumn, names ...string) bool {
+	for _, name := range names {
+		found := false
+
+		for _, column := range columns {
+			if column.name == name {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildHistoryStatements returns the statements that create or update the history table and triggers of tableName.
+// historyColumns are the columns of the existing history table, if any.
+func buildHistoryStatements(tableName string, columns, historyColumns []tableColumn) []string {
+	historyTable := HistoryTableName(tableName)
+	definitions := make([]string, 0, len(columns))
+	names := make([]string, 0, len(columns))
+	oldValues := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		definitions = append(definitions, strings.TrimSpace(column.name+" "+column.dataType))
+		names = append(names, column.name)
+		oldValues = append(oldValues, "old."+column.name)
+	}
+
+	insertColumns := strings.Join(names, ", ") + ", " + historyOperationColumn + ", " + historyValidToColumn
+	insertValues := strings.Join(oldValues, ", ")
+
+	statements := []string{
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s (%s INTEGER PRIMARY KEY, %s, %s TEXT NOT NULL, %s TIMESTAMP NOT NULL)",
+			historyTable, historyIDColumn, strings.Join(definitions, ", "), historyOperationColumn, historyValidToColumn,
+		),
+	}
+
+	// columns removed from tableName are kept so that older versions can still be read
+	if len(historyColumns) > 0 {
+		for _, column := range columns {
+			if !hasColumns(historyColumns, column.name) {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s",
+					historyTable, strings.TrimSpace(column.name+" "+column.dataType)))
+			}
+		}
+	}
+
+	return append(statements,
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_id_version_idx ON %s (id, version)", historyTable, historyTable),
+		// the triggers are recreated since they list the columns of tableName
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s_update", historyTable),
+		fmt.Sprintf(
+			"CREATE TRIGGER %s_update AFTER UPDATE ON %s BEGIN "+
+				"INSERT INTO %s (%s) VALUES (%s, 'UPDATE', new.updated_at); END",
+			historyTable, tableName, historyTable, insertColumns, insertValues,
+		),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s_delete", historyTable),
+		fmt.Sprintf(
+			"CREATE TRIGGER %s_delete AFTER DELETE ON %s BEGIN "+
+				"INSERT INTO %s (%s) VALUES (%s, 'DELETE', datetime('now')); END",
+			historyTable, tableName, historyTable, insertColumns, insertValues,
+		),
+	)
+}
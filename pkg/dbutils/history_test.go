@@ -0,0 +1,299 @@
+package dbutils_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestEnableHistory_RecordsUpdatesAndDeletes(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	ctx := context.Background()
+
+	err := dbutils.EnableHistory(ctx, db, "tenants")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// enabling history is idempotent
+	err = dbutils.EnableHistory(ctx, db, "tenants")
+	if err != nil {
+		t.Fatalf("Expected no error on second call, got %v", err)
+	}
+
+	err = dbutils.UpdateByID(ctx, db, "tenants", 1, 1, map[string]any{"tenant_name": "Acme v2"})
+	if err != nil {
+		t.Fatalf("Failed to update tenant: %v", err)
+	}
+
+	err = dbutils.DeleteByID(ctx, db, "tenants", 1)
+	if err != nil {
+		t.Fatalf("Failed to delete tenant: %v", err)
+	}
+
+	var updates, deletes int
+
+	err = db.QueryRow("SELECT count(*) FROM tenants_history WHERE history_operation = 'UPDATE'").Scan(&updates)
+	if err != nil {
+		t.Fatalf("Failed to count history: %v", err)
+	}
+
+	err = db.QueryRow("SELECT count(*) FROM tenants_history WHERE history_operation = 'DELETE'").Scan(&deletes)
+	if err != nil {
+		t.Fatalf("Failed to count history: %v", err)
+	}
+
+	if updates != 1 || deletes != 1 {
+		t.Errorf("Expected 1 update and 1 delete in history, got %d and %d", updates, deletes)
+	}
+
+	var name string
+
+	err = dbutils.GetByIDAtVersion(ctx, db, "tenants", 1, 2, map[string]any{"tenant_name": &name})
+	if err != nil {
+		t.Fatalf("Expected deleted version to be readable from history, got %v", err)
+	}
+
+	if name != "Acme v2" {
+		t.Errorf("Expected name 'Acme v2', got '%s'", name)
+	}
+}
+
+func TestEnableHistory_AddedColumns(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	ctx := context.Background()
+
+	err := dbutils.EnableHistory(ctx, db, "tenants")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// a later migration adds a column
+	if _, err := db.Exec("ALTER TABLE tenants ADD COLUMN region TEXT NOT NULL DEFAULT 'us'"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbutils.EnableHistory(ctx, db, "tenants")
+	if err != nil {
+		t.Fatalf("Expected no error on startup after the migration, got %v", err)
+	}
+
+	err = dbutils.UpdateByID(ctx, db, "tenants", 1, 1, map[string]any{"region": "eu"})
+	if err != nil {
+		t.Fatalf("Failed to update tenant: %v", err)
+	}
+
+	diffs, err := dbutils.DiffVersions(ctx, db, "tenants", 1, 1, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(diffs) != 1 || diffs[0].Field != "region" || diffs[0].From != "us" || diffs[0].To != "eu" {
+		t.Errorf("Expected region to change from us to eu, got %+v", diffs)
+	}
+}
+
+func TestEnableHistory_UnsupportedTable(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	err := dbutils.EnableHistory(context.Background(), db, "roles")
+	if !errors.Is(err, dbutils.ErrHistoryUnsupportedTable) {
+		t.Errorf("Expected ErrHistoryUnsupportedTable, got %v", err)
+	}
+
+	err = dbutils.EnableHistory(context.Background(), db, "nonexistent")
+	if !errors.Is(err, dbutils.ErrNoSuchTable) {
+		t.Errorf("Expected ErrNoSuchTable, got %v", err)
+	}
+}
+
+func TestGetByIDAtVersion(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	ctx := context.Background()
+	setupTenantHistory(t, db)
+
+	tests := []struct {
+		name     string
+		version  int64
+		expected string
+		err      error
+	}{
+		{name: "original version", version: 1, expected: "Acme"},
+		{name: "intermediate version", version: 2, expected: "Acme v2"},
+		{name: "current version", version: 3, expected: "Acme v3"},
+		{name: "unknown version", version: 4, err: dbutils.ErrRecordNotFound},
+		{name: "negative version", version: -1, err: dbutils.ErrRecordNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var name string
+
+			err := dbutils.GetByIDAtVersion(ctx, db, "tenants", 1, tt.version, map[string]any{"tenant_name": &name})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+
+			if name != tt.expected {
+				t.Errorf("Expected name '%s', got '%s'", tt.expected, name)
+			}
+		})
+	}
+}
+
+func TestGetByIDAsOf(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	ctx := context.Background()
+	setupTenantHistory(t, db)
+
+	// pin the history timeline so that assertions don't depend on wall clock time
+	_, err := db.Exec(`UPDATE tenants_history SET updated_at = '2020-01-01 00:00:00', history_valid_to = '2021-01-01 00:00:00'
+		WHERE id = 1 AND version = 1`)
+	if err != nil {
+		t.Fatalf("Failed to update history: %v", err)
+	}
+
+	_, err = db.Exec(`UPDATE tenants_history SET updated_at = '2021-01-01 00:00:00', history_valid_to = '2022-01-01 00:00:00'
+		WHERE id = 1 AND version = 2`)
+	if err != nil {
+		t.Fatalf("Failed to update history: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		asOf     time.Time
+		expected string
+		err      error
+	}{
+		{name: "before creation", asOf: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), err: dbutils.ErrRecordNotFound},
+		{name: "original version", asOf: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), expected: "Acme"},
+		{name: "intermediate version", asOf: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), expected: "Acme v2"},
+		{name: "current version", asOf: time.Now().Add(time.Hour), expected: "Acme v3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var name string
+
+			err := dbutils.GetByIDAsOf(ctx, db, "tenants", 1, tt.asOf, map[string]any{"tenant_name": &name})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+
+			if name != tt.expected {
+				t.Errorf("Expected name '%s', got '%s'", tt.expected, name)
+			}
+		})
+	}
+}
+
+func TestDiffVersions(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	setupTenantHistory(t, db)
+
+	diffs, err := dbutils.DiffVersions(context.Background(), db, "tenants", 1, 1, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(diffs) != 2 {
+		t.Fatalf("Expected 2 diffs, got %v", diffs)
+	}
+
+	if diffs[0].Field != "tenant_name" || diffs[0].From != "Acme" || diffs[0].To != "Acme v3" {
+		t.Errorf("Unexpected tenant_name diff: %+v", diffs[0])
+	}
+
+	if diffs[1].Field != "plan" || diffs[1].From != "free" || diffs[1].To != "paid" {
+		t.Errorf("Unexpected plan diff: %+v", diffs[1])
+	}
+}
+
+func TestRevertToVersion(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	ctx := context.Background()
+	setupTenantHistory(t, db)
+
+	err := dbutils.RevertToVersion(ctx, db, "tenants", 1, 2, 1)
+	if !errors.Is(err, dbutils.ErrEditConflict) {
+		t.Fatalf("Expected ErrEditConflict for stale version, got %v", err)
+	}
+
+	err = dbutils.RevertToVersion(ctx, db, "tenants", 1, 3, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var (
+		name    string
+		plan    string
+		version int64
+	)
+
+	err = dbutils.GetByID(ctx, db, "tenants", 1, map[string]any{"tenant_name": &name, "plan": &plan, "version": &version})
+	if err != nil {
+		t.Fatalf("Failed to get tenant: %v", err)
+	}
+
+	if name != "Acme" || plan != "free" {
+		t.Errorf("Expected tenant to be reverted to 'Acme'/'free', got '%s'/'%s'", name, plan)
+	}
+
+	if version != 4 {
+		t.Errorf("Expected revert to produce version 4, got %d", version)
+	}
+}
+
+// setupTenantHistory enables history on tenants and produces versions 1-3 of tenant 1.
+func setupTenantHistory(t *testing.T, db dbutils.DB) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	err := dbutils.EnableHistory(ctx, db, "tenants")
+	if err != nil {
+		t.Fatalf("Failed to enable history: %v", err)
+	}
+
+	err = dbutils.UpdateByID(ctx, db, "tenants", 1, 1, map[string]any{"tenant_name": "Acme v2"})
+	if err != nil {
+		t.Fatalf("Failed to update tenant: %v", err)
+	}
+
+	err = dbutils.UpdateByID(ctx, db, "tenants", 1, 2, map[string]any{"tenant_name": "Acme v3", "plan": "paid"})
+	if err != nil {
+		t.Fatalf("Failed to update tenant: %v", err)
+	}
+}
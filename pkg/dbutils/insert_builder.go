@@ -0,0 +1,189 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InsertBuilder builds INSERT statements.
+type InsertBuilder struct {
+	table       string
+	columns     []string
+	rows        [][]any
+	selectQuery *QueryBuilder
+	onConflict  []string
+	returning   []string
+	db          DB
+}
+
+// NewInsertBuilder creates a new InsertBuilder instance which can be used to build and execute INSERT statements.
+func NewInsertBuilder(db DB) *InsertBuilder {
+	return &InsertBuilder{
+		columns:   []string{},
+		rows:      [][]any{},
+		returning: []string{},
+		db:        db,
+	}
+}
+
+// Into sets the table to insert into.
+func (ib *InsertBuilder) Into(table string) *InsertBuilder {
+	ib.table = table
+
+	return ib
+}
+
+// Columns sets the columns to insert.
+func (ib *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	ib.columns = append(ib.columns, columns...)
+
+	return ib
+}
+
+// Values adds a row of values. Call Values once per row to insert several rows.
+func (ib *InsertBuilder) Values(values ...any) *InsertBuilder {
+	ib.rows = append(ib.rows, values)
+
+	return ib
+}
+
+// Select inserts the rows returned by query (INSERT ... SELECT).
+func (ib *InsertBuilder) Select(query *QueryBuilder) *InsertBuilder {
+	ib.selectQuery = query
+
+	return ib
+}
+
+// OnConflictDoUpdate turns the statement into an upsert. When a row with the same conflictColumns
+// already exists, the remaining columns are updated with the inserted values.
+func (ib *InsertBuilder) OnConflictDoUpdate(conflictColumns ...string) *InsertBuilder {
+	ib.onConflict = append(ib.onConflict, conflictColumns...)
+
+	return ib
+}
+
+// Returning sets the columns returned by the statement.
+func (ib *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	ib.returning = append(ib.returning, columns...)
+
+	return ib
+}
+
+// Build generates the SQL statement and returns it along with the arguments.
+func (ib *InsertBuilder) Build() (string, []interface{}) {
+	if ib.table == "" {
+		panic("Table not specified")
+	}
+
+	if (len(ib.rows) == 0) == (ib.selectQuery == nil) {
+		panic("Exactly one of Values or Select must be specified")
+	}
+
+	query := strings.Builder{}
+	args := []interface{}{}
+
+	query.WriteString("INSERT INTO ")
+	query.WriteString(ib.table)
+
+	if len(ib.columns) > 0 {
+		query.WriteString(fmt.Sprintf(" (%s)", strings.Join(ib.columns, ", ")))
+	}
+
+	if ib.selectQuery != nil {
+		selectQuery, selectArgs := ib.selectQuery.Build()
+		query.WriteString(" ")
+		query.WriteString(selectQuery)
+
+		args = append(args, selectArgs...)
+	} else {
+		rows := make([]string, 0, len(ib.rows))
+
+		for _, row := range ib.rows {
+			if len(ib.columns) > 0 && len(row) != len(ib.columns) {
+				panic(fmt.Sprintf("Expected %d values, got %d", len(ib.columns), len(row)))
+			}
+
+			rows = append(rows, fmt.Sprintf("(%s)", strings.TrimSuffix(strings.Repeat("?, ", len(row)), ", ")))
+			args = append(args, row...)
+		}
+
+		query.WriteString(" VALUES ")
+		query.WriteString(strings.Join(rows, ", "))
+	}
+
+	ib.writeOnConflict(&query)
+
+	writeReturning(&query, ib.returning)
+
+	return query.String(), args
+}
+
+func (ib *InsertBuilder) writeOnConflict(query *strings.Builder) {
+	if len(ib.onConflict) == 0 {
+		return
+	}
+
+	if ib.selectQuery != nil || len(ib.columns) == 0 {
+		panic("OnConflictDoUpdate requires Columns and Values")
+	}
+
+	updates := []string{}
+
+	for _, column := range ib.columns {
+		if !slices.Contains(ib.onConflict, column) {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+	}
+
+	// a no-op update keeps RETURNING working when only the conflict columns are inserted
+	if len(updates) == 0 {
+		for _, column := range ib.onConflict {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+	}
+
+	query.WriteString(fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s",
+		strings.Join(ib.onConflict, ", "), strings.Join(updates, ", ")))
+}
+
+// Exec executes the statement.
+func (ib *InsertBuilder) Exec() (sql.Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+
+	return ib.ExecContext(ctx)
+}
+
+// ExecContext executes the statement with the given context.
+func (ib *InsertBuilder) ExecContext(ctx context.Context) (sql.Result, error) {
+	query, args := ib.Build()
+
+	result, err := ib.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, WrapDBError(err)
+	}
+
+	return result, nil
+}
+
+// QueryContext executes the statement and calls the callback function for each row returned by RETURNING.
+func (ib *InsertBuilder) QueryContext(ctx context.Context, callback func(*sql.Rows) error) error {
+	query, args := ib.Build()
+
+	return queryRows(ctx, ib.db, query, args, callback)
+}
+
+// QueryRowContext executes the statement and binds the columns returned by RETURNING to dest.
+func (ib *InsertBuilder) QueryRowContext(ctx context.Context, dest ...any) error {
+	query, args := ib.Build()
+
+	err := ib.db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	if err != nil {
+		return WrapDBError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package dbutils_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestInsertBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		builder       *dbutils.InsertBuilder
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{
+			name: "single row",
+			builder: dbutils.NewInsertBuilder(nil).
+				Into("tenants").
+				Columns("tenant_name", "plan").
+				Values("Acme", "free"),
+			expectedQuery: "INSERT INTO tenants (tenant_name, plan) VALUES (?, ?)",
+			expectedArgs:  []interface{}{"Acme", "free"},
+		},
+		{
+			name: "multiple rows with returning",
+			builder: dbutils.NewInsertBuilder(nil).
+				Into("tenants").
+				Columns("tenant_name", "plan").
+				Values("Acme", "free").
+				Values("Flancrest", "paid").
+				Returning("id", "created_at"),
+			expectedQuery: "INSERT INTO tenants (tenant_name, plan) VALUES (?, ?), (?, ?) RETURNING id, created_at",
+			expectedArgs:  []interface{}{"Acme", "free", "Flancrest", "paid"},
+		},
+		{
+			name: "insert select",
+			builder: dbutils.NewInsertBuilder(nil).
+				Into("users").
+				Columns("user_name", "email", "tenant_id").
+				Select(dbutils.NewQueryBuilder(nil).
+					Select("user_name", "email", "?").
+					From("users").
+					Where("tenant_id = ?", 1)),
+			expectedQuery: "INSERT INTO users (user_name, email, tenant_id) SELECT user_name, email, ? FROM users WHERE (tenant_id = ?)",
+			expectedArgs:  []interface{}{1},
+		},
+		{
+			name: "upsert",
+			builder: dbutils.NewInsertBuilder(nil).
+				Into("tenants").
+				Columns("tenant_name", "plan").
+				Values("Acme", "paid").
+				OnConflictDoUpdate("tenant_name").
+				Returning("id"),
+			expectedQuery: "INSERT INTO tenants (tenant_name, plan) VALUES (?, ?) " +
+				"ON CONFLICT (tenant_name) DO UPDATE SET plan = excluded.plan RETURNING id",
+			expectedArgs: []interface{}{"Acme", "paid"},
+		},
+		{
+			name: "upsert with only conflict columns",
+			builder: dbutils.NewInsertBuilder(nil).
+				Into("tenants").
+				Columns("tenant_name").
+				Values("Acme").
+				OnConflictDoUpdate("tenant_name"),
+			expectedQuery: "INSERT INTO tenants (tenant_name) VALUES (?) " +
+				"ON CONFLICT (tenant_name) DO UPDATE SET tenant_name = excluded.tenant_name",
+			expectedArgs: []interface{}{"Acme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, args := tt.builder.Build()
+
+			if query != tt.expectedQuery {
+				t.Errorf("Expected query %q, got %q", tt.expectedQuery, query)
+			}
+
+			if !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestInsertBuilder_BuildValueCountMismatchPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+
+	dbutils.NewInsertBuilder(nil).Into("tenants").Columns("tenant_name", "plan").Values("Acme").Build()
+}
+
+func TestInsertBuilder_QueryRowContext(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	var id int64
+
+	err := dbutils.NewInsertBuilder(db).
+		Into("tenants").
+		Columns("tenant_name", "contact_email", "plan").
+		Values("Initech", "admin@initech.com", "free").
+		Returning("id").
+		QueryRowContext(context.Background(), &id)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if id != 3 {
+		t.Errorf("Expected id 3, got %d", id)
+	}
+}
@@ -0,0 +1,150 @@
+package dbutils
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupportedJSONSource is returned when a JSON column is scanned from a value that is not text.
+var ErrUnsupportedJSONSource = errors.New("unsupported JSON source")
+
+var jsonPathPattern = regexp.MustCompile(`^\$(\.[A-Za-z_][A-Za-z0-9_]*|\[\d+\])*$`)
+
+// JSON scans a JSON column into a value of type T and stores T as JSON.
+//
+//	var settings dbutils.JSON[Settings]
+//	err := dbutils.NewQueryBuilder(db).Select("settings").From("users").Where("id = ?", id).QueryRow(&settings)
+type JSON[T any] struct {
+	Val T
+}
+
+// NewJSON wraps val so that it is stored as JSON.
+func NewJSON[T any](val T) JSON[T] {
+	return JSON[T]{Val: val}
+}
+
+// Scan implements sql.Scanner. NULL scans into the zero value of T.
+func (j *JSON[T]) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		var zero T
+		j.Val = zero
+
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedJSONSource, src)
+	}
+
+	if err := json.Unmarshal(data, &j.Val); err != nil {
+		return fmt.Errorf("json scan error: %w", err)
+	}
+
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (j JSON[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.Val)
+	if err != nil {
+		return nil, fmt.Errorf("json value error: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// MarshalJSON encodes the wrapped value so that JSON[T] serializes the same as T.
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	//nolint: wrapcheck
+	return json.Marshal(j.Val)
+}
+
+// UnmarshalJSON decodes into the wrapped value.
+func (j *JSON[T]) UnmarshalJSON(data []byte) error {
+	//nolint: wrapcheck
+	return json.Unmarshal(data, &j.Val)
+}
+
+// JSONPath normalizes path to a SQLite JSON path, e.g. "address.city" becomes "$.address.city".
+// It panics if path contains anything other than object keys and array indexes since the
+// path is written into the query.
+func JSONPath(path string) string {
+	if path == "" {
+		return "$"
+	}
+
+	if !strings.HasPrefix(path, "$") {
+		path = "$." + path
+	}
+
+	if !jsonPathPattern.MatchString(path) {
+		panic(fmt.Sprintf("invalid JSON path %q", path))
+	}
+
+	return path
+}
+
+// JSONExtract returns a json_extract expression for path in column, e.g.
+// JSONExtract("users.settings", "theme") returns "json_extract(users.settings, '$.theme')".
+func JSONExtract(column, path string) string {
+	return fmt.Sprintf("json_extract(%s, '%s')", column, JSONPath(path))
+}
+
+// SelectJSON selects path in the JSON column as alias.
+func (qb *QueryBuilder) SelectJSON(column, path, alias string) *QueryBuilder {
+	qb.selectFields = append(qb.selectFields, fmt.Sprintf("%s AS %s", JSONExtract(column, path), alias))
+
+	return qb
+}
+
+// WhereJSON adds a WHERE clause comparing path in the JSON column to value.
+func (qb *QueryBuilder) WhereJSON(column, path string, value any) *QueryBuilder {
+	return qb.Where(JSONExtract(column, path)+" = ?", value)
+}
+
+// AndWhereJSON adds a WHERE clause comparing path in the JSON column to value with an AND conjunction.
+func (qb *QueryBuilder) AndWhereJSON(column, path string, value any) *QueryBuilder {
+	return qb.AndWhere(JSONExtract(column, path)+" = ?", value)
+}
+
+// OrWhereJSON adds a WHERE clause comparing path in the JSON column to value with an OR conjunction.
+func (qb *QueryBuilder) OrWhereJSON(column, path string, value any) *QueryBuilder {
+	return qb.OrWhere(JSONExtract(column, path)+" = ?", value)
+}
+
+// WhereJSONContains adds a WHERE clause matching rows whose JSON array at path contains value.
+// Use "$" as the path when the column itself is an array.
+func (qb *QueryBuilder) WhereJSONContains(column, path string, value any) *QueryBuilder {
+	return qb.Where(jsonContains(column, path), value)
+}
+
+// AndWhereJSONContains adds a WhereJSONContains clause with an AND conjunction.
+func (qb *QueryBuilder) AndWhereJSONContains(column, path string, value any) *QueryBuilder {
+	return qb.AndWhere(jsonContains(column, path), value)
+}
+
+// OrWhereJSONContains adds a WhereJSONContains clause with an OR conjunction.
+func (qb *QueryBuilder) OrWhereJSONContains(column, path string, value any) *QueryBuilder {
+	return qb.OrWhere(jsonContains(column, path), value)
+}
+
+// JoinJSONEach joins the elements of the JSON array at path in column as alias. Each element
+// is available as alias.value, e.g. JoinJSONEach(InnerJoin, "users.tags", "$", "tag").Where("tag.value = ?", "admin").
+func (qb *QueryBuilder) JoinJSONEach(joinType JoinType, column, path, alias string) *QueryBuilder {
+	qb.joins = append(qb.joins, fmt.Sprintf("%s json_each(%s, '%s') AS %s", joinType, column, JSONPath(path), alias))
+
+	return qb
+}
+
+func jsonContains(column, path string) string {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s, '%s') WHERE value = ?)", column, JSONPath(path))
+}
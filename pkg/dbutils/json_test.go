@@ -0,0 +1,158 @@
+package dbutils_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestJSONPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: "$"},
+		{path: "$", expected: "$"},
+		{path: "theme", expected: "$.theme"},
+		{path: "address.city", expected: "$.address.city"},
+		{path: "$.tags[0]", expected: "$.tags[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			if path := dbutils.JSONPath(tt.path); path != tt.expected {
+				t.Errorf("Expected path %q, got %q", tt.expected, path)
+			}
+		})
+	}
+}
+
+func TestJSONPath_InvalidPathPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+
+	dbutils.JSONPath("theme') OR 1=1 --")
+}
+
+func TestQueryBuilder_JSON(t *testing.T) {
+	t.Parallel()
+
+	query, args := dbutils.NewQueryBuilder(nil).
+		Select("users.id").
+		SelectJSON("users.settings", "theme", "theme").
+		From("users").
+		JoinJSONEach(dbutils.InnerJoin, "users.settings", "tags", "tag").
+		WhereJSON("users.settings", "address.city", "Toronto").
+		AndWhereJSONContains("users.settings", "roles", "admin").
+		AndWhere("tag.value = ?", "beta").
+		Build()
+
+	expectedQuery := "SELECT users.id, json_extract(users.settings, '$.theme') AS theme FROM users " +
+		"INNER JOIN json_each(users.settings, '$.tags') AS tag " +
+		"WHERE (json_extract(users.settings, '$.address.city') = ?) " +
+		"AND (EXISTS (SELECT 1 FROM json_each(users.settings, '$.roles') WHERE value = ?)) AND (tag.value = ?)"
+	expectedArgs := []interface{}{"Toronto", "admin", "beta"}
+
+	if query != expectedQuery {
+		t.Errorf("Expected query %q, got %q", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestQueryBuilder_ExecuteJSON(t *testing.T) {
+	t.Parallel()
+
+	type settings struct {
+		Theme string   `json:"theme"`
+		Roles []string `json:"roles"`
+	}
+
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	_, err := db.Exec("CREATE TABLE preferences (id INTEGER PRIMARY KEY, settings JSON)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dbutils.NewInsertBuilder(db).
+		Into("preferences").
+		Columns("settings").
+		Values(dbutils.NewJSON(settings{Theme: "dark", Roles: []string{"admin", "editor"}})).
+		Values(dbutils.NewJSON(settings{Theme: "light", Roles: []string{"editor"}})).
+		Values(nil).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var themes []string
+
+	err = dbutils.NewQueryBuilder(db).
+		SelectJSON("settings", "theme", "theme").
+		From("preferences").
+		WhereJSONContains("settings", "roles", "editor").
+		OrderBy("id").
+		Query(func(rows *sql.Rows) error {
+			var theme string
+			if err := rows.Scan(&theme); err != nil {
+				return err //nolint: wrapcheck
+			}
+
+			themes = append(themes, theme)
+
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(themes, []string{"dark", "light"}) {
+		t.Errorf("Expected themes [dark light], got %v", themes)
+	}
+
+	var admin dbutils.JSON[settings]
+
+	err = dbutils.NewQueryBuilder(db).
+		Select("settings").
+		From("preferences").
+		JoinJSONEach(dbutils.InnerJoin, "preferences.settings", "roles", "role").
+		Where("role.value = ?", "admin").
+		QueryRow(&admin)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := settings{Theme: "dark", Roles: []string{"admin", "editor"}}
+	if !reflect.DeepEqual(admin.Val, expected) {
+		t.Errorf("Expected %v, got %v", expected, admin.Val)
+	}
+
+	var empty dbutils.JSON[settings]
+
+	err = dbutils.NewQueryBuilder(db).Select("settings").From("preferences").Where("id = ?", 3).QueryRow(&empty)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(empty.Val, settings{}) {
+		t.Errorf("Expected zero value, got %v", empty.Val)
+	}
+}
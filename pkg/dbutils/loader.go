@@ -0,0 +1,309 @@
+package dbutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+)
+
+const (
+	loadersKey ctxKey = "loaders"
+
+	// defaultLoaderWait is how long a loader waits for more ids before issuing a batch query.
+	defaultLoaderWait = time.Millisecond
+
+	// maxIDsPerQuery keeps IN lists well below SQLite's limit on the number of bound variables.
+	maxIDsPerQuery = 500
+)
+
+// LoaderBatchFunc fetches the records with the given ids and returns them keyed by id.
+// Ids missing from the result are reported as ErrRecordNotFound.
+type LoaderBatchFunc[T any] func(ctx context.Context, ids []int64) (map[int64]T, error)
+
+// Loader coalesces lookups by id that are made within a short window into a single batch
+// call and memoizes the results. A Loader is meant to live for a single request.
+type Loader[T any] struct {
+	batchFn LoaderBatchFunc[T]
+	wait    time.Duration
+	mu      sync.Mutex
+	cache   map[int64]*loaderResult[T]
+	pending []int64
+}
+
+type loaderResult[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// NewLoader creates a Loader that batches ids requested within wait of each other.
+func NewLoader[T any](batchFn LoaderBatchFunc[T], wait time.Duration) *Loader[T] {
+	return &Loader[T]{
+		batchFn: batchFn,
+		wait:    wait,
+		cache:   make(map[int64]*loaderResult[T]),
+	}
+}
+
+// Load returns the record with the given id.
+func (l *Loader[T]) Load(ctx context.Context, id int64) (T, error) {
+	return l.enqueue(ctx, id).wait(ctx)
+}
+
+// LoadMany returns the records with the given ids keyed by id. Ids that do not exist are omitted.
+func (l *Loader[T]) LoadMany(ctx context.Context, ids []int64) (map[int64]T, error) {
+	results := make(map[int64]*loaderResult[T], len(ids))
+	for _, id := range ids {
+		results[id] = l.enqueue(ctx, id)
+	}
+
+	values := make(map[int64]T, len(results))
+
+	for id, result := range results {
+		value, err := result.wait(ctx)
+		if errors.Is(err, ErrRecordNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[id] = value
+	}
+
+	return values, nil
+}
+
+func (l *Loader[T]) enqueue(ctx context.Context, id int64) *loaderResult[T] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if result, ok := l.cache[id]; ok {
+		return result
+	}
+
+	result := &loaderResult[T]{done: make(chan struct{})}
+	l.cache[id] = result
+
+	if len(l.pending) == 0 {
+		// the batch outlives the caller's timeout, but not its cancellation
+		batchCtx := context.WithoutCancel(ctx)
+
+		time.AfterFunc(l.wait, func() {
+			l.dispatch(batchCtx)
+		})
+	}
+
+	l.pending = append(l.pending, id)
+
+	return result
+}
+
+func (l *Loader[T]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	ids := l.pending
+	l.pending = nil
+
+	results := make(map[int64]*loaderResult[T], len(ids))
+	for _, id := range ids {
+		results[id] = l.cache[id]
+	}
+	l.mu.Unlock()
+
+	values, err := l.batchFn(ctx, ids)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for id, result := range results {
+		value, ok := values[id]
+
+		switch {
+		case err != nil:
+			// don't memoize transient failures
+			result.err = err
+
+			delete(l.cache, id)
+		case !ok:
+			result.err = ErrRecordNotFound
+		default:
+			result.value = value
+		}
+
+		close(result.done)
+	}
+}
+
+func (r *loaderResult[T]) wait(ctx context.Context) (T, error) {
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+
+		return zero, fmt.Errorf("failed to load record: %w", ctx.Err())
+	}
+}
+
+// NewTableLoader creates a Loader that reads records from tableName with a single
+// SELECT ... WHERE id IN (...) query per batch. fields maps column names to
+// destinations in the given model, in the same way as GetByID.
+func NewTableLoader[T any](db DB, tableName string, fields func(model *T) map[string]any) *Loader[*T] {
+	columns := make([]string, 0)
+	for column := range fields(new(T)) {
+		columns = append(columns, column)
+	}
+
+	slices.Sort(columns)
+
+	return NewLoader(func(ctx context.Context, ids []int64) (map[int64]*T, error) {
+		return getByIDs(ctx, db, tableName, ids, columns, fields)
+	}, defaultLoaderWait)
+}
+
+// WithLoaders returns a context that memoizes table loaders created by LoadByID and LoadManyByID.
+func WithLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey, &loaderRegistry{loaders: make(map[string]any)})
+}
+
+// LoadByID gets a record by id, batching the lookup with other LoadByID calls for the same
+// table that are made concurrently. If ctx was created by WithLoaders, results are memoized
+// for the lifetime of the context, so records updated later in the request may be stale.
+func LoadByID[T any](
+	ctx context.Context,
+	db DB,
+	tableName string,
+	id int64,
+	fields func(model *T) map[string]any,
+) (*T, error) {
+	if id < 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return getTableLoader(ctx, db, tableName, fields).Load(ctx, id)
+}
+
+// LoadManyByID gets the records with the given ids keyed by id using a single query.
+// See LoadByID for memoization.
+func LoadManyByID[T any](
+	ctx context.Context,
+	db DB,
+	tableName string,
+	ids []int64,
+	fields func(model *T) map[string]any,
+) (map[int64]*T, error) {
+	return getTableLoader(ctx, db, tableName, fields).LoadMany(ctx, ids)
+}
+
+type loaderRegistry struct {
+	mu      sync.Mutex
+	loaders map[string]any
+}
+
+func getTableLoader[T any](ctx context.Context, db DB, tableName string, fields func(model *T) map[string]any) *Loader[*T] {
+	registry, ok := ctx.Value(loadersKey).(*loaderRegistry)
+	if !ok {
+		return NewTableLoader(db, tableName, fields)
+	}
+
+	key := tableName + ":" + reflect.TypeFor[T]().String()
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if loader, ok := registry.loaders[key].(*Loader[*T]); ok {
+		return loader
+	}
+
+	loader := NewTableLoader(db, tableName, fields)
+	registry.loaders[key] = loader
+
+	return loader
+}
+
+func getByIDs[T any](
+	ctx context.Context,
+	db DB,
+	tableName string,
+	ids []int64,
+	columns []string,
+	fields func(model *T) map[string]any,
+) (map[int64]*T, error) {
+	models := make(map[int64]*T, len(ids))
+
+	for chunk := range slices.Chunk(ids, maxIDsPerQuery) {
+		if err := getChunkByIDs(ctx, db, tableName, chunk, columns, fields, models); err != nil {
+			return nil, err
+		}
+	}
+
+	return models, nil
+}
+
+// getChunkByIDs adds the records with the given ids to models.
+func getChunkByIDs[T any](
+	ctx context.Context,
+	db DB,
+	tableName string,
+	ids []int64,
+	columns []string,
+	fields func(model *T) map[string]any,
+	models map[int64]*T,
+) error {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	// #nosec G201 - tableName and fields are not user input in normal usage
+	query := fmt.Sprintf(
+		"SELECT id, %s FROM %s WHERE id IN (%s)",
+		strings.Join(columns, ","),
+		tableName,
+		strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","),
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, getTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return WrapDBError(err)
+	}
+
+	defer fsutils.CloseAndPanic(rows)
+
+	for rows.Next() {
+		var id int64
+
+		model := new(T)
+		destinations := fields(model)
+
+		dest := make([]any, 0, len(columns)+1)
+		dest = append(dest, &id)
+
+		for _, column := range columns {
+			dest = append(dest, destinations[column])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return WrapDBError(err)
+		}
+
+		models[id] = model
+	}
+
+	if err := rows.Err(); err != nil {
+		return WrapDBError(err)
+	}
+
+	return nil
+}
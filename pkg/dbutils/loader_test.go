@@ -0,0 +1,230 @@
+package dbutils_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+var errBatchFailed = errors.New("batch failed")
+
+type loaderTenant struct {
+	ID   int64
+	Name string
+	Plan string
+}
+
+func loaderTenantFields(model *loaderTenant) map[string]any {
+	return map[string]any{
+		"id":          &model.ID,
+		"tenant_name": &model.Name,
+		"plan":        &model.Plan,
+	}
+}
+
+func TestLoader_CoalescesConcurrentLoads(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		batches [][]int64
+	)
+
+	loader := dbutils.NewLoader(func(_ context.Context, ids []int64) (map[int64]int64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		batches = append(batches, ids)
+		values := make(map[int64]int64, len(ids))
+
+		for _, id := range ids {
+			values[id] = id * 10
+		}
+
+		return values, nil
+	}, 10*time.Millisecond)
+
+	var wg sync.WaitGroup
+
+	for i := range 10 {
+		wg.Add(1)
+
+		go func(id int64) {
+			defer wg.Done()
+
+			value, err := loader.Load(context.Background(), id)
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+
+			if value != id*10 {
+				t.Errorf("Expected %d, got %d", id*10, value)
+			}
+		}(int64(i%5 + 1))
+	}
+
+	wg.Wait()
+
+	if len(batches) != 1 {
+		t.Fatalf("Expected 1 batch, got %d", len(batches))
+	}
+
+	slices.Sort(batches[0])
+
+	if !slices.Equal(batches[0], []int64{1, 2, 3, 4, 5}) {
+		t.Errorf("Expected batch of unique ids, got %v", batches[0])
+	}
+
+	// memoized values do not trigger another batch
+	if _, err := loader.Load(context.Background(), 3); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(batches) != 1 {
+		t.Errorf("Expected memoized load, got %d batches", len(batches))
+	}
+}
+
+func TestLoader_DoesNotMemoizeErrors(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	loader := dbutils.NewLoader(func(_ context.Context, _ []int64) (map[int64]string, error) {
+		calls++
+		if calls == 1 {
+			return nil, errBatchFailed
+		}
+
+		return map[int64]string{1: "one"}, nil
+	}, time.Millisecond)
+
+	_, err := loader.Load(context.Background(), 1)
+	if !errors.Is(err, errBatchFailed) {
+		t.Fatalf("Expected errBatchFailed, got %v", err)
+	}
+
+	value, err := loader.Load(context.Background(), 1)
+	if err != nil || value != "one" {
+		t.Errorf("Expected retry to succeed with 'one', got '%s', %v", value, err)
+	}
+
+	_, err = loader.Load(context.Background(), 2)
+	if !errors.Is(err, dbutils.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestLoadByID(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	ctx := dbutils.WithLoaders(context.Background())
+
+	tests := []struct {
+		name     string
+		id       int64
+		expected string
+		err      error
+	}{
+		{name: "existing record", id: 2, expected: "Flancrest Enterprises"},
+		{name: "missing record", id: 99, err: dbutils.ErrRecordNotFound},
+		{name: "negative id", id: -1, err: dbutils.ErrRecordNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant, err := dbutils.LoadByID(ctx, db, "tenants", tt.id, loaderTenantFields)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+
+			if tt.err == nil && tenant.Name != tt.expected {
+				t.Errorf("Expected name '%s', got '%s'", tt.expected, tenant.Name)
+			}
+		})
+	}
+}
+
+func TestLoadManyByID(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	tenants, err := dbutils.LoadManyByID(context.Background(), db, "tenants", []int64{1, 2, 1, 99}, loaderTenantFields)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(tenants) != 2 {
+		t.Fatalf("Expected 2 tenants, got %d", len(tenants))
+	}
+
+	if tenants[1].Name != "Acme" || tenants[1].Plan != "free" {
+		t.Errorf("Unexpected tenant 1: %+v", tenants[1])
+	}
+
+	if tenants[2].ID != 2 || tenants[2].Name != "Flancrest Enterprises" {
+		t.Errorf("Unexpected tenant 2: %+v", tenants[2])
+	}
+}
+
+// argCountingDB records the largest number of arguments bound to a single query.
+type argCountingDB struct {
+	*sql.DB
+	mu      sync.Mutex
+	maxArgs int
+}
+
+func (db *argCountingDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	db.mu.Lock()
+	db.maxArgs = max(db.maxArgs, len(args))
+	db.mu.Unlock()
+
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+func TestLoadManyByID_ManyIDs(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	// batches may be dispatched concurrently and each in-memory connection is a separate database
+	db.SetMaxOpenConns(1)
+
+	ids := make([]int64, 40000)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+
+	counter := &argCountingDB{DB: db}
+
+	tenants, err := dbutils.LoadManyByID(context.Background(), counter, "tenants", ids, loaderTenantFields)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(tenants) != 2 {
+		t.Fatalf("Expected 2 tenants, got %d", len(tenants))
+	}
+
+	if tenants[1].Name != "Acme" || tenants[2].Name != "Flancrest Enterprises" {
+		t.Errorf("Unexpected tenants: %+v, %+v", tenants[1], tenants[2])
+	}
+
+	// older SQLite builds allow at most 999 bound variables per statement
+	if counter.maxArgs > 999 {
+		t.Errorf("Expected at most 999 ids per query, got %d", counter.maxArgs)
+	}
+}
@@ -0,0 +1,53 @@
+package dbutils
+
+import (
+	"database/sql"
+
+	"github.com/gurch101/gowebutils/pkg/metrics"
+)
+
+// RegisterMetrics reports the connection statistics of the read and write pools to registry with a pool label.
+// Registering another pool replaces the previous one.
+func (d DBPool) RegisterMetrics(registry *metrics.Registry) {
+	pools := map[string]*sql.DB{"write": d.writeDB}
+	if d.readDB != d.writeDB {
+		pools["read"] = d.readDB
+	}
+
+	poolLabel := []string{"pool"}
+
+	gauge := func(name, help string, stat func(stats sql.DBStats) float64) {
+		registry.NewGaugeFunc(name, help, poolLabel, func(observe func(value float64, labelValues ...string)) {
+			for pool, db := range pools {
+				observe(stat(db.Stats()), pool)
+			}
+		})
+	}
+
+	counter := func(name, help string, stat func(stats sql.DBStats) float64) {
+		registry.NewCounterFunc(name, help, poolLabel, func(observe func(value float64, labelValues ...string)) {
+			for pool, db := range pools {
+				observe(stat(db.Stats()), pool)
+			}
+		})
+	}
+
+	gauge("db_connections_max_open", "Maximum number of open connections to the database.",
+		func(stats sql.DBStats) float64 { return float64(stats.MaxOpenConnections) })
+	gauge("db_connections_open", "Number of established connections, both in use and idle.",
+		func(stats sql.DBStats) float64 { return float64(stats.OpenConnections) })
+	gauge("db_connections_in_use", "Number of connections currently in use.",
+		func(stats sql.DBStats) float64 { return float64(stats.InUse) })
+	gauge("db_connections_idle", "Number of idle connections.",
+		func(stats sql.DBStats) float64 { return float64(stats.Idle) })
+	counter("db_connections_wait_total", "Number of connections waited for.",
+		func(stats sql.DBStats) float64 { return float64(stats.WaitCount) })
+	counter("db_connections_wait_duration_seconds_total", "Time spent waiting for new connections.",
+		func(stats sql.DBStats) float64 { return stats.WaitDuration.Seconds() })
+	counter("db_connections_max_idle_closed_total", "Number of connections closed due to SetMaxIdleConns.",
+		func(stats sql.DBStats) float64 { return float64(stats.MaxIdleClosed) })
+	counter("db_connections_max_idle_time_closed_total", "Number of connections closed due to SetConnMaxIdleTime.",
+		func(stats sql.DBStats) float64 { return float64(stats.MaxIdleTimeClosed) })
+	counter("db_connections_max_lifetime_closed_total", "Number of connections closed due to SetConnMaxLifetime.",
+		func(stats sql.DBStats) float64 { return float64(stats.MaxLifetimeClosed) })
+}
@@ -0,0 +1,41 @@
+package dbutils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/metrics"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	db.SetMaxOpenConns(3)
+
+	registry := metrics.NewRegistry()
+	dbutils.FromDB(db).RegisterMetrics(registry)
+
+	var b strings.Builder
+	if err := registry.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"# TYPE db_connections_max_open gauge\ndb_connections_max_open{pool=\"write\"} 3\n",
+		"# TYPE db_connections_wait_total counter\ndb_connections_wait_total{pool=\"write\"} 0\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected metrics to contain %q, got %s", expected, b.String())
+		}
+	}
+
+	if strings.Contains(b.String(), `pool="read"`) {
+		t.Errorf("expected a shared pool to be reported once, got %s", b.String())
+	}
+}
@@ -8,25 +8,63 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gurch101/gowebutils/pkg/fsutils"
 	"github.com/gurch101/gowebutils/pkg/stringutils"
 )
 
 const execTimeout = 3 * time.Second
 
 type QueryBuilder struct {
+	ctes         []commonTableExpression
 	selectFields []string
 	table        string
 	joins        []string
-	conditions   []string
-	args         []interface{}
+	conditions   conditions
 	groupBy      []string
 	orderBy      []string
 	limit        int
 	offset       int
+	unions       []compoundSelect
 	db           DB
 }
 
+type commonTableExpression struct {
+	name      string
+	recursive bool
+	query     *QueryBuilder
+}
+
+type compoundSelect struct {
+	operator string
+	query    *QueryBuilder
+}
+
+// Window describes the OVER clause of a window function.
+type Window struct {
+	PartitionBy []string
+	// OrderBy uses the same -<name> convention for descending order as QueryBuilder.OrderBy.
+	OrderBy []string
+}
+
+// String returns the window as an OVER clause.
+func (w Window) String() string {
+	clauses := make([]string, 0, 2)
+
+	if len(w.PartitionBy) > 0 {
+		clauses = append(clauses, "PARTITION BY "+strings.Join(w.PartitionBy, ", "))
+	}
+
+	if len(w.OrderBy) > 0 {
+		orderBy := make([]string, len(w.OrderBy))
+		for i, field := range w.OrderBy {
+			orderBy[i] = formatOrderBy(field)
+		}
+
+		clauses = append(clauses, "ORDER BY "+strings.Join(orderBy, ", "))
+	}
+
+	return fmt.Sprintf("OVER (%s)", strings.Join(clauses, " "))
+}
+
 type QueryOperator string
 
 const (
@@ -48,8 +86,7 @@ func NewQueryBuilder(db DB) *QueryBuilder {
 		selectFields: []string{},
 		table:        "",
 		joins:        []string{},
-		conditions:   []string{},
-		args:         []interface{}{},
+		conditions:   conditions{clauses: []string{}, args: []interface{}{}},
 		groupBy:      []string{},
 		orderBy:      []string{},
 		limit:        -1, // Default to no limit
@@ -58,6 +95,38 @@ func NewQueryBuilder(db DB) *QueryBuilder {
 	}
 }
 
+// With adds a common table expression to the query. name may include a column list, e.g. "tree(id, depth)".
+// Arguments bound in query are placed before the arguments of the outer query.
+func (qb *QueryBuilder) With(name string, query *QueryBuilder) *QueryBuilder {
+	qb.ctes = append(qb.ctes, commonTableExpression{name: name, query: query})
+
+	return qb
+}
+
+// WithRecursive adds a recursive common table expression to the query. query is typically
+// an anchor select combined with a recursive select using UnionAll.
+func (qb *QueryBuilder) WithRecursive(name string, query *QueryBuilder) *QueryBuilder {
+	qb.ctes = append(qb.ctes, commonTableExpression{name: name, recursive: true, query: query})
+
+	return qb
+}
+
+// Union combines the results of the query with query, removing duplicates.
+// ORDER BY, LIMIT and OFFSET must be set on the outer query and apply to the combined result.
+func (qb *QueryBuilder) Union(query *QueryBuilder) *QueryBuilder {
+	qb.unions = append(qb.unions, compoundSelect{operator: "UNION", query: query})
+
+	return qb
+}
+
+// UnionAll combines the results of the query with query, keeping duplicates.
+// ORDER BY, LIMIT and OFFSET must be set on the outer query and apply to the combined result.
+func (qb *QueryBuilder) UnionAll(query *QueryBuilder) *QueryBuilder {
+	qb.unions = append(qb.unions, compoundSelect{operator: "UNION ALL", query: query})
+
+	return qb
+}
+
 // Select sets the fields to be selected in the query.
 func (qb *QueryBuilder) Select(fields ...string) *QueryBuilder {
 	qb.selectFields = append(qb.selectFields, fields...)
@@ -65,6 +134,28 @@ func (qb *QueryBuilder) Select(fields ...string) *QueryBuilder {
 	return qb
 }
 
+// SelectWindow adds a window function to the selected fields, e.g. SelectWindow("sum(amount)", window, "running_total").
+func (qb *QueryBuilder) SelectWindow(function string, window Window, alias string) *QueryBuilder {
+	qb.selectFields = append(qb.selectFields, fmt.Sprintf("%s %s AS %s", function, window, alias))
+
+	return qb
+}
+
+// SelectRowNumber adds a ROW_NUMBER() window function to the selected fields.
+func (qb *QueryBuilder) SelectRowNumber(window Window, alias string) *QueryBuilder {
+	return qb.SelectWindow("ROW_NUMBER()", window, alias)
+}
+
+// SelectRank adds a RANK() window function to the selected fields.
+func (qb *QueryBuilder) SelectRank(window Window, alias string) *QueryBuilder {
+	return qb.SelectWindow("RANK()", window, alias)
+}
+
+// SelectDenseRank adds a DENSE_RANK() window function to the selected fields.
+func (qb *QueryBuilder) SelectDenseRank(window Window, alias string) *QueryBuilder {
+	return qb.SelectWindow("DENSE_RANK()", window, alias)
+}
+
 // From sets the table to be queried.
 func (qb *QueryBuilder) From(table string) *QueryBuilder {
 	qb.table = table
@@ -83,72 +174,42 @@ func (qb *QueryBuilder) Join(joinType JoinType, table, onCondition string) *Quer
 // condition should be in the format of "field = ?" or "field IN (?, ?, ?)"
 // and args should be the values to be bound to the condition.
 func (qb *QueryBuilder) Where(condition string, args ...any) *QueryBuilder {
-	if len(args) > 0 && !isNilValue(args[0]) {
-		qb.conditions = append(qb.conditions, parenthesize(condition))
-		qb.args = append(qb.args, args[0])
-	}
+	qb.conditions.where(condition, args)
 
 	return qb
 }
 
 // WhereLike adds a WHERE clause to the query with a LIKE pattern.
 func (qb *QueryBuilder) WhereLike(condition string, patternType QueryOperator, value *string) *QueryBuilder {
-	if value == nil {
-		return qb
-	}
-
-	pattern := generateLikePattern(patternType, *value)
-
-	qb.addLikeCondition(condition, "")
-	qb.args = append(qb.args, pattern)
+	qb.conditions.whereLike(condition, "", patternType, value)
 
 	return qb
 }
 
 // AndWhere adds a WHERE clause to the query with an AND conjunction.
 func (qb *QueryBuilder) AndWhere(condition string, args ...interface{}) *QueryBuilder {
-	if len(args) > 0 && !isNilValue(args[0]) {
-		qb.addCondition(condition, "AND")
-		qb.args = append(qb.args, args...)
-	}
+	qb.conditions.whereWithConjunction(condition, "AND", args)
 
 	return qb
 }
 
 // AndWhereLike adds a WHERE clause to the query with an AND conjunction and a LIKE pattern.
 func (qb *QueryBuilder) AndWhereLike(condition string, patternType QueryOperator, value *string) *QueryBuilder {
-	if value == nil {
-		return qb
-	}
-
-	pattern := generateLikePattern(patternType, *value)
-
-	qb.addLikeCondition(condition, "AND")
-	qb.args = append(qb.args, pattern)
+	qb.conditions.whereLike(condition, "AND", patternType, value)
 
 	return qb
 }
 
 // OrWhere adds a WHERE clause to the query with an OR conjunction.
 func (qb *QueryBuilder) OrWhere(condition string, args ...interface{}) *QueryBuilder {
-	if len(args) > 0 && !isNilValue(args[0]) {
-		qb.addCondition(condition, "OR")
-		qb.args = append(qb.args, args...)
-	}
+	qb.conditions.whereWithConjunction(condition, "OR", args)
 
 	return qb
 }
 
 // OrWhereLike adds a WHERE clause to the query with an OR conjunction and a LIKE pattern.
 func (qb *QueryBuilder) OrWhereLike(condition string, patternType QueryOperator, value *string) *QueryBuilder {
-	if value == nil {
-		return qb
-	}
-
-	pattern := generateLikePattern(patternType, *value)
-
-	qb.addLikeCondition(condition, "OR")
-	qb.args = append(qb.args, pattern)
+	qb.conditions.whereLike(condition, "OR", patternType, value)
 
 	return qb
 }
@@ -169,11 +230,7 @@ func (qb *QueryBuilder) OrderBy(fields ...string) *QueryBuilder {
 	}
 
 	for i, field := range fields {
-		if strings.HasPrefix(field, "-") {
-			fields[i] = stringutils.CamelToSnake(strings.TrimPrefix(field, "-")) + " DESC"
-		} else {
-			fields[i] = stringutils.CamelToSnake(field) + " ASC"
-		}
+		fields[i] = formatOrderBy(field)
 	}
 
 	qb.orderBy = append(qb.orderBy, fields...)
@@ -204,7 +261,85 @@ func (qb *QueryBuilder) Page(page, pageSize int) *QueryBuilder {
 }
 
 // Build generates the SQL query and returns it along with the arguments.
+// Arguments are ordered as they appear in the query: common table expressions, the select, then unions.
 func (qb *QueryBuilder) Build() (string, []interface{}) {
+	query := strings.Builder{}
+	args := make([]interface{}, 0, len(qb.conditions.args))
+
+	// WITH clause
+	if len(qb.ctes) > 0 {
+		query.WriteString(qb.withKeyword())
+
+		for i, cte := range qb.ctes {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+
+			cteQuery, cteArgs := cte.query.Build()
+			query.WriteString(fmt.Sprintf("%s AS (%s)", cte.name, cteQuery))
+
+			args = append(args, cteArgs...)
+		}
+
+		query.WriteString(" ")
+	}
+
+	compoundQuery, compoundArgs := qb.buildCompoundSelect()
+	query.WriteString(compoundQuery)
+
+	args = append(args, compoundArgs...)
+
+	// ORDER BY clause
+	if len(qb.orderBy) > 0 {
+		query.WriteString(" ORDER BY ")
+		query.WriteString(strings.Join(qb.orderBy, ", "))
+	}
+
+	// LIMIT and OFFSET clauses
+	if qb.limit >= 0 {
+		query.WriteString(fmt.Sprintf(" LIMIT %d", qb.limit))
+	}
+
+	if qb.offset >= 0 {
+		query.WriteString(fmt.Sprintf(" OFFSET %d", qb.offset))
+	}
+
+	return query.String(), args
+}
+
+func (qb *QueryBuilder) withKeyword() string {
+	for _, cte := range qb.ctes {
+		if cte.recursive {
+			return "WITH RECURSIVE "
+		}
+	}
+
+	return "WITH "
+}
+
+// buildCompoundSelect builds the select and any unions without ORDER BY, LIMIT and OFFSET.
+func (qb *QueryBuilder) buildCompoundSelect() (string, []interface{}) {
+	query := strings.Builder{}
+	query.WriteString(qb.buildSelect())
+
+	args := make([]interface{}, 0, len(qb.conditions.args))
+	args = append(args, qb.conditions.args...)
+
+	for _, union := range qb.unions {
+		if len(union.query.ctes) > 0 || len(union.query.orderBy) > 0 || union.query.limit >= 0 || union.query.offset >= 0 {
+			panic("WITH, ORDER BY, LIMIT and OFFSET must be set on the outer query of a UNION")
+		}
+
+		unionQuery, unionArgs := union.query.buildCompoundSelect()
+		query.WriteString(fmt.Sprintf(" %s %s", union.operator, unionQuery))
+
+		args = append(args, unionArgs...)
+	}
+
+	return query.String(), args
+}
+
+func (qb *QueryBuilder) buildSelect() string {
 	if qb.table == "" {
 		panic("Table not specified")
 	}
@@ -230,10 +365,7 @@ func (qb *QueryBuilder) Build() (string, []interface{}) {
 	}
 
 	// WHERE clause
-	if len(qb.conditions) > 0 {
-		query.WriteString(" WHERE ")
-		query.WriteString(strings.Join(qb.conditions, " "))
-	}
+	qb.conditions.writeTo(&query)
 
 	// GROUP BY clause
 	if len(qb.groupBy) > 0 {
@@ -241,22 +373,7 @@ func (qb *QueryBuilder) Build() (string, []interface{}) {
 		query.WriteString(strings.Join(qb.groupBy, ", "))
 	}
 
-	// ORDER BY clause
-	if len(qb.orderBy) > 0 {
-		query.WriteString(" ORDER BY ")
-		query.WriteString(strings.Join(qb.orderBy, ", "))
-	}
-
-	// LIMIT and OFFSET clauses
-	if qb.limit >= 0 {
-		query.WriteString(fmt.Sprintf(" LIMIT %d", qb.limit))
-	}
-
-	if qb.offset >= 0 {
-		query.WriteString(fmt.Sprintf(" OFFSET %d", qb.offset))
-	}
-
-	return query.String(), qb.args
+	return query.String()
 }
 
 // Query executes the query and calls the callback function for each row.
@@ -271,25 +388,7 @@ func (qb *QueryBuilder) Query(callback func(*sql.Rows) error) error {
 func (qb *QueryBuilder) QueryContext(ctx context.Context, callback func(*sql.Rows) error) error {
 	query, args := qb.Build()
 
-	rows, err := qb.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return fmt.Errorf("query builder exec error: %w", err)
-	}
-
-	defer fsutils.CloseAndPanic(rows)
-
-	for rows.Next() {
-		if err := callback(rows); err != nil {
-			return err
-		}
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return fmt.Errorf("query builder rows error: %w", err)
-	}
-
-	return nil
+	return queryRows(ctx, qb.db, query, args, callback)
 }
 
 // Query executes the query and binds the results to the provided destination.
@@ -327,6 +426,15 @@ func isNilValue(v any) bool {
 	return false
 }
 
+// formatOrderBy converts -<name> to "<name> DESC" and <name> to "<name> ASC".
+func formatOrderBy(field string) string {
+	if strings.HasPrefix(field, "-") {
+		return stringutils.CamelToSnake(strings.TrimPrefix(field, "-")) + " DESC"
+	}
+
+	return stringutils.CamelToSnake(field) + " ASC"
+}
+
 func parenthesize(s string) string {
 	return fmt.Sprintf("(%s)", s)
 }
@@ -343,17 +451,3 @@ func generateLikePattern(patternType QueryOperator, value string) string {
 		panic("Invalid pattern type: use 'starts_with', 'ends_with', or 'contains'")
 	}
 }
-
-func (qb *QueryBuilder) addLikeCondition(condition, conjunction string) {
-	qb.addCondition(condition+" LIKE ?", conjunction)
-}
-
-func (qb *QueryBuilder) addCondition(condition, conjunction string) {
-	if len(qb.conditions) > 0 {
-		lastConditionIndex := len(qb.conditions) - 1
-		formattedCondition := fmt.Sprintf("%s %s (%s)", qb.conditions[lastConditionIndex], conjunction, condition)
-		qb.conditions[lastConditionIndex] = formattedCondition
-	} else {
-		qb.conditions = append(qb.conditions, parenthesize(condition))
-	}
-}
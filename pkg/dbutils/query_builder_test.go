@@ -294,3 +294,173 @@ func TestQueryBuilder_QueryRowNoRow(t *testing.T) {
 		t.Errorf("Expected no record error, got %v", err)
 	}
 }
+
+func TestQueryBuilder_With(t *testing.T) {
+	t.Parallel()
+
+	activeTenants := dbutils.NewQueryBuilder(nil).Select("id").From("tenants").Where("is_active = ?", true)
+	paidTenants := dbutils.NewQueryBuilder(nil).Select("id").From("tenants").Where("plan = ?", "paid")
+
+	qb := dbutils.NewQueryBuilder(nil).
+		With("active_tenants", activeTenants).
+		With("paid_tenants", paidTenants).
+		Select("users.id").
+		From("users").
+		Join(dbutils.InnerJoin, "active_tenants", "active_tenants.id = users.tenant_id").
+		Where("users.user_name = ?", "admin").
+		Limit(10)
+	query, args := qb.Build()
+
+	expectedQuery := "WITH active_tenants AS (SELECT id FROM tenants WHERE (is_active = ?)), " +
+		"paid_tenants AS (SELECT id FROM tenants WHERE (plan = ?)) " +
+		"SELECT users.id FROM users INNER JOIN active_tenants ON active_tenants.id = users.tenant_id " +
+		"WHERE (users.user_name = ?) LIMIT 10"
+	expectedArgs := []interface{}{true, "paid", "admin"}
+
+	if query != expectedQuery {
+		t.Errorf("Expected query %q, got %q", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestQueryBuilder_Union(t *testing.T) {
+	t.Parallel()
+
+	qb := dbutils.NewQueryBuilder(nil).
+		Select("email").
+		From("users").
+		Where("tenant_id = ?", 1).
+		Union(dbutils.NewQueryBuilder(nil).Select("contact_email").From("tenants").Where("id = ?", 2)).
+		UnionAll(dbutils.NewQueryBuilder(nil).Select("contact_email").From("tenants").Where("id = ?", 3)).
+		OrderBy("email").
+		Limit(5)
+	query, args := qb.Build()
+
+	expectedQuery := "SELECT email FROM users WHERE (tenant_id = ?) " +
+		"UNION SELECT contact_email FROM tenants WHERE (id = ?) " +
+		"UNION ALL SELECT contact_email FROM tenants WHERE (id = ?) " +
+		"ORDER BY email ASC LIMIT 5"
+	expectedArgs := []interface{}{1, 2, 3}
+
+	if query != expectedQuery {
+		t.Errorf("Expected query %q, got %q", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestQueryBuilder_UnionWithLimitPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic for LIMIT on union member")
+		}
+	}()
+
+	dbutils.NewQueryBuilder(nil).
+		From("users").
+		Union(dbutils.NewQueryBuilder(nil).From("tenants").Limit(1)).
+		Build()
+}
+
+func TestQueryBuilder_SelectWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		qb            *dbutils.QueryBuilder
+		expectedQuery string
+	}{
+		{
+			name: "row number",
+			qb: dbutils.NewQueryBuilder(nil).Select("id").
+				SelectRowNumber(dbutils.Window{PartitionBy: []string{"tenant_id"}, OrderBy: []string{"-createdAt"}}, "rn").
+				From("users"),
+			expectedQuery: "SELECT id, ROW_NUMBER() OVER (PARTITION BY tenant_id ORDER BY created_at DESC) AS rn FROM users",
+		},
+		{
+			name:          "rank",
+			qb:            dbutils.NewQueryBuilder(nil).SelectRank(dbutils.Window{OrderBy: []string{"plan"}}, "plan_rank").From("tenants"),
+			expectedQuery: "SELECT RANK() OVER (ORDER BY plan ASC) AS plan_rank FROM tenants",
+		},
+		{
+			name:          "dense rank",
+			qb:            dbutils.NewQueryBuilder(nil).SelectDenseRank(dbutils.Window{OrderBy: []string{"plan"}}, "r").From("tenants"),
+			expectedQuery: "SELECT DENSE_RANK() OVER (ORDER BY plan ASC) AS r FROM tenants",
+		},
+		{
+			name:          "aggregate over partition",
+			qb:            dbutils.NewQueryBuilder(nil).SelectWindow("count(*)", dbutils.Window{PartitionBy: []string{"tenant_id"}}, "c").From("users"),
+			expectedQuery: "SELECT count(*) OVER (PARTITION BY tenant_id) AS c FROM users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, _ := tt.qb.Build()
+			if query != tt.expectedQuery {
+				t.Errorf("Expected query %q, got %q", tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestQueryBuilder_ExecuteRecursiveWithWindow(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	// walk tenants by consecutive id starting from tenant 1
+	tree := dbutils.NewQueryBuilder(nil).
+		Select("id", "0").
+		From("tenants").
+		Where("id = ?", 1).
+		UnionAll(dbutils.NewQueryBuilder(nil).
+			Select("tenants.id", "tree.depth + 1").
+			From("tenants").
+			Join(dbutils.InnerJoin, "tree", "tenants.id = tree.id + 1").
+			Where("tree.depth < ?", 5))
+
+	type node struct {
+		ID    int64
+		Depth int
+		Rank  int
+	}
+
+	var nodes []node
+
+	err := dbutils.NewQueryBuilder(db).
+		WithRecursive("tree(id, depth)", tree).
+		Select("id", "depth").
+		SelectRowNumber(dbutils.Window{OrderBy: []string{"-depth"}}, "rn").
+		From("tree").
+		OrderBy("id").
+		Query(func(rows *sql.Rows) error {
+			var n node
+			if err := rows.Scan(&n.ID, &n.Depth, &n.Rank); err != nil {
+				return err //nolint: wrapcheck
+			}
+
+			nodes = append(nodes, n)
+
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []node{{ID: 1, Depth: 0, Rank: 2}, {ID: 2, Depth: 1, Rank: 1}}
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, got %v", expected, nodes)
+	}
+}
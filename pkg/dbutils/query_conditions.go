@@ -0,0 +1,95 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+)
+
+// conditions is the WHERE clause engine shared by the query and statement builders.
+// Conditions whose first argument is nil are skipped.
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+func (c *conditions) where(condition string, args []any) {
+	if len(args) > 0 && !isNilValue(args[0]) {
+		c.clauses = append(c.clauses, parenthesize(condition))
+		c.args = append(c.args, args[0])
+	}
+}
+
+func (c *conditions) whereWithConjunction(condition, conjunction string, args []any) {
+	if len(args) > 0 && !isNilValue(args[0]) {
+		c.addCondition(condition, conjunction)
+		c.args = append(c.args, args...)
+	}
+}
+
+func (c *conditions) whereLike(condition, conjunction string, patternType QueryOperator, value *string) {
+	if value == nil {
+		return
+	}
+
+	pattern := generateLikePattern(patternType, *value)
+
+	c.addCondition(condition+" LIKE ?", conjunction)
+	c.args = append(c.args, pattern)
+}
+
+func (c *conditions) addCondition(condition, conjunction string) {
+	if len(c.clauses) > 0 {
+		lastConditionIndex := len(c.clauses) - 1
+		formattedCondition := fmt.Sprintf("%s %s (%s)", c.clauses[lastConditionIndex], conjunction, condition)
+		c.clauses[lastConditionIndex] = formattedCondition
+	} else {
+		c.clauses = append(c.clauses, parenthesize(condition))
+	}
+}
+
+func (c *conditions) isEmpty() bool {
+	return len(c.clauses) == 0
+}
+
+// writeTo writes the WHERE clause, if any, to query.
+func (c *conditions) writeTo(query *strings.Builder) {
+	if len(c.clauses) > 0 {
+		query.WriteString(" WHERE ")
+		query.WriteString(strings.Join(c.clauses, " "))
+	}
+}
+
+// queryRows runs query and calls callback for each row.
+func queryRows(ctx context.Context, db DB, query string, args []any, callback func(*sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query builder exec error: %w", err)
+	}
+
+	defer fsutils.CloseAndPanic(rows)
+
+	for rows.Next() {
+		if err := callback(rows); err != nil {
+			return err
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("query builder rows error: %w", err)
+	}
+
+	return nil
+}
+
+// writeReturning writes the RETURNING clause, if any, to query.
+func writeReturning(query *strings.Builder, columns []string) {
+	if len(columns) > 0 {
+		query.WriteString(" RETURNING ")
+		query.WriteString(strings.Join(columns, ", "))
+	}
+}
@@ -0,0 +1,160 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const queryTrackerKey ctxKey = "query_tracker"
+
+var (
+	stringLiteralPattern = regexp.MustCompile(`'(?:[^']|'')*'`)
+	placeholderPattern   = regexp.MustCompile(`\$\d+`)
+	numberLiteralPattern = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	inListPattern        = regexp.MustCompile(`(?i)\bin\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whitespacePattern    = regexp.MustCompile(`\s+`)
+)
+
+// QueryTracker counts the queries issued with a context so that N+1 query patterns
+// can be detected in development and tests.
+type QueryTracker struct {
+	mu        sync.Mutex
+	threshold int
+	total     int
+	counts    map[string]int
+}
+
+// NewQueryTracker creates a QueryTracker that logs a warning the first time a query
+// fingerprint is issued more than threshold times. A threshold <= 0 disables warnings.
+func NewQueryTracker(threshold int) *QueryTracker {
+	return &QueryTracker{threshold: threshold, counts: make(map[string]int)}
+}
+
+// ContextWithQueryTracker returns a context whose queries are recorded by tracker.
+// Queries issued through DBPool and WithTransaction with the returned context are tracked.
+func ContextWithQueryTracker(ctx context.Context, tracker *QueryTracker) context.Context {
+	return context.WithValue(ctx, queryTrackerKey, tracker)
+}
+
+// QueryTrackerFromContext returns the QueryTracker in ctx, if any.
+func QueryTrackerFromContext(ctx context.Context) (*QueryTracker, bool) {
+	tracker, ok := ctx.Value(queryTrackerKey).(*QueryTracker)
+
+	return tracker, ok
+}
+
+// Record records a query.
+func (t *QueryTracker) Record(ctx context.Context, query string) {
+	fingerprint := FingerprintQuery(query)
+
+	t.mu.Lock()
+	t.total++
+	t.counts[fingerprint]++
+	count := t.counts[fingerprint]
+	t.mu.Unlock()
+
+	if t.threshold > 0 && count == t.threshold+1 {
+		slog.WarnContext(ctx, "possible N+1 query", "fingerprint", fingerprint, "threshold", t.threshold)
+	}
+}
+
+// Count returns the number of queries recorded.
+func (t *QueryTracker) Count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.total
+}
+
+// Fingerprints returns the number of times each query fingerprint was recorded.
+func (t *QueryTracker) Fingerprints() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[string]int, len(t.counts))
+	for fingerprint, count := range t.counts {
+		counts[fingerprint] = count
+	}
+
+	return counts
+}
+
+// FingerprintQuery normalizes a query by replacing literals and placeholders with ? so that
+// queries that differ only in their arguments share a fingerprint.
+func FingerprintQuery(query string) string {
+	fingerprint := stringLiteralPattern.ReplaceAllString(query, "?")
+	fingerprint = placeholderPattern.ReplaceAllString(fingerprint, "?")
+	fingerprint = numberLiteralPattern.ReplaceAllString(fingerprint, "?")
+	fingerprint = inListPattern.ReplaceAllString(fingerprint, "IN (?)")
+	fingerprint = whitespacePattern.ReplaceAllString(fingerprint, " ")
+
+	return strings.ToLower(strings.TrimSpace(fingerprint))
+}
+
+func recordQuery(ctx context.Context, query string) {
+	if tracker, ok := QueryTrackerFromContext(ctx); ok {
+		tracker.Record(ctx, query)
+	}
+}
+
+// trackedTx records and traces the queries issued in a transaction. Queries are recorded if the transaction
+// was started with a tracked context.
+type trackedTx struct {
+	*sql.Tx
+	tracker *QueryTracker
+}
+
+func (t trackedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	t.record(ctx, query)
+
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	result, err := t.Tx.ExecContext(ctx, query, args...)
+	span.RecordError(err)
+
+	//nolint: wrapcheck
+	return result, err
+}
+
+func (t trackedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	t.record(ctx, query)
+
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	rows, err := t.Tx.QueryContext(ctx, query, args...)
+	span.RecordError(err)
+
+	//nolint: wrapcheck
+	return rows, err
+}
+
+func (t trackedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	t.record(ctx, query)
+
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	row := t.Tx.QueryRowContext(ctx, query, args...)
+	span.RecordError(row.Err())
+
+	return row
+}
+
+func (t trackedTx) record(ctx context.Context, query string) {
+	if t.tracker != nil {
+		t.tracker.Record(ctx, query)
+	}
+}
+
+// trackTx wraps tx so that its queries are traced, and recorded if ctx carries a QueryTracker.
+func trackTx(ctx context.Context, tx *sql.Tx) DB {
+	tracker, _ := QueryTrackerFromContext(ctx)
+
+	return trackedTx{Tx: tx, tracker: tracker}
+}
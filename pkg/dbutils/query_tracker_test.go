@@ -0,0 +1,96 @@
+package dbutils_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestFingerprintQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "placeholders",
+			query:    "SELECT id FROM users WHERE id = ?",
+			expected: "select id from users where id = ?",
+		},
+		{
+			name:     "numbered placeholders",
+			query:    "SELECT id FROM users WHERE id = $1 AND tenant_id = $2",
+			expected: "select id from users where id = ? and tenant_id = ?",
+		},
+		{
+			name:     "literals",
+			query:    "SELECT id FROM users WHERE name = 'O''Brien' AND age > 21.5",
+			expected: "select id from users where name = ? and age > ?",
+		},
+		{
+			name:     "in lists",
+			query:    "SELECT id FROM users WHERE id IN (?, ?, ?)",
+			expected: "select id from users where id in (?)",
+		},
+		{
+			name:     "identifiers with digits",
+			query:    "SELECT some_int64 FROM t1",
+			expected: "select some_int64 from t1",
+		},
+		{
+			name:     "whitespace",
+			query:    "SELECT id\n\tFROM   users",
+			expected: "select id from users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := dbutils.FingerprintQuery(tt.query); actual != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestQueryTracker(t *testing.T) {
+	t.Parallel()
+
+	db := dbutils.FromDB(testutils.SetupTestDB(t))
+	defer db.Close()
+
+	tracker := dbutils.NewQueryTracker(1)
+	ctx := dbutils.ContextWithQueryTracker(context.Background(), tracker)
+
+	for _, id := range []int64{1, 2} {
+		if !dbutils.Exists(ctx, db, "tenants", id) {
+			t.Fatalf("Expected tenant %d to exist", id)
+		}
+	}
+
+	err := dbutils.WithTransaction(ctx, db, func(tx dbutils.DB) error {
+		return dbutils.UpdateByID(ctx, tx, "tenants", 1, 1, map[string]any{"tenant_name": "Tracked"})
+	})
+	if err != nil {
+		t.Fatalf("Failed to update tenant: %v", err)
+	}
+
+	// untracked contexts are not recorded
+	dbutils.Exists(context.Background(), db, "tenants", 1)
+
+	if tracker.Count() != 3 {
+		t.Errorf("Expected 3 queries, got %d: %v", tracker.Count(), tracker.Fingerprints())
+	}
+
+	// both exists checks share a fingerprint
+	fingerprints := tracker.Fingerprints()
+	if len(fingerprints) != 2 {
+		t.Errorf("Expected 2 fingerprints, got %v", fingerprints)
+	}
+}
@@ -8,6 +8,7 @@ import (
 )
 
 // DBPool is a wrapper around a read/write database connection pool.
+// Context-aware methods run inside the unit of work carried by the context, if any.
 type DBPool struct {
 	// DB is the read/write database connection pool.
 	writeDB *sql.DB
@@ -57,7 +58,7 @@ func (d DBPool) ReadDB() *sql.DB {
 
 // WithTransaction executes a callback function within a db transaction.
 func (d DBPool) WithTransaction(ctx context.Context, callback func(tx DB) error) error {
-	return WithTransaction(ctx, d.writeDB, callback)
+	return WithTransaction(ctx, &d, callback)
 }
 
 // Query executes a query with the given arguments.
@@ -68,8 +69,26 @@ func (d DBPool) Query(query string, args ...any) (*sql.Rows, error) {
 
 // QueryContext executes a query with the given context and arguments.
 func (d DBPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	recordQuery(ctx, query)
+
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	if tx, ok := TxFromContext(ctx); ok {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = d.readDB.QueryContext(ctx, query, args...)
+	}
+
+	span.RecordError(err)
+
 	//nolint: wrapcheck
-	return d.readDB.QueryContext(ctx, query, args...)
+	return rows, err
 }
 
 // QueryRow executes a query with the given arguments and returns a single row.
@@ -79,7 +98,22 @@ func (d DBPool) QueryRow(query string, args ...any) *sql.Row {
 
 // QueryRowContext executes a query with the given context and arguments and returns a single row.
 func (d DBPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	return d.readDB.QueryRowContext(ctx, query, args...)
+	recordQuery(ctx, query)
+
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	var row *sql.Row
+
+	if tx, ok := TxFromContext(ctx); ok {
+		row = tx.QueryRowContext(ctx, query, args...)
+	} else {
+		row = d.readDB.QueryRowContext(ctx, query, args...)
+	}
+
+	span.RecordError(row.Err())
+
+	return row
 }
 
 // Exec executes a query with the given arguments.
@@ -90,6 +124,24 @@ func (d DBPool) Exec(query string, args ...interface{}) (sql.Result, error) {
 
 // ExecContext executes a query with the given context and arguments.
 func (d DBPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	recordQuery(ctx, query)
+
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	var (
+		result sql.Result
+		err    error
+	)
+
+	if tx, ok := TxFromContext(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		result, err = d.writeDB.ExecContext(ctx, query, args...)
+	}
+
+	span.RecordError(err)
+
 	//nolint: wrapcheck
-	return d.writeDB.ExecContext(ctx, query, args...)
+	return result, err
 }
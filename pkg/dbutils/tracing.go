@@ -0,0 +1,34 @@
+package dbutils
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/tracing"
+)
+
+// startQuerySpan starts a client span for query with the default tracer.
+func startQuerySpan(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	if tracing.Default() == nil {
+		return ctx, nil
+	}
+
+	operation := queryOperation(query)
+
+	return tracing.Start(ctx, operation, tracing.WithKind(tracing.SpanKindClient), tracing.WithAttributes(
+		slog.String("db.system", "sqlite"),
+		slog.String("db.operation.name", operation),
+		slog.String("db.query.text", query),
+	))
+}
+
+// queryOperation returns the first keyword of query, e.g. SELECT.
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "sqlite"
+	}
+
+	return strings.ToUpper(fields[0])
+}
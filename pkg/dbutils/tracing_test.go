@@ -0,0 +1,69 @@
+package dbutils_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+	"github.com/gurch101/gowebutils/pkg/tracing"
+)
+
+func TestQuerySpans(t *testing.T) {
+	recorder := testutils.SetupTestTracer(t)
+
+	db := dbutils.FromDB(testutils.SetupTestDB(t))
+	defer db.Close()
+
+	ctx, parent := tracing.Start(context.Background(), "request")
+
+	var name string
+
+	err := dbutils.NewQueryBuilder(db).Select("tenant_name").From("tenants").Where("id = ?", 1).
+		QueryRowContext(ctx, &name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbutils.WithTransaction(ctx, db, func(tx dbutils.DB) error {
+		return dbutils.UpdateByID(ctx, tx, "tenants", 1, 1, map[string]any{"tenant_name": "Traced"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.ExecContext(ctx, "UPDATE missing_table SET id = 1")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	parent.End()
+
+	selects := recorder.SpansNamed("SELECT")
+	if len(selects) != 1 {
+		t.Fatalf("expected 1 SELECT span, got %v", recorder.Spans())
+	}
+
+	if selects[0].ParentSpanID != parent.SpanContext().SpanID || selects[0].Kind != tracing.SpanKindClient {
+		t.Errorf("unexpected SELECT span %+v", selects[0])
+	}
+
+	updates := recorder.SpansNamed("UPDATE")
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 UPDATE spans, got %v", recorder.Spans())
+	}
+
+	if updates[0].Status.Code != tracing.StatusUnset {
+		t.Errorf("expected the update in the transaction to succeed, got %v", updates[0].Status)
+	}
+
+	if updates[1].Status.Code != tracing.StatusError {
+		t.Errorf("expected the failed update to be an error, got %v", updates[1].Status)
+	}
+
+	for _, attr := range updates[1].Attributes {
+		if attr.Key == "db.query.text" && attr.Value.String() != "UPDATE missing_table SET id = 1" {
+			t.Errorf("unexpected query %s", attr.Value)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+const unitOfWorkKey ctxKey = "unit_of_work"
+
+// UnitOfWork is a transaction that is shared by every DBPool call made with its context.
+type UnitOfWork struct {
+	tx          *sql.Tx
+	mu          sync.Mutex
+	afterCommit []func()
+	committed   bool
+}
+
+// BeginUnitOfWork starts a transaction on the write pool and returns a context carrying it.
+// While the returned context is used, DBPool queries, QueryBuilder and WithTransaction
+// run inside the unit of work instead of acquiring their own connections.
+func BeginUnitOfWork(ctx context.Context, db *DBPool) (*UnitOfWork, context.Context, error) {
+	tx, err := db.WriteDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctx, fmt.Errorf("failed to begin unit of work: %w", err)
+	}
+
+	uow := &UnitOfWork{tx: tx}
+
+	return uow, context.WithValue(ctx, unitOfWorkKey, uow), nil
+}
+
+// Commit commits the unit of work and then runs any callbacks registered with AfterCommit.
+func (u *UnitOfWork) Commit() error {
+	if err := u.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit unit of work: %w", err)
+	}
+
+	u.mu.Lock()
+	callbacks := u.afterCommit
+	u.afterCommit = nil
+	u.committed = true
+	u.mu.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+
+	return nil
+}
+
+// Rollback rolls back the unit of work and discards any callbacks registered with AfterCommit.
+func (u *UnitOfWork) Rollback() error {
+	u.mu.Lock()
+	u.afterCommit = nil
+	u.mu.Unlock()
+
+	if err := u.tx.Rollback(); err != nil {
+		return fmt.Errorf("failed to rollback unit of work: %w", err)
+	}
+
+	return nil
+}
+
+// AfterCommit defers a side effect, such as sending an email, until the unit of work
+// in ctx commits. The callback is dropped if the unit of work rolls back. If ctx does not
+// carry a unit of work or the unit of work has already committed, the callback runs immediately.
+func AfterCommit(ctx context.Context, callback func()) {
+	uow, ok := unitOfWorkFromContext(ctx)
+	if !ok {
+		callback()
+
+		return
+	}
+
+	uow.mu.Lock()
+
+	if uow.committed {
+		uow.mu.Unlock()
+		callback()
+
+		return
+	}
+
+	defer uow.mu.Unlock()
+
+	uow.afterCommit = append(uow.afterCommit, callback)
+}
+
+// TxFromContext returns the transaction of the unit of work in ctx, if any.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	uow, ok := unitOfWorkFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	return uow.tx, true
+}
+
+func unitOfWorkFromContext(ctx context.Context) (*UnitOfWork, bool) {
+	uow, ok := ctx.Value(unitOfWorkKey).(*UnitOfWork)
+
+	return uow, ok
+}
@@ -0,0 +1,210 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// ErrNoUpdateFilters is returned when an UpdateBuilder without WHERE conditions is executed.
+var ErrNoUpdateFilters = errors.New("no filters provided")
+
+// UpdateBuilder builds UPDATE statements.
+type UpdateBuilder struct {
+	table      string
+	sets       []string
+	setArgs    []any
+	from       string
+	joinOn     string
+	conditions conditions
+	returning  []string
+	db         DB
+}
+
+// NewUpdateBuilder creates a new UpdateBuilder instance which can be used to build and execute UPDATE statements.
+func NewUpdateBuilder(db DB) *UpdateBuilder {
+	return &UpdateBuilder{
+		sets:       []string{},
+		setArgs:    []any{},
+		conditions: conditions{clauses: []string{}, args: []interface{}{}},
+		returning:  []string{},
+		db:         db,
+	}
+}
+
+// Table sets the table to update.
+func (ub *UpdateBuilder) Table(table string) *UpdateBuilder {
+	ub.table = table
+
+	return ub
+}
+
+// Set sets column to value.
+func (ub *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	ub.sets = append(ub.sets, column+" = ?")
+	ub.setArgs = append(ub.setArgs, value)
+
+	return ub
+}
+
+// SetExpr adds an assignment expression, e.g. SetExpr("count = count + ?", 1).
+func (ub *UpdateBuilder) SetExpr(expression string, args ...any) *UpdateBuilder {
+	ub.sets = append(ub.sets, expression)
+	ub.setArgs = append(ub.setArgs, args...)
+
+	return ub
+}
+
+// From joins another table into the update (UPDATE ... FROM). condition relates the joined
+// table to the updated table and is added to the WHERE clause, e.g.
+// From("tenants", "users.tenant_id = tenants.id"). It doesn't count as a filter, so Where must
+// still be called.
+func (ub *UpdateBuilder) From(table, condition string) *UpdateBuilder {
+	ub.from = table
+	ub.joinOn = condition
+
+	return ub
+}
+
+// Where adds a WHERE clause to the statement. See QueryBuilder.Where.
+func (ub *UpdateBuilder) Where(condition string, args ...any) *UpdateBuilder {
+	ub.conditions.where(condition, args)
+
+	return ub
+}
+
+// WhereLike adds a WHERE clause to the statement with a LIKE pattern.
+func (ub *UpdateBuilder) WhereLike(condition string, patternType QueryOperator, value *string) *UpdateBuilder {
+	ub.conditions.whereLike(condition, "", patternType, value)
+
+	return ub
+}
+
+// AndWhere adds a WHERE clause to the statement with an AND conjunction.
+func (ub *UpdateBuilder) AndWhere(condition string, args ...any) *UpdateBuilder {
+	ub.conditions.whereWithConjunction(condition, "AND", args)
+
+	return ub
+}
+
+// AndWhereLike adds a WHERE clause to the statement with an AND conjunction and a LIKE pattern.
+func (ub *UpdateBuilder) AndWhereLike(condition string, patternType QueryOperator, value *string) *UpdateBuilder {
+	ub.conditions.whereLike(condition, "AND", patternType, value)
+
+	return ub
+}
+
+// OrWhere adds a WHERE clause to the statement with an OR conjunction.
+func (ub *UpdateBuilder) OrWhere(condition string, args ...any) *UpdateBuilder {
+	ub.conditions.whereWithConjunction(condition, "OR", args)
+
+	return ub
+}
+
+// OrWhereLike adds a WHERE clause to the statement with an OR conjunction and a LIKE pattern.
+func (ub *UpdateBuilder) OrWhereLike(condition string, patternType QueryOperator, value *string) *UpdateBuilder {
+	ub.conditions.whereLike(condition, "OR", patternType, value)
+
+	return ub
+}
+
+// Returning sets the columns returned by the statement.
+func (ub *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	ub.returning = append(ub.returning, columns...)
+
+	return ub
+}
+
+// Build generates the SQL statement and returns it along with the arguments.
+func (ub *UpdateBuilder) Build() (string, []interface{}) {
+	if ub.table == "" {
+		panic("Table not specified")
+	}
+
+	if len(ub.sets) == 0 {
+		panic("No columns to set")
+	}
+
+	query := strings.Builder{}
+
+	query.WriteString("UPDATE ")
+	query.WriteString(ub.table)
+	query.WriteString(" SET ")
+	query.WriteString(strings.Join(ub.sets, ", "))
+
+	if ub.from != "" {
+		query.WriteString(" FROM ")
+		query.WriteString(ub.from)
+		query.WriteString(" WHERE ")
+		query.WriteString(parenthesize(ub.joinOn))
+
+		if !ub.conditions.isEmpty() {
+			query.WriteString(" AND (")
+			query.WriteString(strings.Join(ub.conditions.clauses, " "))
+			query.WriteString(")")
+		}
+	} else {
+		ub.conditions.writeTo(&query)
+	}
+
+	writeReturning(&query, ub.returning)
+
+	args := make([]interface{}, 0, len(ub.setArgs)+len(ub.conditions.args))
+	args = append(args, ub.setArgs...)
+	args = append(args, ub.conditions.args...)
+
+	return query.String(), args
+}
+
+// Exec executes the statement.
+func (ub *UpdateBuilder) Exec() (sql.Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+
+	return ub.ExecContext(ctx)
+}
+
+// ExecContext executes the statement with the given context. ErrNoUpdateFilters is returned
+// if no WHERE conditions were added, since conditions with nil arguments are skipped.
+func (ub *UpdateBuilder) ExecContext(ctx context.Context) (sql.Result, error) {
+	if ub.conditions.isEmpty() {
+		return nil, ErrNoUpdateFilters
+	}
+
+	query, args := ub.Build()
+
+	result, err := ub.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, WrapDBError(err)
+	}
+
+	return result, nil
+}
+
+// QueryContext executes the statement and calls the callback function for each row returned by RETURNING.
+func (ub *UpdateBuilder) QueryContext(ctx context.Context, callback func(*sql.Rows) error) error {
+	if ub.conditions.isEmpty() {
+		return ErrNoUpdateFilters
+	}
+
+	query, args := ub.Build()
+
+	return queryRows(ctx, ub.db, query, args, callback)
+}
+
+// QueryRowContext executes the statement and binds the columns returned by RETURNING to dest.
+func (ub *UpdateBuilder) QueryRowContext(ctx context.Context, dest ...any) error {
+	if ub.conditions.isEmpty() {
+		return ErrNoUpdateFilters
+	}
+
+	query, args := ub.Build()
+
+	err := ub.db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	if err != nil {
+		return WrapDBError(err)
+	}
+
+	return nil
+}
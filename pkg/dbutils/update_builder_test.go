@@ -0,0 +1,141 @@
+package dbutils_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestUpdateBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	name := "acme"
+
+	tests := []struct {
+		name          string
+		builder       *dbutils.UpdateBuilder
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{
+			name: "set and where",
+			builder: dbutils.NewUpdateBuilder(nil).
+				Table("tenants").
+				Set("plan", "paid").
+				Where("id = ?", 1),
+			expectedQuery: "UPDATE tenants SET plan = ? WHERE (id = ?)",
+			expectedArgs:  []interface{}{"paid", 1},
+		},
+		{
+			name: "set expression and returning",
+			builder: dbutils.NewUpdateBuilder(nil).
+				Table("tenants").
+				SetExpr("version = version + ?", 1).
+				Set("is_active", false).
+				Where("id = ?", 1).
+				AndWhereLike("tenant_name", dbutils.OpContains, &name).
+				Returning("version"),
+			expectedQuery: "UPDATE tenants SET version = version + ?, is_active = ? WHERE (id = ?) AND (tenant_name LIKE ?) RETURNING version",
+			expectedArgs:  []interface{}{1, false, 1, "%acme%"},
+		},
+		{
+			name: "update from",
+			builder: dbutils.NewUpdateBuilder(nil).
+				Table("users").
+				SetExpr("version = users.version + 1").
+				From("tenants", "users.tenant_id = tenants.id").
+				AndWhere("tenants.plan = ?", "free"),
+			expectedQuery: "UPDATE users SET version = users.version + 1 FROM tenants WHERE (users.tenant_id = tenants.id) AND ((tenants.plan = ?))",
+			expectedArgs:  []interface{}{"free"},
+		},
+		{
+			name: "update from with or",
+			builder: dbutils.NewUpdateBuilder(nil).
+				Table("users").
+				SetExpr("version = users.version + 1").
+				From("tenants", "users.tenant_id = tenants.id").
+				Where("tenants.plan = ?", "free").
+				OrWhere("tenants.is_active = ?", false),
+			expectedQuery: "UPDATE users SET version = users.version + 1 FROM tenants WHERE (users.tenant_id = tenants.id) AND ((tenants.plan = ?) OR (tenants.is_active = ?))",
+			expectedArgs:  []interface{}{"free", false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, args := tt.builder.Build()
+
+			if query != tt.expectedQuery {
+				t.Errorf("Expected query %q, got %q", tt.expectedQuery, query)
+			}
+
+			if !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestUpdateBuilder_ExecContextWithoutFilters(t *testing.T) {
+	t.Parallel()
+
+	var id *int64
+
+	_, err := dbutils.NewUpdateBuilder(nil).
+		Table("tenants").
+		Set("plan", "paid").
+		Where("id = ?", id).
+		ExecContext(context.Background())
+	if !errors.Is(err, dbutils.ErrNoUpdateFilters) {
+		t.Errorf("Expected ErrNoUpdateFilters, got %v", err)
+	}
+}
+
+func TestUpdateBuilder_ExecContextFromWithoutFilters(t *testing.T) {
+	t.Parallel()
+
+	var id *int64
+
+	_, err := dbutils.NewUpdateBuilder(nil).
+		Table("users").
+		SetExpr("version = users.version + 1").
+		From("tenants", "users.tenant_id = tenants.id").
+		Where("tenants.id = ?", id).
+		ExecContext(context.Background())
+	if !errors.Is(err, dbutils.ErrNoUpdateFilters) {
+		t.Errorf("Expected ErrNoUpdateFilters, got %v", err)
+	}
+}
+
+func TestUpdateBuilder_QueryRowContext(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	var version int64
+
+	err := dbutils.NewUpdateBuilder(db).
+		Table("users").
+		SetExpr("version = users.version + ?", 1).
+		From("tenants", "users.tenant_id = tenants.id").
+		AndWhere("tenants.plan = ?", "free").
+		AndWhere("users.id = ?", 1).
+		Returning("users.version").
+		QueryRowContext(context.Background(), &version)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if version != 2 {
+		t.Errorf("Expected version 2, got %d", version)
+	}
+}
@@ -0,0 +1,333 @@
+// Package decimal provides fixed-point decimal and money types that can be stored in the database
+// and serialized to JSON without the rounding errors of float64.
+package decimal
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidDecimal is returned when a value can't be parsed as a decimal.
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// ErrDivisionByZero is returned when dividing by zero.
+var ErrDivisionByZero = errors.New("division by zero")
+
+//nolint:gochecknoglobals
+var ten = big.NewInt(10)
+
+// Decimal is an arbitrary precision fixed-point decimal number. The zero value is 0.
+// Decimals are immutable; arithmetic returns a new Decimal.
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+// New returns value * 10^-scale, e.g. New(1234, 2) is 12.34.
+func New(value int64, scale int32) Decimal {
+	checkScale(scale)
+
+	return Decimal{value: big.NewInt(value), scale: scale}
+}
+
+// checkScale panics if scale is negative.
+func checkScale(scale int32) {
+	if scale < 0 {
+		panic("decimal: negative scale")
+	}
+}
+
+// NewFromInt returns value as a Decimal.
+func NewFromInt(value int64) Decimal {
+	return New(value, 0)
+}
+
+// NewFromFloat returns the shortest decimal representation of value.
+func NewFromFloat(value float64) (Decimal, error) {
+	return Parse(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// Parse parses a decimal string such as "-12.340". The scale of the result is the number of digits
+// after the decimal point.
+func Parse(s string) (Decimal, error) {
+	original := s
+	s = strings.TrimSpace(s)
+
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, original)
+	}
+
+	value, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, original)
+	}
+
+	if negative {
+		value.Neg(value)
+	}
+
+	return Decimal{value: value, scale: int32(len(fracPart))}, nil //nolint: gosec
+}
+
+// MustParse is like Parse but panics if s is not a valid decimal.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d Decimal) unscaled() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+
+	return d.value
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// rescale returns the unscaled value of d at a scale >= d.scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	return new(big.Int).Mul(d.unscaled(), pow10(scale-d.scale))
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
+
+// Add returns d + other.
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+
+	return Decimal{value: new(big.Int).Add(d.rescale(scale), other.rescale(scale)), scale: scale}
+}
+
+// Sub returns d - other.
+func (d Decimal) Sub(other Decimal) Decimal {
+	return d.Add(other.Neg())
+}
+
+// Mul returns d * other. The scale of the result is the sum of the scales.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.unscaled(), other.unscaled()), scale: d.scale + other.scale}
+}
+
+// Div returns d / other rounded to scale digits after the decimal point using mode. It panics if scale
+// is negative.
+func (d Decimal) Div(other Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	checkScale(scale)
+
+	if other.IsZero() {
+		return Decimal{}, ErrDivisionByZero
+	}
+
+	// d / other = (dv * 10^(other.scale + scale)) / (ov * 10^d.scale) * 10^-scale
+	numerator := new(big.Int).Mul(d.unscaled(), pow10(other.scale+scale))
+	denominator := new(big.Int).Mul(other.unscaled(), pow10(d.scale))
+
+	return Decimal{value: quoRound(numerator, denominator, mode), scale: scale}, nil
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.unscaled()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.unscaled()), scale: d.scale}
+}
+
+// Round returns d rounded to scale digits after the decimal point using mode.
+// If scale is larger than the scale of d, trailing zeros are added. It panics if scale is negative.
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	checkScale(scale)
+
+	if scale >= d.scale {
+		return Decimal{value: d.rescale(scale), scale: scale}
+	}
+
+	return Decimal{value: quoRound(d.unscaled(), pow10(d.scale-scale), mode), scale: scale}
+}
+
+// Cmp returns -1, 0 or 1 if d is less than, equal to or greater than other.
+func (d Decimal) Cmp(other Decimal) int {
+	scale := max(d.scale, other.scale)
+
+	return d.rescale(scale).Cmp(other.rescale(scale))
+}
+
+// Equal returns true if d and other are numerically equal. 1.5 is equal to 1.50.
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// LessThan returns true if d < other.
+func (d Decimal) LessThan(other Decimal) bool {
+	return d.Cmp(other) < 0
+}
+
+// GreaterThan returns true if d > other.
+func (d Decimal) GreaterThan(other Decimal) bool {
+	return d.Cmp(other) > 0
+}
+
+// Sign returns -1, 0 or 1 if d is negative, zero or positive.
+func (d Decimal) Sign() int {
+	return d.unscaled().Sign()
+}
+
+// IsZero returns true if d is 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(d.unscaled(), pow10(d.scale)).Float64()
+
+	return f
+}
+
+// String returns d with exactly Scale() digits after the decimal point, e.g. "-12.30".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.unscaled()).String()
+
+	var sb strings.Builder
+	if d.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+
+	if d.scale == 0 {
+		sb.WriteString(digits)
+
+		return sb.String()
+	}
+
+	if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	point := len(digits) - int(d.scale)
+	sb.WriteString(digits[:point])
+	sb.WriteByte('.')
+	sb.WriteString(digits[point:])
+
+	return sb.String()
+}
+
+// Value implements driver.Valuer. Decimals are stored as text so that no precision is lost.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner. SQLite stores DECIMAL/NUMERIC columns as integers or reals when
+// possible, so reals are converted using their shortest representation and the scale isn't kept:
+// "10.00" scans as 10. Use a TEXT column to keep the scale or for values with more than 15
+// significant digits. NULL scans into 0.
+func (d *Decimal) Scan(src any) error {
+	var err error
+
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+	case string:
+		*d, err = Parse(v)
+	case []byte:
+		*d, err = Parse(string(v))
+	case int64:
+		*d = NewFromInt(v)
+	case float64:
+		*d, err = NewFromFloat(v)
+	default:
+		return fmt.Errorf("%w: can't scan %T", ErrInvalidDecimal, src)
+	}
+
+	return err
+}
+
+// MarshalJSON encodes d as a JSON string to preserve precision.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON accepts a JSON string or number.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s, err := unquoteJSON(data)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidDecimal, data)
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
+
+// unquoteJSON returns the contents of a JSON string, or data itself if it isn't a string.
+func unquoteJSON(data []byte) (string, error) {
+	if len(data) == 0 || data[0] != '"' {
+		return string(data), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", fmt.Errorf("invalid json string: %w", err)
+	}
+
+	return s, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Decimal) UnmarshalText(data []byte) error {
+	parsed, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
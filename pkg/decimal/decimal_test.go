@@ -0,0 +1,262 @@
+package decimal_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/decimal"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+		err      error
+	}{
+		{input: "12.34", expected: "12.34"},
+		{input: "-0.050", expected: "-0.050"},
+		{input: "+7", expected: "7"},
+		{input: ".5", expected: "0.5"},
+		{input: "1.", expected: "1"},
+		{input: "123456789012345678901234567890.123456789", expected: "123456789012345678901234567890.123456789"},
+		{input: "", err: decimal.ErrInvalidDecimal},
+		{input: "-", err: decimal.ErrInvalidDecimal},
+		{input: "1.2.3", err: decimal.ErrInvalidDecimal},
+		{input: "1e5", err: decimal.ErrInvalidDecimal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := decimal.Parse(tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if err == nil && d.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, d.String())
+			}
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	t.Parallel()
+
+	a := decimal.MustParse("10.25")
+	b := decimal.MustParse("0.1")
+
+	tests := []struct {
+		name     string
+		actual   decimal.Decimal
+		expected string
+	}{
+		{name: "add", actual: a.Add(b), expected: "10.35"},
+		{name: "sub", actual: b.Sub(a), expected: "-10.15"},
+		{name: "mul", actual: a.Mul(b), expected: "1.025"},
+		{name: "neg", actual: a.Neg(), expected: "-10.25"},
+		{name: "abs", actual: a.Neg().Abs(), expected: "10.25"},
+		{name: "zero value", actual: decimal.Decimal{}.Add(b), expected: "0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.actual.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, tt.actual.String())
+			}
+		})
+	}
+}
+
+func TestDiv(t *testing.T) {
+	t.Parallel()
+
+	d, err := decimal.NewFromInt(10).Div(decimal.NewFromInt(3), 4, decimal.RoundHalfUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.String() != "3.3333" {
+		t.Errorf("expected 3.3333, got %s", d.String())
+	}
+
+	d, err = decimal.MustParse("-1").Div(decimal.MustParse("0.08"), 1, decimal.RoundHalfEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.String() != "-12.5" {
+		t.Errorf("expected -12.5, got %s", d.String())
+	}
+
+	_, err = decimal.NewFromInt(1).Div(decimal.Decimal{}, 2, decimal.RoundHalfUp)
+	if !errors.Is(err, decimal.ErrDivisionByZero) {
+		t.Errorf("expected ErrDivisionByZero, got %v", err)
+	}
+}
+
+func TestRound(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		mode     decimal.RoundingMode
+		expected string
+	}{
+		{value: "2.5", mode: decimal.RoundHalfUp, expected: "3"},
+		{value: "-2.5", mode: decimal.RoundHalfUp, expected: "-3"},
+		{value: "2.5", mode: decimal.RoundHalfEven, expected: "2"},
+		{value: "3.5", mode: decimal.RoundHalfEven, expected: "4"},
+		{value: "-3.5", mode: decimal.RoundHalfEven, expected: "-4"},
+		{value: "2.5", mode: decimal.RoundHalfDown, expected: "2"},
+		{value: "2.51", mode: decimal.RoundHalfDown, expected: "3"},
+		{value: "2.1", mode: decimal.RoundUp, expected: "3"},
+		{value: "-2.1", mode: decimal.RoundUp, expected: "-3"},
+		{value: "2.9", mode: decimal.RoundDown, expected: "2"},
+		{value: "-2.9", mode: decimal.RoundDown, expected: "-2"},
+		{value: "-2.9", mode: decimal.RoundCeiling, expected: "-2"},
+		{value: "2.1", mode: decimal.RoundCeiling, expected: "3"},
+		{value: "-2.1", mode: decimal.RoundFloor, expected: "-3"},
+		{value: "2.9", mode: decimal.RoundFloor, expected: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			actual := decimal.MustParse(tt.value).Round(0, tt.mode)
+			if actual.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, actual.String())
+			}
+		})
+	}
+
+	if actual := decimal.MustParse("1.5").Round(3, decimal.RoundHalfUp); actual.String() != "1.500" {
+		t.Errorf("expected 1.500, got %s", actual.String())
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	if !decimal.MustParse("1.5").Equal(decimal.MustParse("1.500")) {
+		t.Errorf("expected 1.5 to equal 1.500")
+	}
+
+	if !decimal.MustParse("-1").LessThan(decimal.MustParse("0.01")) {
+		t.Errorf("expected -1 to be less than 0.01")
+	}
+
+	if !decimal.MustParse("0.01").GreaterThan(decimal.Decimal{}) {
+		t.Errorf("expected 0.01 to be greater than 0")
+	}
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		src      any
+		expected string
+	}{
+		{name: "string", src: "1.50", expected: "1.50"},
+		{name: "bytes", src: []byte("-2.25"), expected: "-2.25"},
+		{name: "int64", src: int64(3), expected: "3"},
+		{name: "float64", src: 12.34, expected: "12.34"},
+		{name: "null", src: nil, expected: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var d decimal.Decimal
+			if err := d.Scan(tt.src); err != nil {
+				t.Fatal(err)
+			}
+
+			if d.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, d.String())
+			}
+		})
+	}
+
+	var d decimal.Decimal
+	if err := d.Scan(true); !errors.Is(err, decimal.ErrInvalidDecimal) {
+		t.Errorf("expected ErrInvalidDecimal, got %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	type payload struct {
+		Price decimal.Decimal `json:"price"`
+	}
+
+	data, err := json.Marshal(payload{Price: decimal.MustParse("19.90")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"price":"19.90"}` {
+		t.Errorf("expected price as a string, got %s", data)
+	}
+
+	for _, input := range []string{`{"price":"19.90"}`, `{"price":19.90}`} {
+		var p payload
+		if err := json.Unmarshal([]byte(input), &p); err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Price.String() != "19.90" {
+			t.Errorf("expected 19.90, got %s", p.Price.String())
+		}
+	}
+
+	var p payload
+	if err := json.Unmarshal([]byte(`{"price":"abc"}`), &p); !errors.Is(err, decimal.ErrInvalidDecimal) {
+		t.Errorf("expected ErrInvalidDecimal, got %v", err)
+	}
+
+	for _, input := range []string{`"19.90`, `19.90"`, `""19.90""`, `"19.90"x`} {
+		var d decimal.Decimal
+		if err := d.UnmarshalJSON([]byte(input)); !errors.Is(err, decimal.ErrInvalidDecimal) {
+			t.Errorf("expected ErrInvalidDecimal for %s, got %v", input, err)
+		}
+	}
+}
+
+func TestNegativeScale(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{name: "new", fn: func() { decimal.New(1, -1) }},
+		{name: "div", fn: func() { _, _ = decimal.NewFromInt(1).Div(decimal.NewFromInt(3), -1, decimal.RoundHalfUp) }},
+		{name: "round", fn: func() { decimal.NewFromInt(15).Round(-1, decimal.RoundHalfUp) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+
+			tt.fn()
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package decimal
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// MoneyScale is the number of digits after the decimal point of a Money amount.
+const MoneyScale = 2
+
+// ErrInvalidMoney is returned when a value can't be represented as Money.
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// Money is an amount stored as an integer number of minor units (e.g. cents), so that it
+// can be summed in SQL without rounding errors. The zero value is 0.00.
+type Money struct {
+	minor int64
+}
+
+// NewMoney returns an amount of minor units, e.g. NewMoney(1234) is 12.34.
+func NewMoney(minor int64) Money {
+	return Money{minor: minor}
+}
+
+// MoneyFromDecimal rounds d to MoneyScale digits using mode.
+func MoneyFromDecimal(d Decimal, mode RoundingMode) (Money, error) {
+	rounded := d.Round(MoneyScale, mode).unscaled()
+	if !rounded.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s is out of range", ErrInvalidMoney, d)
+	}
+
+	return Money{minor: rounded.Int64()}, nil
+}
+
+// ParseMoney parses an amount such as "12.34". Amounts with more than MoneyScale digits after
+// the decimal point are rejected rather than rounded.
+func ParseMoney(s string) (Money, error) {
+	d, err := Parse(s)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if d.Scale() > MoneyScale && !d.Round(MoneyScale, RoundDown).Equal(d) {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidMoney, s, MoneyScale)
+	}
+
+	return MoneyFromDecimal(d, RoundDown)
+}
+
+// Minor returns the amount in minor units.
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Decimal returns the amount as a Decimal with MoneyScale digits after the decimal point.
+func (m Money) Decimal() Decimal {
+	return New(m.minor, MoneyScale)
+}
+
+// Add returns m + other.
+func (m Money) Add(other Money) Money {
+	return Money{minor: m.minor + other.minor}
+}
+
+// Sub returns m - other.
+func (m Money) Sub(other Money) Money {
+	return Money{minor: m.minor - other.minor}
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor}
+}
+
+// Mul returns m * factor rounded to minor units using mode, e.g. to apply a tax rate.
+func (m Money) Mul(factor Decimal, mode RoundingMode) (Money, error) {
+	return MoneyFromDecimal(m.Decimal().Mul(factor), mode)
+}
+
+// Allocate splits m into parts amounts that differ by at most one minor unit and sum to m.
+// The leftover minor units are given to the first amounts.
+func (m Money) Allocate(parts int) []Money {
+	if parts <= 0 {
+		return nil
+	}
+
+	share := m.minor / int64(parts)
+	remainder := m.minor % int64(parts)
+
+	step := int64(1)
+	if remainder < 0 {
+		step, remainder = -1, -remainder
+	}
+
+	amounts := make([]Money, parts)
+	for i := range amounts {
+		amounts[i] = Money{minor: share}
+		if int64(i) < remainder {
+			amounts[i].minor += step
+		}
+	}
+
+	return amounts
+}
+
+// Cmp returns -1, 0 or 1 if m is less than, equal to or greater than other.
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.minor < other.minor:
+		return -1
+	case m.minor > other.minor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero returns true if m is 0.
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// String returns m with MoneyScale digits after the decimal point, e.g. "12.30".
+func (m Money) String() string {
+	return m.Decimal().String()
+}
+
+// Value implements driver.Valuer. Money is stored as integer minor units.
+func (m Money) Value() (driver.Value, error) {
+	return m.minor, nil
+}
+
+// Scan implements sql.Scanner. NULL scans into 0.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		m.minor = 0
+	case int64:
+		m.minor = v
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("%w: can't scan %T", ErrInvalidMoney, src)
+	}
+
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	minor, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	m.minor = minor
+
+	return nil
+}
+
+// MarshalJSON encodes m as a decimal JSON string, e.g. "12.30".
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON accepts a decimal JSON string or number, e.g. "12.30" or 12.3.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s, err := unquoteJSON(data)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidMoney, data)
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
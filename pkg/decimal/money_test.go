@@ -0,0 +1,148 @@
+package decimal_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/decimal"
+)
+
+func TestParseMoney(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected int64
+		err      error
+	}{
+		{input: "12.34", expected: 1234},
+		{input: "12.3", expected: 1230},
+		{input: "-5", expected: -500},
+		{input: "1.000", expected: 100},
+		{input: "1.005", err: decimal.ErrInvalidMoney},
+		{input: "abc", err: decimal.ErrInvalidDecimal},
+		{input: "100000000000000000000", err: decimal.ErrInvalidMoney},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := decimal.ParseMoney(tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if m.Minor() != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, m.Minor())
+			}
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	t.Parallel()
+
+	price := decimal.NewMoney(1999)
+
+	total := price.Add(decimal.NewMoney(1)).Sub(decimal.NewMoney(500))
+	if total.String() != "15.00" {
+		t.Errorf("expected 15.00, got %s", total.String())
+	}
+
+	tax, err := price.Mul(decimal.MustParse("0.13"), decimal.RoundHalfEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 19.99 * 0.13 = 2.5987
+	if tax.Minor() != 260 {
+		t.Errorf("expected 260, got %d", tax.Minor())
+	}
+
+	if price.Neg().Cmp(price) != -1 {
+		t.Errorf("expected negative price to be less than price")
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount   int64
+		parts    int
+		expected []int64
+	}{
+		{amount: 100, parts: 3, expected: []int64{34, 33, 33}},
+		{amount: -100, parts: 3, expected: []int64{-34, -33, -33}},
+		{amount: 5, parts: 5, expected: []int64{1, 1, 1, 1, 1}},
+		{amount: 1, parts: 0, expected: []int64{}},
+	}
+
+	for _, tt := range tests {
+		amounts := decimal.NewMoney(tt.amount).Allocate(tt.parts)
+
+		actual := make([]int64, 0, len(amounts))
+		for _, amount := range amounts {
+			actual = append(actual, amount.Minor())
+		}
+
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("expected %v, got %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(decimal.NewMoney(1230))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `"12.30"` {
+		t.Errorf(`expected "12.30", got %s`, data)
+	}
+
+	var m decimal.Money
+	if err := json.Unmarshal([]byte("12.3"), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Minor() != 1230 {
+		t.Errorf("expected 1230, got %d", m.Minor())
+	}
+
+	if err := m.UnmarshalJSON([]byte(`"12.30`)); !errors.Is(err, decimal.ErrInvalidMoney) {
+		t.Errorf("expected ErrInvalidMoney, got %v", err)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	t.Parallel()
+
+	var m decimal.Money
+	if err := m.Scan(int64(250)); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.String() != "2.50" {
+		t.Errorf("expected 2.50, got %s", m.String())
+	}
+
+	value, err := m.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != int64(250) {
+		t.Errorf("expected 250, got %v", value)
+	}
+
+	if err := m.Scan(nil); err != nil || !m.IsZero() {
+		t.Errorf("expected NULL to scan into 0, got %s %v", m.String(), err)
+	}
+}
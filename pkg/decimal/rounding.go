@@ -0,0 +1,62 @@
+package decimal
+
+import "math/big"
+
+// RoundingMode determines how a value is rounded when digits are dropped.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds to the nearest value, with ties away from zero. 2.5 -> 3, -2.5 -> -3.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds to the nearest value, with ties to the even neighbour (banker's rounding). 2.5 -> 2, 3.5 -> 4.
+	RoundHalfEven
+	// RoundHalfDown rounds to the nearest value, with ties towards zero. 2.5 -> 2, -2.5 -> -2.
+	RoundHalfDown
+	// RoundUp rounds away from zero. 2.1 -> 3, -2.1 -> -3.
+	RoundUp
+	// RoundDown rounds towards zero (truncation). 2.9 -> 2, -2.9 -> -2.
+	RoundDown
+	// RoundCeiling rounds towards positive infinity. 2.1 -> 3, -2.9 -> -2.
+	RoundCeiling
+	// RoundFloor rounds towards negative infinity. 2.9 -> 2, -2.1 -> -3.
+	RoundFloor
+)
+
+// quoRound returns numerator / denominator rounded to an integer using mode.
+func quoRound(numerator, denominator *big.Int, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	sign := numerator.Sign() * denominator.Sign()
+	// compare 2|remainder| with |denominator| to find out which side of the halfway point we're on
+	half := new(big.Int).Abs(remainder)
+	half.Lsh(half, 1)
+	halfCmp := half.Cmp(new(big.Int).Abs(denominator))
+
+	var awayFromZero bool
+
+	switch mode {
+	case RoundUp:
+		awayFromZero = true
+	case RoundDown:
+		awayFromZero = false
+	case RoundCeiling:
+		awayFromZero = sign > 0
+	case RoundFloor:
+		awayFromZero = sign < 0
+	case RoundHalfDown:
+		awayFromZero = halfCmp > 0
+	case RoundHalfEven:
+		awayFromZero = halfCmp > 0 || (halfCmp == 0 && quotient.Bit(0) == 1)
+	case RoundHalfUp:
+		awayFromZero = halfCmp >= 0
+	}
+
+	if awayFromZero {
+		quotient.Add(quotient, big.NewInt(int64(sign)))
+	}
+
+	return quotient
+}
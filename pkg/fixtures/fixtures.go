@@ -0,0 +1,248 @@
+// Package fixtures seeds a database from YAML or JSON fixture files.
+//
+// Fixtures are grouped into sets (e.g. dev, test, demo), one directory per set, with one file
+// per table:
+//
+//	db/fixtures/test/tenants.yaml
+//	db/fixtures/test/users.yaml
+//
+// Each file lists the rows of its table by symbolic name. Columns may reference a row of another
+// table with "@table.name", which resolves to the id of that row. A literal leading "@" is written as "@@".
+//
+//	key: [email]
+//	rows:
+//	  admin:
+//	    user_name: admin
+//	    email: admin@acme.com
+//	    tenant_id: "@tenants.acme"
+//
+// Rows are upserted on their key columns (id by default) so seeding the same set twice is safe.
+package fixtures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+)
+
+var (
+	ErrInvalidFixture      = errors.New("invalid fixture")
+	ErrUnknownFixtureSet   = errors.New("unknown fixture set")
+	ErrUnresolvedReference = errors.New("unresolved fixture reference")
+	ErrReferenceCycle      = errors.New("fixture reference cycle")
+)
+
+const (
+	referencePrefix = "@"
+	defaultKey      = "id"
+)
+
+// Fixture is the list of rows to seed into a table.
+type Fixture struct {
+	Table string
+	Key   []string
+	Rows  []Row
+}
+
+// Row is a named fixture row. Columns preserve the order they were declared in.
+type Row struct {
+	Name    string
+	Columns []string
+	Values  []any
+}
+
+// Reference is a "@table.name" value that resolves to the id of another fixture row.
+type Reference struct {
+	Table string
+	Name  string
+}
+
+func (r Reference) String() string {
+	return r.Table + "." + r.Name
+}
+
+// Refs maps "table.name" to the id (rowid) of each seeded row.
+type Refs map[string]int64
+
+// ID returns the id of the named row of table or 0 if it was not seeded.
+func (r Refs) ID(table, name string) int64 {
+	return r[table+"."+name]
+}
+
+// Seed loads the given fixture sets from fsys and upserts their rows in a single transaction.
+// Tables are seeded in dependency order so that references resolve to rows seeded earlier.
+func Seed(ctx context.Context, db dbutils.DB, fsys fs.FS, sets ...string) (Refs, error) {
+	fixtures, err := Load(fsys, sets...)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := Refs{}
+
+	err = dbutils.WithTransaction(ctx, db, func(tx dbutils.DB) error {
+		for _, fixture := range fixtures {
+			if err := seedFixture(ctx, tx, fixture, refs); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
+// Load reads the fixture files of each set from fsys and orders them so that every table
+// comes after the tables it references. Each set is a directory in fsys.
+func Load(fsys fs.FS, sets ...string) ([]Fixture, error) {
+	fixtures := []Fixture{}
+
+	for _, set := range sets {
+		entries, err := fs.ReadDir(fsys, set)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownFixtureSet, set)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture set %s: %w", set, err)
+		}
+
+		for _, entry := range entries {
+			ext := path.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+				continue
+			}
+
+			filename := path.Join(set, entry.Name())
+
+			data, err := fs.ReadFile(fsys, filename)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read fixture file %s: %w", filename, err)
+			}
+
+			fixture, err := Parse(strings.TrimSuffix(entry.Name(), ext), data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+
+			fixtures = append(fixtures, fixture)
+		}
+	}
+
+	return sortFixtures(fixtures)
+}
+
+func seedFixture(ctx context.Context, db dbutils.DB, fixture Fixture, refs Refs) error {
+	for _, row := range fixture.Rows {
+		values := make([]any, len(row.Values))
+
+		for i, value := range row.Values {
+			ref, ok := value.(Reference)
+			if !ok {
+				values[i] = value
+
+				continue
+			}
+
+			id, ok := refs[ref.String()]
+			if !ok {
+				return fmt.Errorf("%w: %s.%s references %s", ErrUnresolvedReference, fixture.Table, row.Name, ref)
+			}
+
+			values[i] = id
+		}
+
+		var id int64
+
+		err := dbutils.NewInsertBuilder(db).
+			Into(fixture.Table).
+			Columns(row.Columns...).
+			Values(values...).
+			OnConflictDoUpdate(fixture.Key...).
+			Returning("rowid").
+			QueryRowContext(ctx, &id)
+		if err != nil {
+			return fmt.Errorf("failed to seed %s.%s: %w", fixture.Table, row.Name, err)
+		}
+
+		refs[fixture.Table+"."+row.Name] = id
+	}
+
+	return nil
+}
+
+// sortFixtures orders fixtures so that referenced tables are seeded first. Fixtures without
+// dependencies between them keep the order they were loaded in.
+func sortFixtures(fixtures []Fixture) ([]Fixture, error) {
+	sorted := make([]Fixture, 0, len(fixtures))
+	done := make([]bool, len(fixtures))
+
+	for len(sorted) < len(fixtures) {
+		progressed := false
+
+		for i, fixture := range fixtures {
+			if done[i] || !dependenciesSeeded(fixture, fixtures, done) {
+				continue
+			}
+
+			sorted = append(sorted, fixture)
+			done[i] = true
+			progressed = true
+
+			break
+		}
+
+		if !progressed {
+			remaining := []string{}
+
+			for i, fixture := range fixtures {
+				if !done[i] {
+					remaining = append(remaining, fixture.Table)
+				}
+			}
+
+			return nil, fmt.Errorf("%w between %s", ErrReferenceCycle, strings.Join(remaining, ", "))
+		}
+	}
+
+	return sorted, nil
+}
+
+// dependenciesSeeded reports whether every other table referenced by fixture has no fixtures left to seed.
+func dependenciesSeeded(fixture Fixture, fixtures []Fixture, done []bool) bool {
+	for _, dependency := range fixture.dependencies() {
+		if dependency == fixture.Table {
+			continue
+		}
+
+		for i, other := range fixtures {
+			if !done[i] && other.Table == dependency {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (f Fixture) dependencies() []string {
+	dependencies := []string{}
+
+	for _, row := range f.Rows {
+		for _, value := range row.Values {
+			if ref, ok := value.(Reference); ok {
+				dependencies = append(dependencies, ref.Table)
+			}
+		}
+	}
+
+	return dependencies
+}
@@ -0,0 +1,226 @@
+package fixtures_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gurch101/gowebutils/pkg/fixtures"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		data     string
+		expected fixtures.Fixture
+		err      error
+	}{
+		{
+			name: "yaml with references",
+			data: `
+key: [email]
+rows:
+  jane:
+    email: jane@acme.com
+    tenant_id: "@tenants.acme"
+    handle: "@@jane"
+    settings:
+      theme: dark
+`,
+			expected: fixtures.Fixture{
+				Table: "users",
+				Key:   []string{"email"},
+				Rows: []fixtures.Row{{
+					Name:    "jane",
+					Columns: []string{"email", "tenant_id", "handle", "settings"},
+					Values: []any{
+						"jane@acme.com",
+						fixtures.Reference{Table: "tenants", Name: "acme"},
+						"@jane",
+						`{"theme":"dark"}`,
+					},
+				}},
+			},
+		},
+		{
+			name: "json with table override",
+			data: `{"table": "accounts", "rows": {"first": {"id": 1, "active": true}}}`,
+			expected: fixtures.Fixture{
+				Table: "accounts",
+				Key:   []string{"id"},
+				Rows:  []fixtures.Row{{Name: "first", Columns: []string{"id", "active"}, Values: []any{1, true}}},
+			},
+		},
+		{
+			name: "missing key column",
+			data: "rows:\n  jane:\n    email: jane@acme.com\n",
+			err:  fixtures.ErrInvalidFixture,
+		},
+		{
+			name: "invalid reference",
+			data: "key: [email]\nrows:\n  jane:\n    email: jane@acme.com\n    tenant_id: \"@tenants\"\n",
+			err:  fixtures.ErrInvalidFixture,
+		},
+		{
+			name: "unknown field",
+			data: "rowz: {}\n",
+			err:  fixtures.ErrInvalidFixture,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fixture, err := fixtures.Parse("users", []byte(tt.data))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if tt.err == nil && !reflect.DeepEqual(fixture, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, fixture)
+			}
+		})
+	}
+}
+
+func TestLoad_OrdersByReference(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"demo/a_users.yaml":   {Data: []byte("table: users\nrows:\n  u:\n    id: 1\n    tenant_id: \"@tenants.t\"\n")},
+		"demo/b_tenants.yaml": {Data: []byte("table: tenants\nrows:\n  t:\n    id: 1\n")},
+		"demo/README.md":      {Data: []byte("ignored")},
+	}
+
+	loaded, err := fixtures.Load(fsys, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := []string{}
+	for _, fixture := range loaded {
+		tables = append(tables, fixture.Table)
+	}
+
+	if !reflect.DeepEqual(tables, []string{"tenants", "users"}) {
+		t.Errorf("expected tenants before users, got %v", tables)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"cycle/a.yaml": {Data: []byte("rows:\n  x:\n    id: 1\n    b_id: \"@b.y\"\n")},
+		"cycle/b.yaml": {Data: []byte("rows:\n  y:\n    id: 1\n    a_id: \"@a.x\"\n")},
+	}
+
+	if _, err := fixtures.Load(fsys, "cycle"); !errors.Is(err, fixtures.ErrReferenceCycle) {
+		t.Errorf("expected ErrReferenceCycle, got %v", err)
+	}
+
+	if _, err := fixtures.Load(fsys, "missing"); !errors.Is(err, fixtures.ErrUnknownFixtureSet) {
+		t.Errorf("expected ErrUnknownFixtureSet, got %v", err)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	fsys := fstest.MapFS{
+		"demo/users.yaml": {Data: []byte(`
+key: [email]
+rows:
+  jane:
+    user_name: jane
+    email: jane@globex.com
+    tenant_id: "@tenants.globex"
+`)},
+		"demo/tenants.yaml": {Data: []byte(`
+key: [tenant_name]
+rows:
+  acme:
+    tenant_name: Acme
+    contact_email: billing@acme.com
+    plan: paid
+  globex:
+    tenant_name: Globex
+    contact_email: admin@globex.com
+    plan: free
+`)},
+	}
+
+	ctx := context.Background()
+
+	refs, err := fixtures.Seed(ctx, db, fsys, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Acme already exists in the test fixtures so it is updated in place.
+	if refs.ID("tenants", "acme") != 1 {
+		t.Errorf("expected acme to keep id 1, got %d", refs.ID("tenants", "acme"))
+	}
+
+	// seeding again must not create duplicates
+	again, err := fixtures.Seed(ctx, db, fsys, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(refs, again) {
+		t.Errorf("expected the same ids on reseed, got %v and %v", refs, again)
+	}
+
+	var plan string
+	if err := db.QueryRow("SELECT plan FROM tenants WHERE id = 1").Scan(&plan); err != nil || plan != "paid" {
+		t.Errorf("expected acme plan to be updated to paid, got %q (%v)", plan, err)
+	}
+
+	var tenantID int64
+	if err := db.QueryRow("SELECT tenant_id FROM users WHERE email = 'jane@globex.com'").Scan(&tenantID); err != nil {
+		t.Fatal(err)
+	}
+
+	if tenantID != refs.ID("tenants", "globex") {
+		t.Errorf("expected jane to reference globex (%d), got %d", refs.ID("tenants", "globex"), tenantID)
+	}
+}
+
+func TestSeed_UnresolvedReference(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	fsys := fstest.MapFS{
+		"demo/users.yaml": {Data: []byte(`
+key: [email]
+rows:
+  jane:
+    user_name: jane
+    email: jane@globex.com
+    tenant_id: "@tenants.globex"
+`)},
+	}
+
+	_, err := fixtures.Seed(context.Background(), db, fsys, "demo")
+	if !errors.Is(err, fixtures.ErrUnresolvedReference) {
+		t.Fatalf("expected ErrUnresolvedReference, got %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users WHERE email = 'jane@globex.com'").Scan(&count); err != nil || count != 0 {
+		t.Errorf("expected no rows to be seeded, got %d (%v)", count, err)
+	}
+}
@@ -0,0 +1,146 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Parse parses a YAML or JSON fixture file. The table defaults to name unless the file sets "table".
+func Parse(name string, data []byte) (Fixture, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Fixture{}, fmt.Errorf("%w: %w", ErrInvalidFixture, err)
+	}
+
+	fixture := Fixture{Table: name, Key: []string{defaultKey}, Rows: []Row{}}
+
+	if len(doc.Content) == 0 {
+		return fixture, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return Fixture{}, fmt.Errorf("%w: expected a mapping with table, key and rows", ErrInvalidFixture)
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+
+		var err error
+
+		switch key {
+		case "table":
+			err = value.Decode(&fixture.Table)
+		case "key":
+			err = value.Decode(&fixture.Key)
+		case "rows":
+			fixture.Rows, err = parseRows(value)
+		default:
+			err = fmt.Errorf("%w: unknown field %q", ErrInvalidFixture, key)
+		}
+
+		if err != nil {
+			return Fixture{}, err
+		}
+	}
+
+	if fixture.Table == "" || len(fixture.Key) == 0 {
+		return Fixture{}, fmt.Errorf("%w: table and key must not be empty", ErrInvalidFixture)
+	}
+
+	for _, row := range fixture.Rows {
+		for _, key := range fixture.Key {
+			if !containsColumn(row.Columns, key) {
+				return Fixture{}, fmt.Errorf("%w: row %s is missing key column %s", ErrInvalidFixture, row.Name, key)
+			}
+		}
+	}
+
+	return fixture, nil
+}
+
+func parseRows(node *yaml.Node) ([]Row, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w: rows must be a mapping of row name to columns", ErrInvalidFixture)
+	}
+
+	rows := make([]Row, 0, len(node.Content)/2)
+
+	for i := 0; i < len(node.Content); i += 2 {
+		name, columns := node.Content[i].Value, node.Content[i+1]
+
+		if columns.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%w: row %s must be a mapping of column to value", ErrInvalidFixture, name)
+		}
+
+		row := Row{Name: name, Columns: []string{}, Values: []any{}}
+
+		for j := 0; j < len(columns.Content); j += 2 {
+			value, err := parseValue(columns.Content[j+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: row %s column %s: %w", ErrInvalidFixture, name, columns.Content[j].Value, err)
+			}
+
+			row.Columns = append(row.Columns, columns.Content[j].Value)
+			row.Values = append(row.Values, value)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseValue converts a column node to a value that can be bound to a statement. Nested
+// mappings and sequences are stored as JSON text.
+func parseValue(node *yaml.Node) (any, error) {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case string:
+		return parseString(v)
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(data), nil
+	default:
+		return value, nil
+	}
+}
+
+func parseString(value string) (any, error) {
+	if !strings.HasPrefix(value, referencePrefix) {
+		return value, nil
+	}
+
+	value = strings.TrimPrefix(value, referencePrefix)
+	if strings.HasPrefix(value, referencePrefix) {
+		return value, nil
+	}
+
+	table, name, ok := strings.Cut(value, ".")
+	if !ok || table == "" || name == "" {
+		return nil, fmt.Errorf("invalid reference %q, expected @table.name", referencePrefix+value)
+	}
+
+	return Reference{Table: table, Name: name}, nil
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,21 +3,52 @@
 package fsutils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/gurch101/gowebutils/pkg/tracing"
 )
 
+// ErrFileNotFound is returned when a file doesn't exist.
+var ErrFileNotFound = errors.New("file not found")
+
+// FileService stores files. The methods without a context are the Context methods called with
+// context.Background().
 type FileService interface {
 	UploadFile(fileName string, file io.Reader) (string, error)
+	UploadFileContext(ctx context.Context, fileName string, file io.Reader) (string, error)
 	DownloadFile(fileName string) ([]byte, error)
+	DownloadFileContext(ctx context.Context, fileName string) ([]byte, error)
+	// Open returns a reader streaming the contents of a file. The reader must be closed.
+	Open(ctx context.Context, fileName string) (io.ReadCloser, ObjectInfo, error)
+	// OpenRange returns a reader streaming length bytes of a file starting at offset. The size of the returned
+	// ObjectInfo is the size of the whole file.
+	OpenRange(ctx context.Context, fileName string, offset, length int64) (io.ReadCloser, ObjectInfo, error)
+	// Stat returns information about a file without downloading it.
+	Stat(ctx context.Context, fileName string) (ObjectInfo, error)
 	DeleteFile(fileName string) error
+	DeleteFileContext(ctx context.Context, fileName string) error
 	DeleteFiles(fileNames []string) error
+	DeleteFilesContext(ctx context.Context, fileNames []string) error
+}
+
+// ObjectInfo describes a stored file.
+type ObjectInfo struct {
+	Name         string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
 }
 
 type Service struct {
@@ -47,65 +78,176 @@ func NewService(region, bucket, key, secret string) *Service {
 
 // UploadFile uploads a file.
 func (s *Service) UploadFile(fileName string, file io.Reader) (string, error) {
+	return s.UploadFileContext(context.Background(), fileName, file)
+}
+
+// UploadFileContext uploads a file. The upload is traced as part of the trace in ctx.
+func (s *Service) UploadFileContext(ctx context.Context, fileName string, file io.Reader) (string, error) {
+	ctx, span := s.startSpan(ctx, "PutObject", fileName)
+	defer span.End()
+
 	//nolint: exhaustruct
-	result, err := s.uploader.Upload(&s3manager.UploadInput{
+	result, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(fileName),
 		Body:   file,
 	})
 	if err != nil {
+		span.RecordError(err)
+
 		return "", fmt.Errorf("failed to upload file: %w", err)
 	}
 
 	return result.Location, nil
 }
 
-// DownloadFile downloads a file.
-/*
-handler code:
-		// Set headers for the response
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
-		w.Header().Set("Content-Type", "application/octet-stream")
-		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(buf.Bytes())))
-
-		// Write the file to the response
-		_, err = w.Write(buf.Bytes())
-		if err != nil {
-			http.Error(w, "Failed to write file to response", http.StatusInternalServerError)
-			return
-		}
-*/
+// DownloadFile downloads a file into memory. Use Open to stream large files.
 func (s *Service) DownloadFile(fileName string) ([]byte, error) {
+	return s.DownloadFileContext(context.Background(), fileName)
+}
+
+// DownloadFileContext downloads a file into memory. Use Open to stream large files.
+func (s *Service) DownloadFileContext(ctx context.Context, fileName string) ([]byte, error) {
+	ctx, span := s.startSpan(ctx, "GetObject", fileName)
+	defer span.End()
+
 	buf := aws.NewWriteAtBuffer([]byte{})
 
 	//nolint: exhaustruct
-	_, err := s.downloader.Download(buf, &s3.GetObjectInput{
+	_, err := s.downloader.DownloadWithContext(ctx, buf, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(fileName),
 	})
 	if err != nil {
+		span.RecordError(err)
+
 		return nil, fmt.Errorf("failed to download file: %w", err)
 	}
 
 	return buf.Bytes(), nil
 }
 
+// Open returns a reader streaming the contents of a file from S3.
+func (s *Service) Open(ctx context.Context, fileName string) (io.ReadCloser, ObjectInfo, error) {
+	//nolint: exhaustruct
+	return s.getObject(ctx, fileName, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fileName),
+	})
+}
+
+// OpenRange returns a reader streaming length bytes of a file from S3 starting at offset.
+func (s *Service) OpenRange(ctx context.Context, fileName string, offset, length int64) (io.ReadCloser, ObjectInfo, error) {
+	//nolint: exhaustruct
+	return s.getObject(ctx, fileName, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fileName),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+}
+
+func (s *Service) getObject(ctx context.Context, fileName string, input *s3.GetObjectInput) (io.ReadCloser, ObjectInfo, error) {
+	ctx, span := s.startSpan(ctx, "GetObject", fileName)
+	defer span.End()
+
+	output, err := s.client.GetObjectWithContext(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+
+		return nil, ObjectInfo{}, wrapS3Error("failed to open file", err)
+	}
+
+	info := ObjectInfo{
+		Name:         fileName,
+		Size:         aws.Int64Value(output.ContentLength),
+		ContentType:  aws.StringValue(output.ContentType),
+		ETag:         aws.StringValue(output.ETag),
+		LastModified: aws.TimeValue(output.LastModified),
+	}
+
+	// ranged responses have a Content-Range of "bytes <start>-<end>/<size>"
+	if contentRange := aws.StringValue(output.ContentRange); contentRange != "" {
+		var start, end int64
+		if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &info.Size); err != nil {
+			output.Body.Close()
+
+			return nil, ObjectInfo{}, fmt.Errorf("failed to parse content range %q: %w", contentRange, err)
+		}
+	}
+
+	return output.Body, info, nil
+}
+
+// Stat returns information about a file in S3 without downloading it.
+func (s *Service) Stat(ctx context.Context, fileName string) (ObjectInfo, error) {
+	ctx, span := s.startSpan(ctx, "HeadObject", fileName)
+	defer span.End()
+
+	//nolint: exhaustruct
+	output, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fileName),
+	})
+	if err != nil {
+		span.RecordError(err)
+
+		return ObjectInfo{}, wrapS3Error("failed to stat file", err)
+	}
+
+	return ObjectInfo{
+		Name:         fileName,
+		Size:         aws.Int64Value(output.ContentLength),
+		ContentType:  aws.StringValue(output.ContentType),
+		ETag:         aws.StringValue(output.ETag),
+		LastModified: aws.TimeValue(output.LastModified),
+	}, nil
+}
+
+// wrapS3Error wraps err with ErrFileNotFound if the object doesn't exist.
+func wrapS3Error(message string, err error) error {
+	var awsErr awserr.Error
+	// HEAD requests have no body so S3 can't return NoSuchKey
+	if errors.As(err, &awsErr) && (awsErr.Code() == s3.ErrCodeNoSuchKey || awsErr.Code() == "NotFound") {
+		return fmt.Errorf("%s: %w", message, ErrFileNotFound)
+	}
+
+	return fmt.Errorf("%s: %w", message, err)
+}
+
 // DeleteFile deletes a file from S3.
 func (s *Service) DeleteFile(fileName string) error {
+	return s.DeleteFileContext(context.Background(), fileName)
+}
+
+// DeleteFileContext deletes a file from S3.
+func (s *Service) DeleteFileContext(ctx context.Context, fileName string) error {
+	ctx, span := s.startSpan(ctx, "DeleteObject", fileName)
+	defer span.End()
+
 	//nolint: exhaustruct
-	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(fileName),
 	})
 	if err != nil {
+		span.RecordError(err)
+
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
 
 	return nil
 }
 
-// / DeleteFiles deletes multiple files from S3.
+// DeleteFiles deletes multiple files from S3.
 func (s *Service) DeleteFiles(fileNames []string) error {
+	return s.DeleteFilesContext(context.Background(), fileNames)
+}
+
+// DeleteFilesContext deletes multiple files from S3.
+func (s *Service) DeleteFilesContext(ctx context.Context, fileNames []string) error {
+	ctx, span := s.startSpan(ctx, "DeleteObjects", "")
+	defer span.End()
+
 	// Convert file names to slice of pointers
 	objects := make([]*s3.ObjectIdentifier, 0, len(fileNames))
 
@@ -117,7 +259,7 @@ func (s *Service) DeleteFiles(fileNames []string) error {
 	}
 
 	//nolint: exhaustruct
-	_, err := s.client.DeleteObjects(&s3.DeleteObjectsInput{
+	_, err := s.client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
 		Bucket: aws.String(s.bucket),
 		Delete: &s3.Delete{
 			Objects: objects,
@@ -125,8 +267,26 @@ func (s *Service) DeleteFiles(fileNames []string) error {
 		},
 	})
 	if err != nil {
+		span.RecordError(err)
+
 		return fmt.Errorf("failed to delete files: %w", err)
 	}
 
 	return nil
 }
+
+// startSpan starts a client span for an S3 operation on key. Operations on several keys pass an empty key.
+func (s *Service) startSpan(ctx context.Context, operation, key string) (context.Context, *tracing.Span) {
+	attrs := []slog.Attr{
+		slog.String("rpc.system", "aws-api"),
+		slog.String("rpc.service", "S3"),
+		slog.String("rpc.method", operation),
+		slog.String("aws.s3.bucket", s.bucket),
+	}
+
+	if key != "" {
+		attrs = append(attrs, slog.String("aws.s3.key", key))
+	}
+
+	return tracing.Start(ctx, "S3."+operation, tracing.WithKind(tracing.SpanKindClient), tracing.WithAttributes(attrs...))
+}
@@ -18,6 +18,9 @@ import (
 
 	"github.com/gurch101/gowebutils/pkg/app"
 	"github.com/gurch101/gowebutils/pkg/dbutils"
+	{{- if .IncludeDecimal}}
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	{{- end}}
 	"github.com/gurch101/gowebutils/pkg/httputils"
 	{{if .RequireValidation}}"github.com/gurch101/gowebutils/pkg/validation"{{end}}
 
@@ -66,23 +69,10 @@ func (c *Create{{.SingularTitleCaseName}}Controller) Create{{.SingularTitleCaseN
 		return
 	}
 	{{if .RequireValidation}}
-		v := validation.NewValidator()
-		{{- range .Fields}}
-			{{- if .IsEmail}}
-				v.Email(req.{{.TitleCaseName}}, "{{.JSONName}}", "{{.HumanName}} must be a valid email address")
-			{{- else if .Required}}
-				{{- if eq .GoType "string"}}
-				v.Required(req.{{.TitleCaseName}}, "{{.JSONName}}", "{{.HumanName}} is required")
-				{{- else}}
-				v.Check(req.{{.TitleCaseName}} > 0, "{{.JSONName}}", "{{.HumanName}} is required")
-				{{- end}}
-			{{- end}}
-		{{- end}}
-
-		if v.HasErrors() {
-			httputils.FailedValidationResponse(w, r, v.Errors)
-			return
-		}
+	if v := validateCreate{{.SingularTitleCaseName}}Request(&req); v.HasErrors() {
+		httputils.FailedValidationResponse(w, r, v.Errors)
+		return
+	}
 	{{- end}}
 
 	id, err := Create{{.SingularTitleCaseName}}(r.Context(), c.app.DB(), &req)
@@ -100,6 +90,27 @@ func (c *Create{{.SingularTitleCaseName}}Controller) Create{{.SingularTitleCaseN
 	}
 }
 
+{{if .RequireValidation}}
+func validateCreate{{.SingularTitleCaseName}}Request(req *Create{{.SingularTitleCaseName}}Request) *validation.Validator {
+	v := validation.NewValidator()
+	{{- range .Fields}}
+		{{- if .IsEmail}}
+		v.Email(req.{{.TitleCaseName}}, "{{.JSONName}}", "{{.HumanName}} must be a valid email address")
+		{{- else if .Required}}
+		{{- if eq .GoType "string"}}
+		v.Required(req.{{.TitleCaseName}}, "{{.JSONName}}", "{{.HumanName}} is required")
+		{{- else if eq .GoType "decimal.Decimal"}}
+		v.Check(!req.{{.TitleCaseName}}.IsZero(), "{{.JSONName}}", "{{.HumanName}} is required")
+		{{- else}}
+		v.Check(req.{{.TitleCaseName}} > 0, "{{.JSONName}}", "{{.HumanName}} is required")
+		{{- end}}
+		{{- end}}
+	{{- end}}
+
+	return v
+}
+{{- end}}
+
 /* Service */
 func Create{{.SingularTitleCaseName}}(
 	ctx context.Context,
@@ -172,6 +183,9 @@ import (
 	{{- if .RequireValidation}}
 	"github.com/gurch101/gowebutils/pkg/validation"
 	{{- end}}
+	{{- if .IncludeDecimal}}
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	{{- end}}
 	"github.com/gurch101/gowebutils/pkg/testutils"
 )
 
@@ -232,7 +246,11 @@ func TestCreate{{.SingularTitleCaseName}}(t *testing.T) {
 		}
 
 		{{- range .Fields}}
+		{{- if eq .GoType "decimal.Decimal"}}
+		if !{{.JSONName}}.Equal(body.{{.TitleCaseName}}) {
+		{{- else}}
 		if {{.JSONName}} != body.{{.TitleCaseName}} {
+		{{- end}}
 			t.Errorf("expected {{.JSONName}} to be %v, got %v", body.{{.TitleCaseName}}, {{.JSONName}})
 		}
 		{{- end}}
@@ -271,6 +289,8 @@ func TestCreate{{.SingularTitleCaseName}}(t *testing.T) {
 				{{- else if .Required}}
 						{{- if or (eq .GoType "int") (eq .GoType "int64")}}
 						{{.TitleCaseName}}: 0,
+						{{- else if eq .GoType "decimal.Decimal"}}
+						{{.TitleCaseName}}: decimal.Decimal{},
 						{{- else}}
 						{{.TitleCaseName}}: "",
 						{{- end}}
@@ -463,6 +483,7 @@ func newCreateHandlerTemplateData(moduleName string, schema Table) createHandler
 		HumanName:             stringutils.SnakeToHuman(strings.TrimSuffix(schema.Name, "s")),
 		UniqueConstraint:      includeUniqueConstraint,
 		RequireValidation:     requireValidation,
+		IncludeDecimal:        schema.HasDecimal(),
 		TitleCaseTableName:    stringutils.SnakeToTitle(schema.Name),
 		SingularTitleCaseName: stringutils.SnakeToTitle(strings.TrimSuffix(schema.Name, "s")),
 		SingularCamelCaseName: stringutils.SnakeToCamel(strings.TrimSuffix(schema.Name, "s")),
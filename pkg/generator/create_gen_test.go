@@ -68,6 +68,21 @@ func getTestUserSchema() generator.Table {
 	}
 }
 
+func getTestSchemaWithTenants() []generator.Table {
+	return []generator.Table{
+		getTestUserSchema(),
+		{
+			Name: "tenants",
+			Fields: []generator.Field{
+				{Name: "id", DataType: generator.SQLInt64, Constraints: []string{}},
+				{Name: "tenant_name", DataType: generator.SQLString, Constraints: []string{"NOT NULL"}},
+				{Name: "plan", DataType: generator.SQLString, Constraints: []string{"NOT NULL"}},
+				{Name: "created_at", DataType: generator.SQLDatetime, Constraints: []string{}},
+			},
+		},
+	}
+}
+
 func TestCreateGen(t *testing.T) {
 	createTemplate, createTestTemplate, err := generator.RenderCreateTemplate("github.com/gurch101/gowebutils", getTestUserSchema())
 	if err != nil {
@@ -119,3 +134,40 @@ func TestCreateGenNoUniqueIndexNoConstraints(t *testing.T) {
 	testutils.AssertFileEqualsString(t, "snapshots/create_user_no_unique_index_no_constraints.txt", string(createTemplate))
 	testutils.AssertFileEqualsString(t, "snapshots/create_user_no_unique_index_no_constraints_test.txt", string(createTestTemplate))
 }
+
+func TestCreateGenWithDecimal(t *testing.T) {
+	table := generator.Table{
+		Name: "products",
+		Fields: []generator.Field{
+			{
+				Name:        "id",
+				DataType:    generator.SQLInt64,
+				Constraints: []string{},
+			},
+			{
+				Name:        "version",
+				DataType:    generator.SQLInt64,
+				Constraints: []string{},
+			},
+			{
+				Name:        "name",
+				DataType:    generator.SQLString,
+				Constraints: []string{"CHECK (name <> '')"},
+			},
+			{
+				Name:        "price",
+				DataType:    generator.SQLDecimal,
+				Constraints: []string{"NOT NULL"},
+			},
+		},
+		UniqueIndexes: []generator.UniqueIndex{},
+	}
+
+	createTemplate, createTestTemplate, err := generator.RenderCreateTemplate("github.com/gurch101/gowebutils", table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertFileEqualsString(t, "snapshots/create_product_with_decimal.txt", string(createTemplate))
+	testutils.AssertFileEqualsString(t, "snapshots/create_product_with_decimal_test.txt", string(createTestTemplate))
+}
@@ -0,0 +1,353 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/stringutils"
+)
+
+const csvHandlerTemplate = `package {{.PackageName}}
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gurch101/gowebutils/pkg/app"
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/validation"
+)
+
+const max{{.SingularTitleCaseName}}ImportBytes = 10 << 20
+
+/* Handler */
+type {{.SingularTitleCaseName}}CSVController struct {
+	app *app.App
+}
+
+func New{{.SingularTitleCaseName}}CSVController(app *app.App) *{{.SingularTitleCaseName}}CSVController {
+	return &{{.SingularTitleCaseName}}CSVController{app: app}
+}
+
+type Import{{.TitleCaseTableName}}Response struct {
+	Imported int ` + "`" + `json:"imported"` + "`" + `
+}
+
+// Import{{.TitleCaseTableName}} godoc
+//
+//	@Summary			Import {{.HumanName}}s
+//	@Description	Import {{.HumanName}}s from a CSV file whose header uses the request field names. No rows are imported if any row is invalid.
+//	@Tags					{{.HumanName}}s
+//	@Accept				text/csv
+//	@Produce			json
+//	@Success			200	{object}	Import{{.TitleCaseTableName}}Response
+//	@Failure			400,500	{object}	httputils.ErrorResponse
+//	@Router				/{{.KebabCaseTableName}}/import [post]
+func (c *{{.SingularTitleCaseName}}CSVController) Import{{.TitleCaseTableName}}Handler(
+	w http.ResponseWriter,
+	r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, max{{.SingularTitleCaseName}}ImportBytes)
+
+	imported, err := Import{{.TitleCaseTableName}}(r.Context(), c.app.DB(), r.Body)
+	if err != nil {
+		httputils.HandleErrorResponse(w, r, err)
+		return
+	}
+
+	err = httputils.WriteJSON(w, http.StatusOK, Import{{.TitleCaseTableName}}Response{Imported: imported}, nil)
+	if err != nil {
+		httputils.ServerErrorResponse(w, r, err)
+	}
+}
+
+// Export{{.TitleCaseTableName}} godoc
+//
+//	@Summary			Export {{.HumanName}}s
+//	@Description	Download all {{.HumanName}}s as a CSV file
+//	@Tags					{{.HumanName}}s
+//	@Produce			text/csv
+//	@Success			200	{file}	file
+//	@Router				/{{.KebabCaseTableName}}/export [get]
+func (c *{{.SingularTitleCaseName}}CSVController) Export{{.TitleCaseTableName}}Handler(
+	w http.ResponseWriter,
+	r *http.Request) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", ` + "`" + `attachment; filename="{{.Name}}.csv"` + "`" + `)
+
+	// rows are streamed so the status has already been sent if the export fails
+	if err := Export{{.TitleCaseTableName}}(r.Context(), c.app.DB(), w); err != nil {
+		slog.ErrorContext(r.Context(), "csv export failed", "error", err)
+	}
+}
+
+/* Service */
+func Import{{.TitleCaseTableName}}(ctx context.Context, db dbutils.DB, r io.Reader) (int, error) {
+	return dbutils.ImportCSV(
+		ctx,
+		db,
+		r,
+		parse{{.SingularTitleCaseName}}CSVRecord,
+		func(ctx context.Context, tx dbutils.DB, req Create{{.SingularTitleCaseName}}Request) error {
+			_, err := Create{{.SingularTitleCaseName}}(ctx, tx, &req)
+
+			return err
+		},
+		dbutils.WithCSVColumns(
+			"id",
+			{{- range .Fields}}
+			"{{.JSONName}}",
+			{{- end}}
+		),
+	)
+}
+
+// parse{{.SingularTitleCaseName}}CSVRecord applies the same validation as the create handler. The id column is ignored.
+func parse{{.SingularTitleCaseName}}CSVRecord(record *dbutils.CSVRecord) (Create{{.SingularTitleCaseName}}Request, []validation.Error) {
+	req := Create{{.SingularTitleCaseName}}Request{
+		{{- range .Fields}}
+		{{.TitleCaseName}}: record.{{.CSVGetter}}("{{.JSONName}}"),
+		{{- end}}
+	}
+	{{if .RequireValidation}}
+	return req, validateCreate{{.SingularTitleCaseName}}Request(&req).Errors
+	{{- else}}
+	return req, nil
+	{{- end}}
+}
+
+func Export{{.TitleCaseTableName}}(ctx context.Context, db dbutils.DB, w io.Writer) error {
+	return dbutils.ExportCSV(ctx, w, dbutils.NewQueryBuilder(db).
+		Select(
+			"id",
+			{{- range .Fields}}
+			"{{.Name}}{{if ne .Name .JSONName}} AS {{.JSONName}}{{end}}",
+			{{- end}}
+		).
+		From("{{.Name}}").
+		OrderBy("id"))
+}
+`
+
+const csvHandlerTestTemplate = `package {{.PackageName}}_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	{{- if .IncludeFmt}}
+	"fmt"
+	{{- end}}
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	{{- if .IncludeTime}}
+	"time"
+	{{- end}}
+
+	"{{.ModuleName}}/internal/{{.PackageName}}"
+	{{- range .ForeignKeys}}
+	"{{$.ModuleName}}/internal/{{.Table}}"
+	{{- end}}
+	{{- if .RequireValidation}}
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	{{- end}}
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestImport{{.TitleCaseTableName}}(t *testing.T) {
+	t.Parallel()
+
+	t.Run("successful import", func(t *testing.T) {
+		app := testutils.NewTestApp(t)
+		defer app.Close()
+
+		controller := {{.PackageName}}.New{{.SingularTitleCaseName}}CSVController(app.App)
+		app.TestRouter.Post("/{{.KebabCaseTableName}}/import", controller.Import{{.TitleCaseTableName}}Handler)
+
+		{{- range .ForeignKeys}}
+		{{.SingularCamelCaseTableName}}ID, _ := {{.Table}}.CreateTest{{.SingularTitleCaseTableName}}(t, app.DB())
+		{{- end}}
+
+		body := {{.PackageName}}.CreateTest{{.SingularTitleCaseName}}Request(t)
+		{{- range .ForeignKeys}}
+		body.{{.SingularTitleCaseTableName}}ID = {{.SingularCamelCaseTableName}}ID
+		{{- end}}
+
+		var file bytes.Buffer
+
+		writer := csv.NewWriter(&file)
+		_ = writer.Write([]string{
+			{{- range .Fields}}
+			"{{.JSONName}}",
+			{{- end}}
+		})
+		_ = writer.Write([]string{
+			{{- range .Fields}}
+			{{- if eq .GoType "string"}}
+			body.{{.TitleCaseName}},
+			{{- else if eq .GoType "time.Time"}}
+			body.{{.TitleCaseName}}.Format(time.RFC3339),
+			{{- else if eq .GoType "json.RawMessage"}}
+			string(body.{{.TitleCaseName}}),
+			{{- else}}
+			fmt.Sprint(body.{{.TitleCaseName}}),
+			{{- end}}
+			{{- end}}
+		})
+		writer.Flush()
+
+		req := httptest.NewRequest(http.MethodPost, "/{{.KebabCaseTableName}}/import", &file)
+		req.Header.Set("Content-Type", "text/csv")
+		rr := app.MakeRequest(req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+
+		var response {{.PackageName}}.Import{{.TitleCaseTableName}}Response
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+
+		if response.Imported != 1 {
+			t.Errorf("expected 1 imported row, got %d", response.Imported)
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		app := testutils.NewTestApp(t)
+		defer app.Close()
+
+		controller := {{.PackageName}}.New{{.SingularTitleCaseName}}CSVController(app.App)
+		app.TestRouter.Post("/{{.KebabCaseTableName}}/import", controller.Import{{.TitleCaseTableName}}Handler)
+
+		req := httptest.NewRequest(http.MethodPost, "/{{.KebabCaseTableName}}/import", strings.NewReader("invalid\nvalue\n"))
+		rr := app.MakeRequest(req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+	{{- if .RequireValidation}}
+
+	t.Run("failed row validation", func(t *testing.T) {
+		app := testutils.NewTestApp(t)
+		defer app.Close()
+
+		controller := {{.PackageName}}.New{{.SingularTitleCaseName}}CSVController(app.App)
+		app.TestRouter.Post("/{{.KebabCaseTableName}}/import", controller.Import{{.TitleCaseTableName}}Handler)
+
+		file := "{{range $i, $field := .Fields}}{{if $i}},{{end}}{{$field.JSONName}}{{end}}\n{{range $i, $field := .Fields}}{{if $i}},{{end}}{{end}}\n"
+
+		req := httptest.NewRequest(http.MethodPost, "/{{.KebabCaseTableName}}/import", strings.NewReader(file))
+		rr := app.MakeRequest(req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+
+		var response httputils.ErrorResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(response.Rows) != 1 || response.Rows[0].Line != 2 || len(response.Rows[0].Errors) == 0 {
+			t.Errorf("expected errors for line 2, got %+v", response.Rows)
+		}
+	})
+	{{- end}}
+}
+
+func TestExport{{.TitleCaseTableName}}(t *testing.T) {
+	t.Parallel()
+
+	app := testutils.NewTestApp(t)
+	defer app.Close()
+
+	controller := {{.PackageName}}.New{{.SingularTitleCaseName}}CSVController(app.App)
+	app.TestRouter.Get("/{{.KebabCaseTableName}}/export", controller.Export{{.TitleCaseTableName}}Handler)
+
+	{{.PackageName}}.CreateTest{{.SingularTitleCaseName}}(t, app.DB())
+
+	req := httptest.NewRequest(http.MethodGet, "/{{.KebabCaseTableName}}/export", nil)
+	rr := app.MakeRequest(req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	if rr.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("expected text/csv content type, got %s", rr.Header().Get("Content-Type"))
+	}
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedHeader := "id{{range .Fields}},{{.JSONName}}{{end}}"
+	if len(records) < 2 || strings.Join(records[0], ",") != expectedHeader {
+		t.Errorf("expected header %s and at least one row, got %v", expectedHeader, records)
+	}
+}
+`
+
+func newCSVHandlerTemplateData(moduleName string, schema Table) csvHandlerTemplateData {
+	createData := newCreateHandlerTemplateData(moduleName, schema)
+
+	fields := []RequestField{}
+	includeTime := false
+	includeFmt := false
+
+	for _, field := range createData.Fields {
+		if field.CSVGetter() == "" {
+			continue
+		}
+
+		switch field.GoType {
+		case "string", "json.RawMessage":
+		case "time.Time":
+			includeTime = true
+		default:
+			includeFmt = true
+		}
+
+		fields = append(fields, field)
+	}
+
+	return csvHandlerTemplateData{
+		PackageName:           schema.Name,
+		Name:                  schema.Name,
+		ModuleName:            moduleName,
+		HumanName:             createData.HumanName,
+		TitleCaseTableName:    stringutils.SnakeToTitle(schema.Name),
+		SingularTitleCaseName: stringutils.SnakeToTitle(strings.TrimSuffix(schema.Name, "s")),
+		KebabCaseTableName:    strings.ToLower(stringutils.SnakeToKebab(schema.Name)),
+		RequireValidation:     createData.RequireValidation,
+		IncludeTime:           includeTime,
+		IncludeFmt:            includeFmt,
+		Fields:                fields,
+		ForeignKeys:           schema.ForeignKeys,
+	}
+}
+
+// RenderCSVTemplate renders CSV import and export handlers that reuse the generated create service.
+func RenderCSVTemplate(moduleName string, schema Table) ([]byte, []byte, error) {
+	data := newCSVHandlerTemplateData(moduleName, schema)
+
+	csvTemplate, err := renderTemplateFile(csvHandlerTemplate, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error rendering csv template: %w", err)
+	}
+
+	csvTestTemplate, err := renderTemplateFile(csvHandlerTestTemplate, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error rendering csv test template: %w", err)
+	}
+
+	return csvTemplate, csvTestTemplate, nil
+}
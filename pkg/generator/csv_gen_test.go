@@ -0,0 +1,18 @@
+package generator_test
+
+import (
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/generator"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestCSVGen(t *testing.T) {
+	csvTemplate, csvTestTemplate, err := generator.RenderCSVTemplate("github.com/gurch101/gowebutils", getTestUserSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertFileEqualsString(t, "snapshots/csv_user.txt", string(csvTemplate))
+	testutils.AssertFileEqualsString(t, "snapshots/csv_user_test.txt", string(csvTestTemplate))
+}
@@ -11,11 +11,17 @@ const getHandlerTemplate = `package {{.PackageName}}
 
 import (
 	"context"
+	{{- if .Relations}}
+	"errors"
+	{{- end}}
 	"net/http"
 	"time"
 
 	"github.com/gurch101/gowebutils/pkg/app"
 	"github.com/gurch101/gowebutils/pkg/dbutils"
+	{{- if .IncludeDecimal}}
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	{{- end}}
 	"github.com/gurch101/gowebutils/pkg/httputils"
 	"github.com/gurch101/gowebutils/pkg/parser"
 )
@@ -32,6 +38,9 @@ type Get{{.SingularTitleCaseName}}ByIDResponse struct {
 	{{- range .ModelFields}}
 	{{.TitleCaseName}} {{.GoType}} ` + "`" + `json:"{{.JSONName}}"` + "`" + `
 	{{- end}}
+	{{- range .Relations}}
+	{{.TitleCaseName}} *{{.StructName}} ` + "`" + `json:"{{.JSONName}}"` + "`" + `
+	{{- end}}
 }
 
 // Get{{.SingularTitleCaseName}} godoc
@@ -59,11 +68,35 @@ func (tc *Get{{.SingularTitleCaseName}}ByIDController) Get{{.SingularTitleCaseNa
 		httputils.HandleErrorResponse(w, r, err)
 		return
 	}
+	{{- if and .HasVersion (not .Relations)}}
 
+	if httputils.NotModified(w, r, httputils.VersionETag(model.Version)) {
+		return
+	}
+	{{- end}}
+	{{range .Relations}}
+	{{.JSONName}}, err := dbutils.LoadByID(r.Context(), tc.app.DB(), "{{.Table}}", model.{{.TitleCaseFromColumnName}}, {{.StructName}}Fields)
+	if err != nil && !errors.Is(err, dbutils.ErrRecordNotFound) {
+		httputils.ServerErrorResponse(w, r, err)
+		return
+	}
+	{{end}}
+	{{- if and .HasVersion .Relations}}
+	// related records can change without changing the version, so If-None-Match isn't answered with a 304
+	w.Header().Set("ETag", httputils.VersionETag(model.Version))
+	{{end}}
+	{{- if .HasVersion}}
 	err = httputils.WriteJSON(w, http.StatusOK, &Get{{.SingularTitleCaseName}}ByIDResponse{
+	{{- else}}
+	// the ETag is computed from the body so that it changes when related records change
+	err = httputils.WriteJSONWithETag(w, r, http.StatusOK, &Get{{.SingularTitleCaseName}}ByIDResponse{
+	{{- end}}
 	{{- range .ModelFields}}
 	{{.TitleCaseName}}: model.{{.TitleCaseName}},
 	{{- end}}
+	{{- range .Relations}}
+	{{.TitleCaseName}}: {{.JSONName}},
+	{{- end}}
 	}, nil)
 	if err != nil {
 		httputils.ServerErrorResponse(w, r, err)
@@ -126,7 +159,11 @@ func TestGet{{.SingularTitleCaseName}}ByID(t *testing.T) {
 			t.Errorf("expected ID to be %d, got %d", ID, response.ID)
 		}
 		{{- range .CreateFields}}
+		{{- if eq .GoType "decimal.Decimal"}}
+		if !response.{{.TitleCaseName}}.Equal(createReq.{{.TitleCaseName}}) {
+		{{- else}}
 		if response.{{.TitleCaseName}} != createReq.{{.TitleCaseName}} {
+		{{- end}}
 			t.Errorf("expected {{.JSONName}} to be %v, got %v", createReq.{{.TitleCaseName}}, response.{{.TitleCaseName}})
 		}
 		{{- end}}
@@ -141,6 +178,33 @@ func TestGet{{.SingularTitleCaseName}}ByID(t *testing.T) {
 		}
 		{{- end}}
 	})
+	{{- if not (and .HasVersion .Relations)}}
+
+	t.Run("not modified", func(t *testing.T) {
+		app := testutils.NewTestApp(t)
+		defer app.Close()
+
+		ID, _ := {{.PackageName}}.CreateTest{{.SingularTitleCaseName}}(t, app.DB())
+
+		controller := {{.PackageName}}.NewGet{{.SingularTitleCaseName}}ByIDController(app.App)
+		app.TestRouter.Get("/{{.KebabCaseTableName}}/{id}", controller.Get{{.SingularTitleCaseName}}ByIDHandler)
+
+		rr := app.MakeRequest(testutils.CreateGetRequest(t, fmt.Sprintf("/{{.KebabCaseTableName}}/%d", ID)))
+
+		etag := rr.Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("expected an ETag")
+		}
+
+		req := testutils.CreateGetRequest(t, fmt.Sprintf("/{{.KebabCaseTableName}}/%d", ID))
+		req.Header.Set("If-None-Match", etag)
+		rr = app.MakeRequest(req)
+
+		if rr.Code != http.StatusNotModified {
+			t.Errorf("expected status code %d, got %d", http.StatusNotModified, rr.Code)
+		}
+	})
+	{{- end}}
 
 	t.Run("record not found", func(t *testing.T) {
 		app := testutils.NewTestApp(t)
@@ -175,7 +239,7 @@ func TestGet{{.SingularTitleCaseName}}ByID(t *testing.T) {
 }
 `
 
-func newGetOneHandlerTemplateData(moduleName string, schema Table) getHandlerTemplateData {
+func newGetOneHandlerTemplateData(moduleName string, schema Table, options *renderOptions) getHandlerTemplateData {
 	modelFields := []ModelField{}
 	createFields := []RequestField{}
 	hasCreatedAt := false
@@ -222,15 +286,18 @@ func newGetOneHandlerTemplateData(moduleName string, schema Table) getHandlerTem
 		SingularTitleCaseName: stringutils.SnakeToTitle(strings.TrimSuffix(schema.Name, "s")),
 		SingularCamelCaseName: stringutils.SnakeToCamel(strings.TrimSuffix(schema.Name, "s")),
 		KebabCaseTableName:    stringutils.SnakeToKebab(schema.Name),
+		IncludeDecimal:        schema.HasDecimal(),
 		ModelFields:           modelFields,
 		CreateFields:          createFields,
 		HasCreatedAt:          hasCreatedAt,
 		HasUpdatedAt:          schema.HasUpdateAt(),
+		HasVersion:            schema.HasVersion(),
+		Relations:             newRelations(schema, options),
 	}
 }
 
-func RenderGetOneTemplate(moduleName string, schema Table) ([]byte, []byte, error) {
-	data := newGetOneHandlerTemplateData(moduleName, schema)
+func RenderGetOneTemplate(moduleName string, schema Table, opts ...RenderOption) ([]byte, []byte, error) {
+	data := newGetOneHandlerTemplateData(moduleName, schema, newRenderOptions(opts))
 
 	tmpl, err := renderTemplateFile(getHandlerTemplate, data)
 	if err != nil {
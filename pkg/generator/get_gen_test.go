@@ -16,3 +16,16 @@ func TestGetGen(t *testing.T) {
 	testutils.AssertFileEqualsString(t, "snapshots/get_user_by_id.txt", string(template))
 	testutils.AssertFileEqualsString(t, "snapshots/get_user_by_id_test.txt", string(testTemplate))
 }
+
+func TestGetGenWithForeignKeyLoaders(t *testing.T) {
+	template, _, err := generator.RenderGetOneTemplate(
+		"github.com/gurch101/gowebutils",
+		getTestUserSchema(),
+		generator.WithForeignKeyLoaders(getTestSchemaWithTenants()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertFileEqualsString(t, "snapshots/get_user_by_id_with_loaders.txt", string(template))
+}
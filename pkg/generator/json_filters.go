@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/stringutils"
+)
+
+// ErrInvalidJSONFilter is returned when a JSONFilter has an unsupported path or type.
+var ErrInvalidJSONFilter = errors.New("invalid json filter")
+
+var (
+	jsonFilterPathPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*|\[\d+\])*$`)
+	jsonFilterGoTypes     = []string{"string", "int", "int64", "bool"}
+)
+
+// JSONFilter is a sub-field of a JSON column that is exposed as a search filter.
+type JSONFilter struct {
+	// Column is the JSON column, e.g. settings.
+	Column string
+	// Path is the path of the sub-field in the column, e.g. address.city.
+	Path string
+	// GoType is the type of the sub-field: string, int, int64 or bool.
+	GoType string
+}
+
+// Validate returns ErrInvalidJSONFilter if the path isn't made of object keys and array indexes or the type
+// isn't supported.
+func (f JSONFilter) Validate() error {
+	if !jsonFilterPathPattern.MatchString(f.Path) {
+		return fmt.Errorf("%w: path %q", ErrInvalidJSONFilter, f.Path)
+	}
+
+	if !slices.Contains(jsonFilterGoTypes, f.GoType) {
+		return fmt.Errorf("%w: type %q must be one of %s", ErrInvalidJSONFilter, f.GoType, strings.Join(jsonFilterGoTypes, ", "))
+	}
+
+	return nil
+}
+
+// WithJSONFilters exposes sub-fields of the JSON columns of table as filters in the generated search code.
+func WithJSONFilters(table string, filters ...JSONFilter) RenderOption {
+	return func(o *renderOptions) {
+		if o.jsonFilters == nil {
+			o.jsonFilters = map[string][]JSONFilter{}
+		}
+
+		o.jsonFilters[table] = append(o.jsonFilters[table], filters...)
+	}
+}
+
+func validateJSONFilters(schema Table, options *renderOptions) error {
+	for _, filter := range options.jsonFilters[schema.Name] {
+		if err := filter.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// JSONFilterField is a JSONFilter rendered as a search request field.
+type JSONFilterField struct {
+	RequestField
+	Column string
+	Path   string
+}
+
+func newJSONFilterFields(schema Table, options *renderOptions) []JSONFilterField {
+	filters := options.jsonFilters[schema.Name]
+	fields := make([]JSONFilterField, 0, len(filters))
+
+	for _, filter := range filters {
+		name := filter.Column + "_" + strings.NewReplacer(".", "_", "[", "_", "]", "").Replace(filter.Path)
+		name = strings.ReplaceAll(name, "__", "_")
+
+		fields = append(fields, JSONFilterField{
+			RequestField: RequestField{
+				Name:          name,
+				TitleCaseName: stringutils.SnakeToTitle(name),
+				JSONName:      stringutils.SnakeToCamel(name),
+				HumanName:     stringutils.SnakeToHuman(name),
+				GoType:        filter.GoType,
+			},
+			Column: filter.Column,
+			Path:   filter.Path,
+		})
+	}
+
+	return fields
+}
@@ -11,18 +11,23 @@ const modelTemplate = `package {{.PackageName}}
 
 import (
 	{{if .IncludeTime}}"time"{{end}}
+	{{- if .IncludeDecimal}}
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	{{- end}}
 	{{if .IncludeValidation}}"github.com/gurch101/gowebutils/pkg/validation"{{end}}
 )
 
 {{- range .UniqueFields}}
 var Err{{.TitleCaseName}}AlreadyExists = validation.Error{
 	Field:   "{{.JSONName}}",
+	Code:    validation.CodeAlreadyExists,
 	Message: "{{.HumanName}} already exists",
 }
 {{- end}}
 {{- range .ForeignKeys}}
 var Err{{.SingularTitleCaseTableName}}NotFound = validation.Error{
 	Field:   "{{.JSONName}}",
+	Code:    validation.CodeNotFound,
 	Message: "{{.HumanTableName}} not found",
 }
 {{- end}}
@@ -44,15 +49,38 @@ func newCreate{{.SingularTitleCaseName}}Model(
 		{{- end}}
 	}
 }
+{{- range .Relations}}
+
+type {{.StructName}} struct {
+	{{- range .Fields}}
+	{{.TitleCaseName}} {{.GoType}} ` + "`" + `json:"{{.JSONName}}"` + "`" + `
+	{{- end}}
+}
+
+func {{.StructName}}Fields(model *{{.StructName}}) map[string]any {
+	return map[string]any{
+		{{- range .Fields}}
+		"{{.Name}}": &model.{{.TitleCaseName}},
+		{{- end}}
+	}
+}
+{{- end}}
 `
 
-func newModelTemplateData(moduleName string, schema Table) modelTemplateData {
+func newModelTemplateData(moduleName string, schema Table, options *renderOptions) modelTemplateData {
+	relations := newRelations(schema, options)
 	modelFields := []ModelField{}
 	fields := []RequestField{}
 	uniqueFields := []UniqueField{}
 	includeTime := false
+	includeDecimal := schema.HasDecimal()
 	includeValidation := len(schema.ForeignKeys) > 0
 
+	for _, relation := range relations {
+		includeTime = includeTime || relation.IncludesTime()
+		includeDecimal = includeDecimal || relation.IncludesDecimal()
+	}
+
 	for _, field := range schema.Fields {
 		sanitizedName := getSanitizedName(field.Name)
 
@@ -83,6 +111,7 @@ func newModelTemplateData(moduleName string, schema Table) modelTemplateData {
 		Name:                  schema.Name,
 		ModuleName:            moduleName,
 		IncludeTime:           includeTime,
+		IncludeDecimal:        includeDecimal,
 		IncludeValidation:     includeValidation,
 		TitleCaseTableName:    stringutils.SnakeToTitle(schema.Name),
 		SingularTitleCaseName: stringutils.SnakeToTitle(strings.TrimSuffix(schema.Name, "s")),
@@ -91,11 +120,12 @@ func newModelTemplateData(moduleName string, schema Table) modelTemplateData {
 		Fields:                fields,
 		UniqueFields:          uniqueFields,
 		ForeignKeys:           schema.ForeignKeys,
+		Relations:             relations,
 	}
 }
 
-func RenderModelTemplate(moduleName string, schema Table) ([]byte, error) {
-	data := newModelTemplateData(moduleName, schema)
+func RenderModelTemplate(moduleName string, schema Table, opts ...RenderOption) ([]byte, error) {
+	data := newModelTemplateData(moduleName, schema, newRenderOptions(opts))
 
 	createTemplate, err := renderTemplateFile(modelTemplate, data)
 	if err != nil {
@@ -15,3 +15,16 @@ func TestModelGen(t *testing.T) {
 
 	testutils.AssertFileEqualsString(t, "snapshots/user_model.txt", string(modelTemplate))
 }
+
+func TestModelGenWithForeignKeyLoaders(t *testing.T) {
+	modelTemplate, err := generator.RenderModelTemplate(
+		"github.com/gurch101/gowebutils",
+		getTestUserSchema(),
+		generator.WithForeignKeyLoaders(getTestSchemaWithTenants()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertFileEqualsString(t, "snapshots/user_model_with_loaders.txt", string(modelTemplate))
+}
@@ -3,7 +3,10 @@ package generator
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/gurch101/gowebutils/pkg/dbutils"
 	"github.com/gurch101/gowebutils/pkg/fsutils"
@@ -32,12 +35,33 @@ func getDatabaseSchema(db *dbutils.DBPool) ([]Table, error) {
 			return nil, err
 		}
 
+		if isHistoryTable(table) {
+			continue
+		}
+
 		tables = append(tables, *table)
 	}
 
 	return tables, nil
 }
 
+// isHistoryTable returns true if table was created by dbutils.EnableHistory. Checking the columns rather
+// than the name keeps tables that only happen to end in _history, such as order_history.
+func isHistoryTable(table *Table) bool {
+	var hasID, hasOperation bool
+
+	for _, field := range table.Fields {
+		switch field.Name {
+		case "history_id":
+			hasID = true
+		case "history_operation":
+			hasOperation = true
+		}
+	}
+
+	return hasID && hasOperation
+}
+
 func processTable(db *dbutils.DBPool, tableName string) (*Table, error) {
 	tableInfo, err := getTableInfo(db, tableName)
 	if err != nil {
@@ -104,13 +128,48 @@ func processCheckConstraints(db *dbutils.DBPool, tableName string, tableInfo *Ta
 	return nil
 }
 
+// applyConstraintToFields adds a check to the column it belongs to. Checks of a single column are treated as
+// column constraints even when they are declared on the table. Other checks are kept on the table.
 func applyConstraintToFields(constraint CheckConstraint, tableInfo *Table) {
+	column := constraint.Column
+	if column == "" {
+		columns := checkColumns(constraint.Expression, tableInfo.Fields)
+		if len(columns) != 1 {
+			tableInfo.Checks = append(tableInfo.Checks, constraint)
+
+			return
+		}
+
+		column = columns[0]
+	}
+
 	for i, field := range tableInfo.Fields {
-		if field.Name == constraint.Name || strings.Contains(constraint.Expression, field.Name) {
+		if strings.EqualFold(field.Name, column) {
 			tableInfo.Fields[i].Constraints = append(tableInfo.Fields[i].Constraints, "CHECK "+constraint.Expression)
-			break
+
+			return
 		}
 	}
+
+	tableInfo.Checks = append(tableInfo.Checks, constraint)
+}
+
+// checkColumns returns the names of the fields referenced by a check expression.
+func checkColumns(expression string, fields []Field) []string {
+	identifiers := map[string]bool{}
+	for _, identifier := range identifierRegex.FindAllString(sqlStringRegex.ReplaceAllString(expression, "''"), -1) {
+		identifiers[strings.ToLower(identifier)] = true
+	}
+
+	var columns []string
+
+	for _, field := range fields {
+		if identifiers[strings.ToLower(field.Name)] {
+			columns = append(columns, field.Name)
+		}
+	}
+
+	return columns
 }
 
 func processForeignKeys(db *dbutils.DBPool, tableName string, tableInfo *Table) error {
@@ -152,7 +211,11 @@ func getTableNames(db *dbutils.DBPool) ([]string, error) {
 			return nil, fmt.Errorf("%w: failed to get table name", err)
 		}
 
-		if !strings.HasPrefix(tableName, "sqlite_") && !strings.HasPrefix(tableName, "schema_migrations") && tableName != "sessions" {
+		if !strings.HasPrefix(tableName, "sqlite_") &&
+			!strings.HasPrefix(tableName, "schema_migrations") &&
+			tableName != "sessions" &&
+			tableName != "rate_limits" &&
+			tableName != "idempotency_keys" {
 			tableNames = append(tableNames, tableName)
 		}
 	}
@@ -205,6 +268,7 @@ func getTableInfo(db *dbutils.DBPool, tableName string) (*Table, error) {
 		fields = append(fields, Field{
 			Name:        name,
 			DataType:    sqlDataType,
+			SQLType:     dataType,
 			Constraints: constraints,
 		})
 	}
@@ -216,13 +280,38 @@ func getTableInfo(db *dbutils.DBPool, tableName string) (*Table, error) {
 }
 
 func getUniqueIndexes(db *dbutils.DBPool, tableName string) ([]UniqueIndex, error) {
+	names, err := getUniqueIndexNames(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []UniqueIndex
+
+	for _, name := range names {
+		columns, err := getIndexColumns(db, name)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, UniqueIndex{
+			Name:   name,
+			Fields: columns,
+		})
+	}
+
+	return indexes, nil
+}
+
+// getUniqueIndexNames reads the index list before querying index columns so that a single
+// connection in-memory database is not queried from a second connection while rows are open.
+func getUniqueIndexNames(db *dbutils.DBPool, tableName string) ([]string, error) {
 	rows, err := db.Query(fmt.Sprintf("PRAGMA index_list(%s)", tableName))
 	if err != nil {
 		return nil, fmt.Errorf("%w: failed to query indexes", err)
 	}
 	defer fsutils.CloseAndPanic(rows)
 
-	var indexes []UniqueIndex
+	var names []string
 
 	for rows.Next() {
 		var (
@@ -238,52 +327,46 @@ func getUniqueIndexes(db *dbutils.DBPool, tableName string) ([]UniqueIndex, erro
 		}
 
 		// Only process unique indexes
-		if unique != 1 {
-			continue
-		}
-
-		// Get the columns in this index
-		indexInfo, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", name))
-		if err != nil {
-			return nil, fmt.Errorf("%w: failed to get columns in index", err)
+		if unique == 1 {
+			names = append(names, name)
 		}
+	}
 
-		defer fsutils.CloseAndPanic(indexInfo)
+	return names, rows.Err()
+}
 
-		var columns []string
+func getIndexColumns(db *dbutils.DBPool, indexName string) ([]string, error) {
+	indexInfo, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", indexName))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get columns in index", err)
+	}
+	defer fsutils.CloseAndPanic(indexInfo)
 
-		for indexInfo.Next() {
-			var (
-				seqno   int
-				cid     int
-				colName string
-			)
+	var columns []string
 
-			if err := indexInfo.Scan(&seqno, &cid, &colName); err != nil {
-				return nil, fmt.Errorf("%w: failed to get columns in index", err)
-			}
+	for indexInfo.Next() {
+		var (
+			seqno   int
+			cid     int
+			colName string
+		)
 
-			columns = append(columns, colName)
+		if err := indexInfo.Scan(&seqno, &cid, &colName); err != nil {
+			return nil, fmt.Errorf("%w: failed to get columns in index", err)
 		}
 
-		indexes = append(indexes, UniqueIndex{
-			Name:   name,
-			Fields: columns,
-		})
+		columns = append(columns, colName)
 	}
 
-	return indexes, nil
+	return columns, indexInfo.Err()
 }
 
+// getCheckConstraints parses the check constraints out of the CREATE TABLE statement of tableName. The Column of
+// column constraints is set.
 func getCheckConstraints(db *dbutils.DBPool, tableName string) ([]CheckConstraint, error) {
-	const (
-		sqliteMasterQuery = `
-			SELECT sql FROM sqlite_master
-			WHERE type='table' AND name=?`
-		checkKeyword      = "CHECK"
-		constraintKeyword = "CONSTRAINT"
-		constraintParts   = 2
-	)
+	const sqliteMasterQuery = `
+		SELECT sql FROM sqlite_master
+		WHERE type='table' AND name=?`
 
 	row := db.QueryRow(sqliteMasterQuery, tableName)
 
@@ -292,47 +375,247 @@ func getCheckConstraints(db *dbutils.DBPool, tableName string) ([]CheckConstrain
 		return nil, fmt.Errorf("%w: failed to get create table statement", err)
 	}
 
-	var checks []CheckConstraint
+	return parseCheckConstraints(createSQL), nil
+}
+
+//nolint:gochecknoglobals
+var (
+	identifierRegex = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	sqlStringRegex  = regexp.MustCompile(`'(?:[^']|'')*'`)
+)
 
-	parts := strings.Split(createSQL, ",")
+// tableConstraintKeywords start the definitions of a CREATE TABLE statement that aren't columns.
+//
+//nolint:gochecknoglobals
+var tableConstraintKeywords = []string{"CONSTRAINT", "CHECK", "PRIMARY", "UNIQUE", "FOREIGN"}
 
-	for _, raw := range parts {
-		part := strings.TrimSpace(raw)
-		if !strings.Contains(strings.ToUpper(part), checkKeyword) {
+func parseCheckConstraints(createSQL string) []CheckConstraint {
+	createSQL = stripSQLComments(createSQL)
+
+	open := strings.IndexByte(createSQL, '(')
+	if open < 0 {
+		return nil
+	}
+
+	closing := matchingParen(createSQL, open)
+	if closing < 0 {
+		return nil
+	}
+
+	var checks []CheckConstraint
+
+	for _, definition := range splitSQLList(createSQL[open+1 : closing]) {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
 			continue
 		}
 
-		var name, expression string
+		firstWord := strings.ToUpper(identifierRegex.FindString(definition))
 
-		up := strings.ToUpper(part)
-		if strings.Contains(up, constraintKeyword) {
-			// Example: CONSTRAINT name CHECK (expression)
-			nameExpr := strings.SplitN(part, constraintKeyword, constraintParts)
-			if len(nameExpr) == constraintParts {
-				nameAndCheck := strings.SplitN(nameExpr[1], checkKeyword, constraintParts)
-				if len(nameAndCheck) == constraintParts {
-					name = strings.TrimSpace(nameAndCheck[0])
-					expression = strings.TrimSpace(nameAndCheck[1])
-				}
+		var check CheckConstraint
+
+		if slices.Contains(tableConstraintKeywords, firstWord) {
+			if firstWord == "CONSTRAINT" {
+				check.Name = unquoteIdentifier(strings.Fields(definition)[1])
 			}
 		} else {
-			// Example: CHECK (expression)
-			checkExpr := strings.SplitN(part, checkKeyword, constraintParts)
-			if len(checkExpr) == constraintParts {
-				expression = strings.TrimSpace(checkExpr[1])
+			check.Column = unquoteIdentifier(strings.Fields(definition)[0])
+		}
+
+		for _, expression := range checkExpressions(definition) {
+			check.Expression = expression
+			checks = append(checks, check)
+		}
+	}
+
+	return checks
+}
+
+// checkExpressions returns the expressions of the CHECK constraints of a single column or table definition,
+// without their parentheses.
+func checkExpressions(definition string) []string {
+	var expressions []string
+
+	upper := strings.ToUpper(definition)
+
+	walkSQL(definition, func(i, depth int) bool {
+		if depth != 0 || !strings.HasPrefix(upper[i:], "CHECK") || !isWordBoundary(definition, i, i+len("CHECK")) {
+			return true
+		}
+
+		open := strings.IndexByte(definition[i:], '(')
+		if open < 0 {
+			return false
+		}
+
+		closing := matchingParen(definition, i+open)
+		if closing < 0 {
+			return false
+		}
+
+		expressions = append(expressions, normalizeSQL(definition[i+open+1:closing]))
+
+		return true
+	})
+
+	return expressions
+}
+
+// walkSQL calls visit with the index and parenthesis depth of every character of sql that isn't in a quoted string
+// or identifier. Walking stops when visit returns false.
+func walkSQL(sql string, visit func(i, depth int) bool) {
+	var quote byte
+
+	depth := 0
+
+	for i := 0; i < len(sql); i++ {
+		char := sql[i]
+
+		if quote != 0 {
+			if char == quote {
+				quote = 0
 			}
+
+			continue
 		}
 
-		expression = strings.TrimPrefix(expression, "(")
-		expression = strings.TrimSuffix(expression, ")")
+		switch char {
+		case '\'', '"', '`':
+			quote = char
 
-		checks = append(checks, CheckConstraint{
-			Name:       name,
-			Expression: expression,
-		})
+			continue
+		case '[':
+			quote = ']'
+
+			continue
+		case ')':
+			depth--
+		}
+
+		if !visit(i, depth) {
+			return
+		}
+
+		if char == '(' {
+			depth++
+		}
 	}
+}
+
+// matchingParen returns the index of the parenthesis that closes the one at open, or -1.
+func matchingParen(sql string, open int) int {
+	closing := -1
+
+	walkSQL(sql[open:], func(i, depth int) bool {
+		if i > 0 && depth == 0 && sql[open+i] == ')' {
+			closing = open + i
+
+			return false
+		}
+
+		return true
+	})
+
+	return closing
+}
+
+// splitSQLList splits a comma separated list on the commas that aren't nested in parentheses or quotes.
+func splitSQLList(sql string) []string {
+	var parts []string
+
+	start := 0
+
+	walkSQL(sql, func(i, depth int) bool {
+		if depth == 0 && sql[i] == ',' {
+			parts = append(parts, sql[start:i])
+			start = i + 1
+		}
+
+		return true
+	})
+
+	return append(parts, sql[start:])
+}
+
+// stripSQLComments replaces -- and /* */ comments outside of quotes with a space. Comments can't be skipped by
+// walkSQL since they may contain quotes.
+func stripSQLComments(sql string) string {
+	result := strings.Builder{}
+
+	var quote byte
+
+	for i := 0; i < len(sql); i++ {
+		char := sql[i]
+
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case strings.HasPrefix(sql[i:], "--"), strings.HasPrefix(sql[i:], "/*"):
+			end := len(sql)
+
+			if char == '-' {
+				if newline := strings.IndexByte(sql[i:], '\n'); newline >= 0 {
+					end = i + newline
+				}
+			} else if closing := strings.Index(sql[i+2:], "*/"); closing >= 0 {
+				end = i + 2 + closing + len("*/")
+			}
+
+			result.WriteByte(' ')
+
+			i = end - 1
+
+			continue
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '[':
+			quote = ']'
+		}
+
+		result.WriteByte(char)
+	}
+
+	return result.String()
+}
+
+// normalizeSQL collapses the whitespace outside of quotes so that equivalent expressions compare equal.
+func normalizeSQL(sql string) string {
+	result := strings.Builder{}
+	last := 0
+
+	walkSQL(sql, func(i, _ int) bool {
+		if i < last || !unicode.IsSpace(rune(sql[i])) {
+			return true
+		}
+
+		end := i
+		for end < len(sql) && unicode.IsSpace(rune(sql[end])) {
+			end++
+		}
+
+		result.WriteString(sql[last:i] + " ")
+		last = end
+
+		return true
+	})
+
+	result.WriteString(sql[last:])
+
+	return strings.TrimSpace(result.String())
+}
+
+func isWordBoundary(sql string, start, end int) bool {
+	isIdentifierChar := func(char byte) bool {
+		return char == '_' || unicode.IsLetter(rune(char)) || unicode.IsDigit(rune(char))
+	}
+
+	return (start == 0 || !isIdentifierChar(sql[start-1])) && (end >= len(sql) || !isIdentifierChar(sql[end]))
+}
 
-	return checks, nil
+func unquoteIdentifier(identifier string) string {
+	return strings.Trim(identifier, "\"`[]")
 }
 
 // nolint: cyclop
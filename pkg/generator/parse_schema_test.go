@@ -1,6 +1,7 @@
 package generator_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/gurch101/gowebutils/pkg/collectionutils"
@@ -18,7 +19,6 @@ func TestParseSchema(t *testing.T) {
 
 	pool := dbutils.FromDB(db)
 
-	// in-memory DBs don't appear to have unique indexes, so we'll skip this test for now
 	tables, err := generator.ParseSchema(pool)
 
 	if err != nil {
@@ -52,4 +52,91 @@ func TestParseSchema(t *testing.T) {
 	if usersTable.Fields[0].Constraints[0] != "PRIMARY KEY" {
 		t.Errorf("Expected first field of users table to have PRIMARY KEY constraint, but got '%s'", usersTable.Fields[0].Constraints[0])
 	}
+
+	if len(usersTable.UniqueIndexes) != 1 || usersTable.UniqueIndexes[0].Name != "idx_tenant_id_email_unique" {
+		t.Errorf("Expected users table to have a unique index on tenant_id and email, but got %v", usersTable.UniqueIndexes)
+	}
+}
+
+func TestParseSchemaHistoryTables(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	for _, statement := range []string{
+		"CREATE TABLE order_history (id INTEGER PRIMARY KEY, status TEXT NOT NULL)",
+		"CREATE TABLE tenants_history (history_id INTEGER PRIMARY KEY, history_operation TEXT NOT NULL, id INTEGER NOT NULL)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tables, err := generator.ParseSchema(dbutils.FromDB(db))
+	if err != nil {
+		t.Fatalf("Error parsing schema: %v", err)
+	}
+
+	names := collectionutils.Map(tables, func(table generator.Table) string { return table.Name })
+
+	if !slices.Contains(names, "order_history") {
+		t.Errorf("Expected order_history to be parsed, got %v", names)
+	}
+
+	if slices.Contains(names, "tenants_history") {
+		t.Errorf("Expected tenants_history to be skipped, got %v", names)
+	}
+}
+
+func TestParseSchemaCheckConstraints(t *testing.T) {
+	t.Parallel()
+	db := testutils.SetupTestDB(t)
+
+	defer fsutils.CloseAndPanic(db)
+
+	_, err := db.Exec(`CREATE TABLE orders (
+		id INTEGER PRIMARY KEY,
+		status TEXT NOT NULL CHECK (status IN ('new', 'paid')), -- the order's status, e.g. new
+		name TEXT CONSTRAINT name_not_empty CHECK(name <> ''),
+		starts_at TEXT,
+		ends_at TEXT,
+		/* checks of more than one column stay on the table */
+		CONSTRAINT valid_range CHECK (starts_at <   ends_at),
+		CHECK (length(name) < 10)
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := generator.ParseSchema(dbutils.FromDB(db))
+	if err != nil {
+		t.Fatalf("Error parsing schema: %v", err)
+	}
+
+	orders, ok := collectionutils.FindFirst(tables, func(table generator.Table) bool { return table.Name == "orders" })
+	if !ok {
+		t.Fatal("Expected to find orders table")
+	}
+
+	tests := []struct {
+		field    string
+		expected []string
+	}{
+		{field: "status", expected: []string{"NOT NULL", "CHECK status IN ('new', 'paid')"}},
+		{field: "name", expected: []string{"CHECK name <> ''", "CHECK length(name) < 10"}},
+		{field: "starts_at", expected: nil},
+	}
+
+	for _, tt := range tests {
+		field, _ := collectionutils.FindFirst(orders.Fields, func(field generator.Field) bool { return field.Name == tt.field })
+		if !slices.Equal(field.Constraints, tt.expected) {
+			t.Errorf("Expected %s to have constraints %q, got %q", tt.field, tt.expected, field.Constraints)
+		}
+	}
+
+	expectedChecks := []generator.CheckConstraint{{Name: "valid_range", Expression: "starts_at < ends_at"}}
+	if !slices.Equal(orders.Checks, expectedChecks) {
+		t.Errorf("Expected table checks %+v, got %+v", expectedChecks, orders.Checks)
+	}
 }
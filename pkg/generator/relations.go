@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/stringutils"
+)
+
+// RenderOption configures optional features of the generated code.
+type RenderOption func(*renderOptions)
+
+type renderOptions struct {
+	schema            []Table
+	foreignKeyLoaders bool
+	jsonFilters       map[string][]JSONFilter
+}
+
+// WithForeignKeyLoaders resolves foreign keys in the generated model, get and search code.
+// Related records are fetched with dbutils.LoadByID/LoadManyByID so that lookups are batched
+// into a single query per table. schema is used to look up the columns of the referenced tables.
+func WithForeignKeyLoaders(schema []Table) RenderOption {
+	return func(o *renderOptions) {
+		o.schema = schema
+		o.foreignKeyLoaders = true
+	}
+}
+
+func newRenderOptions(opts []RenderOption) *renderOptions {
+	options := &renderOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+// Relation is a foreign key that is resolved to the referenced record.
+type Relation struct {
+	ForeignKey
+	// TitleCaseName is the name of the response field holding the related record, e.g. Tenant.
+	TitleCaseName string
+	// JSONName is the json name of the response field holding the related record, e.g. tenant.
+	JSONName string
+	// StructName is the name of the generated struct for the related record, e.g. userTenant.
+	StructName string
+	Fields     []ModelField
+}
+
+func (r Relation) IncludesTime() bool {
+	for _, field := range r.Fields {
+		if field.GoType == "time.Time" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Relation) IncludesDecimal() bool {
+	for _, field := range r.Fields {
+		if field.GoType == SQLDecimal.GoType() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newRelations(schema Table, options *renderOptions) []Relation {
+	if !options.foreignKeyLoaders {
+		return nil
+	}
+
+	relations := []Relation{}
+	singularCamelCaseName := stringutils.SnakeToCamel(strings.TrimSuffix(schema.Name, "s"))
+
+	for _, fk := range schema.ForeignKeys {
+		referencedTable, ok := findTable(options.schema, fk.Table)
+		if !ok {
+			continue
+		}
+
+		name := strings.TrimSuffix(fk.FromColumn, "_id")
+		if name == fk.FromColumn {
+			name = strings.TrimSuffix(fk.Table, "s")
+		}
+
+		fields := make([]ModelField, 0, len(referencedTable.Fields))
+		for _, field := range referencedTable.Fields {
+			fields = append(fields, newModelField(getSanitizedName(field.Name), field))
+		}
+
+		relations = append(relations, Relation{
+			ForeignKey:    fk,
+			TitleCaseName: stringutils.SnakeToTitle(name),
+			JSONName:      stringutils.SnakeToCamel(name),
+			StructName:    singularCamelCaseName + stringutils.SnakeToTitle(name),
+			Fields:        fields,
+		})
+	}
+
+	return relations
+}
+
+func findTable(schema []Table, name string) (Table, bool) {
+	for _, table := range schema {
+		if table.Name == name {
+			return table, true
+		}
+	}
+
+	return Table{}, false
+}
@@ -15,14 +15,16 @@ import (
 	"github.com/gurch101/gowebutils/pkg/app"
 )
 
-func Routes(app *app.App) {
-	app.AddProtectedRoute(http.MethodGet, "/api/{{.KebabCaseTableName}}", NewSearch{{.SingularTitleCaseName}}Controller(app).Search{{.SingularTitleCaseName}}Handler)
-	app.AddProtectedRoute(http.MethodPost, "/api/{{.KebabCaseTableName}}", NewCreate{{.SingularTitleCaseName}}Controller(app).Create{{.SingularTitleCaseName}}Handler)
-	app.AddProtectedRoute(http.MethodGet, "/api/{{.KebabCaseTableName}}/{id}", NewGet{{.SingularTitleCaseName}}ByIDController(app).Get{{.SingularTitleCaseName}}ByIDHandler)
+func Routes(a *app.App) {
+	a.AddProtectedRoute(http.MethodGet, "/api/{{.KebabCaseTableName}}", NewSearch{{.SingularTitleCaseName}}Controller(a).Search{{.SingularTitleCaseName}}Handler)
+	a.AddProtectedRoute(http.MethodPost, "/api/{{.KebabCaseTableName}}", NewCreate{{.SingularTitleCaseName}}Controller(a).Create{{.SingularTitleCaseName}}Handler, app.WithIdempotency())
+	a.AddProtectedRoute(http.MethodPost, "/api/{{.KebabCaseTableName}}/import", New{{.SingularTitleCaseName}}CSVController(a).Import{{.TitleCaseTableName}}Handler)
+	a.AddProtectedRoute(http.MethodGet, "/api/{{.KebabCaseTableName}}/export", New{{.SingularTitleCaseName}}CSVController(a).Export{{.TitleCaseTableName}}Handler)
+	a.AddProtectedRoute(http.MethodGet, "/api/{{.KebabCaseTableName}}/{id}", NewGet{{.SingularTitleCaseName}}ByIDController(a).Get{{.SingularTitleCaseName}}ByIDHandler)
 	{{- if .HasUpdate}}
-	app.AddProtectedRoute(http.MethodPatch, "/api/{{.KebabCaseTableName}}/{id}", NewUpdate{{.SingularTitleCaseName}}Controller(app).Update{{.SingularTitleCaseName}}Handler)
+	a.AddProtectedRoute(http.MethodPatch, "/api/{{.KebabCaseTableName}}/{id}", NewUpdate{{.SingularTitleCaseName}}Controller(a).Update{{.SingularTitleCaseName}}Handler)
 	{{- end}}
-	app.AddProtectedRoute(http.MethodDelete, "/api/{{.KebabCaseTableName}}/{id}", NewDelete{{.SingularTitleCaseName}}Controller(app).Delete{{.SingularTitleCaseName}}Handler)
+	a.AddProtectedRoute(http.MethodDelete, "/api/{{.KebabCaseTableName}}/{id}", NewDelete{{.SingularTitleCaseName}}Controller(a).Delete{{.SingularTitleCaseName}}Handler)
 }
 `
 
@@ -31,6 +33,7 @@ func RenderRoutesTemplate(moduleName string, schema Table) ([]byte, error) {
 		"PackageName":           schema.Name,
 		"KebabCaseTableName":    stringutils.SnakeToKebab(schema.Name),
 		"SingularTitleCaseName": stringutils.SnakeToTitle(strings.TrimSuffix(schema.Name, "s")),
+		"TitleCaseTableName":    stringutils.SnakeToTitle(schema.Name),
 		"HasUpdate":             schema.HasUpdateAt(),
 	})
 
@@ -0,0 +1,493 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+)
+
+const driftMigrationPermission = 0o644
+
+// SchemaDiff is the difference between the schema produced by the migrations (expected)
+// and the schema of a live database (actual).
+type SchemaDiff struct {
+	MissingTables []Table     `json:"missingTables,omitempty"`
+	ExtraTables   []Table     `json:"extraTables,omitempty"`
+	Tables        []TableDiff `json:"tables,omitempty"`
+}
+
+// TableDiff is the difference between the expected and actual definition of a table.
+type TableDiff struct {
+	Name                 string        `json:"name"`
+	MissingColumns       []Field       `json:"missingColumns,omitempty"`
+	ExtraColumns         []Field       `json:"extraColumns,omitempty"`
+	ChangedColumns       []ColumnDiff  `json:"changedColumns,omitempty"`
+	MissingUniqueIndexes []UniqueIndex `json:"missingUniqueIndexes,omitempty"`
+	ExtraUniqueIndexes   []UniqueIndex `json:"extraUniqueIndexes,omitempty"`
+	MissingForeignKeys   []ForeignKey  `json:"missingForeignKeys,omitempty"`
+	ExtraForeignKeys     []ForeignKey  `json:"extraForeignKeys,omitempty"`
+	// MissingChecks and ExtraChecks are the table check constraints that differ. Checks of a single column
+	// are compared as part of the column.
+	MissingChecks []CheckConstraint `json:"missingChecks,omitempty"`
+	ExtraChecks   []CheckConstraint `json:"extraChecks,omitempty"`
+}
+
+// ColumnDiff is a column whose type or constraints differ from the migrations.
+type ColumnDiff struct {
+	Name     string `json:"name"`
+	Expected Field  `json:"expected"`
+	Actual   Field  `json:"actual"`
+}
+
+// MigrationSchema applies the .up.sql migrations in migrationsDir to an in-memory database
+// and returns the resulting schema.
+func MigrationSchema(migrationsDir string) ([]Table, error) {
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.up.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	sort.Strings(files)
+
+	db := dbutils.Open(":memory:")
+	defer db.Close()
+
+	// every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		if _, err := db.Exec(string(migration)); err != nil {
+			return nil, fmt.Errorf("failed to apply migration %s: %w", file, err)
+		}
+	}
+
+	return ParseSchema(dbutils.FromDB(db))
+}
+
+// DetectSchemaDrift compares the schema produced by the migrations in migrationsDir with the schema of db.
+func DetectSchemaDrift(migrationsDir string, db *dbutils.DBPool) (SchemaDiff, error) {
+	expected, err := MigrationSchema(migrationsDir)
+	if err != nil {
+		return SchemaDiff{}, err
+	}
+
+	actual, err := ParseSchema(db)
+	if err != nil {
+		return SchemaDiff{}, err
+	}
+
+	return DiffSchema(expected, actual), nil
+}
+
+// DiffSchema compares an expected schema with an actual schema. Tables and columns are matched by name,
+// unique indexes by their columns, foreign keys by their definition and check constraints by their expression.
+func DiffSchema(expected, actual []Table) SchemaDiff {
+	diff := SchemaDiff{}
+
+	actualTables := map[string]Table{}
+	for _, table := range actual {
+		actualTables[table.Name] = table
+	}
+
+	expectedTables := map[string]bool{}
+
+	for _, table := range sortedTables(expected) {
+		expectedTables[table.Name] = true
+
+		actualTable, ok := actualTables[table.Name]
+		if !ok {
+			diff.MissingTables = append(diff.MissingTables, table)
+
+			continue
+		}
+
+		if tableDiff := diffTable(table, actualTable); !tableDiff.IsEmpty() {
+			diff.Tables = append(diff.Tables, tableDiff)
+		}
+	}
+
+	for _, table := range sortedTables(actual) {
+		if !expectedTables[table.Name] {
+			diff.ExtraTables = append(diff.ExtraTables, table)
+		}
+	}
+
+	return diff
+}
+
+// IsEmpty returns true if the schemas match.
+func (d SchemaDiff) IsEmpty() bool {
+	return len(d.MissingTables) == 0 && len(d.ExtraTables) == 0 && len(d.Tables) == 0
+}
+
+// IsEmpty returns true if the table definitions match.
+func (d TableDiff) IsEmpty() bool {
+	return len(d.MissingColumns) == 0 && len(d.ExtraColumns) == 0 && len(d.ChangedColumns) == 0 &&
+		len(d.MissingUniqueIndexes) == 0 && len(d.ExtraUniqueIndexes) == 0 &&
+		len(d.MissingForeignKeys) == 0 && len(d.ExtraForeignKeys) == 0 &&
+		len(d.MissingChecks) == 0 && len(d.ExtraChecks) == 0
+}
+
+// String returns a human readable report of the differences.
+func (d SchemaDiff) String() string {
+	if d.IsEmpty() {
+		return "No schema drift detected.\n"
+	}
+
+	report := strings.Builder{}
+
+	for _, table := range d.MissingTables {
+		fmt.Fprintf(&report, "- table %s is missing\n", table.Name)
+	}
+
+	for _, table := range d.ExtraTables {
+		fmt.Fprintf(&report, "+ table %s is not in the migrations\n", table.Name)
+	}
+
+	for _, table := range d.Tables {
+		fmt.Fprintf(&report, "~ table %s\n", table.Name)
+
+		for _, field := range table.MissingColumns {
+			fmt.Fprintf(&report, "  - column %s is missing\n", field.Name)
+		}
+
+		for _, field := range table.ExtraColumns {
+			fmt.Fprintf(&report, "  + column %s is not in the migrations\n", field.Name)
+		}
+
+		for _, column := range table.ChangedColumns {
+			fmt.Fprintf(&report, "  ~ column %s: expected %s, found %s\n",
+				column.Name, columnDefinition(column.Expected), columnDefinition(column.Actual))
+		}
+
+		for _, index := range table.MissingUniqueIndexes {
+			fmt.Fprintf(&report, "  - unique index on (%s) is missing\n", strings.Join(index.Fields, ", "))
+		}
+
+		for _, index := range table.ExtraUniqueIndexes {
+			fmt.Fprintf(&report, "  + unique index %s on (%s) is not in the migrations\n", index.Name, strings.Join(index.Fields, ", "))
+		}
+
+		for _, fk := range table.MissingForeignKeys {
+			fmt.Fprintf(&report, "  - foreign key %s is missing\n", foreignKeyDefinition(fk))
+		}
+
+		for _, fk := range table.ExtraForeignKeys {
+			fmt.Fprintf(&report, "  + foreign key %s is not in the migrations\n", foreignKeyDefinition(fk))
+		}
+
+		for _, check := range table.MissingChecks {
+			fmt.Fprintf(&report, "  - check (%s) is missing\n", check.Expression)
+		}
+
+		for _, check := range table.ExtraChecks {
+			fmt.Fprintf(&report, "  + check (%s) is not in the migrations\n", check.Expression)
+		}
+	}
+
+	return report.String()
+}
+
+// CorrectiveMigration proposes SQL that brings the live database in line with the migrations.
+// Destructive statements and changes SQLite can only make by rebuilding a table are emitted as
+// comments and must be reviewed by hand.
+func (d SchemaDiff) CorrectiveMigration() string {
+	migration := strings.Builder{}
+
+	for _, table := range d.MissingTables {
+		migration.WriteString(createTableStatement(table))
+
+		for _, index := range table.UniqueIndexes {
+			migration.WriteString(createUniqueIndexStatement(table.Name, index))
+		}
+	}
+
+	for _, table := range d.Tables {
+		for _, field := range table.MissingColumns {
+			if !canAddColumn(field) {
+				fmt.Fprintf(&migration, "-- %s.%s cannot be added with ALTER TABLE; rebuild the table\n", table.Name, field.Name)
+
+				continue
+			}
+
+			fmt.Fprintf(&migration, "ALTER TABLE %s ADD COLUMN %s;\n", table.Name, columnDefinition(field))
+		}
+
+		for _, field := range table.ExtraColumns {
+			fmt.Fprintf(&migration, "-- ALTER TABLE %s DROP COLUMN %s;\n", table.Name, field.Name)
+		}
+
+		for _, column := range table.ChangedColumns {
+			fmt.Fprintf(&migration, "-- %s.%s should be %s; rebuild the table\n",
+				table.Name, column.Name, columnDefinition(column.Expected))
+		}
+
+		for _, index := range table.MissingUniqueIndexes {
+			migration.WriteString(createUniqueIndexStatement(table.Name, index))
+		}
+
+		for _, index := range table.ExtraUniqueIndexes {
+			if strings.HasPrefix(index.Name, "sqlite_autoindex_") {
+				fmt.Fprintf(&migration, "-- unique constraint on %s (%s) is not in the migrations; rebuild the table\n",
+					table.Name, strings.Join(index.Fields, ", "))
+
+				continue
+			}
+
+			fmt.Fprintf(&migration, "DROP INDEX IF EXISTS %s;\n", index.Name)
+		}
+
+		for _, fk := range table.MissingForeignKeys {
+			fmt.Fprintf(&migration, "-- %s is missing foreign key %s; rebuild the table\n", table.Name, foreignKeyDefinition(fk))
+		}
+
+		for _, fk := range table.ExtraForeignKeys {
+			fmt.Fprintf(&migration, "-- %s has foreign key %s that is not in the migrations; rebuild the table\n",
+				table.Name, foreignKeyDefinition(fk))
+		}
+
+		for _, check := range table.MissingChecks {
+			fmt.Fprintf(&migration, "-- %s is missing check (%s); rebuild the table\n", table.Name, check.Expression)
+		}
+
+		for _, check := range table.ExtraChecks {
+			fmt.Fprintf(&migration, "-- %s has check (%s) that is not in the migrations; rebuild the table\n",
+				table.Name, check.Expression)
+		}
+	}
+
+	for _, table := range d.ExtraTables {
+		fmt.Fprintf(&migration, "-- DROP TABLE %s;\n", table.Name)
+	}
+
+	return migration.String()
+}
+
+var migrationNumberRegex = regexp.MustCompile(`^(\d+)_`)
+
+// WriteCorrectiveMigration writes the corrective migration to the next sequential up migration
+// in migrationsDir and returns its path.
+func (d SchemaDiff) WriteCorrectiveMigration(migrationsDir string) (string, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	next, width := 1, 6
+
+	for _, entry := range entries {
+		match := migrationNumberRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		number, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+
+		next = max(next, number+1)
+		width = len(match[1])
+	}
+
+	filename := filepath.Join(migrationsDir, fmt.Sprintf("%0*d_fix_schema_drift.up.sql", width, next))
+
+	if err := os.WriteFile(filename, []byte(d.CorrectiveMigration()), driftMigrationPermission); err != nil {
+		return "", fmt.Errorf("failed to write migration: %w", err)
+	}
+
+	return filename, nil
+}
+
+func diffTable(expected, actual Table) TableDiff {
+	diff := TableDiff{Name: expected.Name}
+
+	for _, field := range expected.Fields {
+		actualField, ok := findField(actual.Fields, field.Name)
+		if !ok {
+			diff.MissingColumns = append(diff.MissingColumns, field)
+
+			continue
+		}
+
+		if !sameColumn(field, actualField) {
+			diff.ChangedColumns = append(diff.ChangedColumns, ColumnDiff{Name: field.Name, Expected: field, Actual: actualField})
+		}
+	}
+
+	for _, field := range actual.Fields {
+		if _, ok := findField(expected.Fields, field.Name); !ok {
+			diff.ExtraColumns = append(diff.ExtraColumns, field)
+		}
+	}
+
+	diff.MissingUniqueIndexes = uniqueIndexesNotIn(expected.UniqueIndexes, actual.UniqueIndexes)
+	diff.ExtraUniqueIndexes = uniqueIndexesNotIn(actual.UniqueIndexes, expected.UniqueIndexes)
+	diff.MissingForeignKeys = foreignKeysNotIn(expected.ForeignKeys, actual.ForeignKeys)
+	diff.ExtraForeignKeys = foreignKeysNotIn(actual.ForeignKeys, expected.ForeignKeys)
+	diff.MissingChecks = checksNotIn(expected.Checks, actual.Checks)
+	diff.ExtraChecks = checksNotIn(actual.Checks, expected.Checks)
+
+	return diff
+}
+
+func sortedTables(tables []Table) []Table {
+	sorted := slices.Clone(tables)
+	slices.SortFunc(sorted, func(a, b Table) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return sorted
+}
+
+func findField(fields []Field, name string) (Field, bool) {
+	for _, field := range fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+
+	return Field{}, false
+}
+
+func sameColumn(expected, actual Field) bool {
+	if !strings.EqualFold(expected.SQLType, actual.SQLType) {
+		return false
+	}
+
+	expectedConstraints := slices.Sorted(slices.Values(expected.Constraints))
+	actualConstraints := slices.Sorted(slices.Values(actual.Constraints))
+
+	return slices.Equal(expectedConstraints, actualConstraints)
+}
+
+func uniqueIndexesNotIn(indexes, other []UniqueIndex) []UniqueIndex {
+	var missing []UniqueIndex
+
+	for _, index := range indexes {
+		if !slices.ContainsFunc(other, func(o UniqueIndex) bool { return slices.Equal(o.Fields, index.Fields) }) {
+			missing = append(missing, index)
+		}
+	}
+
+	return missing
+}
+
+func foreignKeysNotIn(foreignKeys, other []ForeignKey) []ForeignKey {
+	var missing []ForeignKey
+
+	for _, fk := range foreignKeys {
+		if !slices.Contains(other, fk) {
+			missing = append(missing, fk)
+		}
+	}
+
+	return missing
+}
+
+func checksNotIn(checks, other []CheckConstraint) []CheckConstraint {
+	var missing []CheckConstraint
+
+	for _, check := range checks {
+		if !slices.ContainsFunc(other, func(o CheckConstraint) bool { return o.Expression == check.Expression }) {
+			missing = append(missing, check)
+		}
+	}
+
+	return missing
+}
+
+// canAddColumn reports whether SQLite can add field with ALTER TABLE ADD COLUMN. The column can't be a primary
+// key or unique, and a NOT NULL column needs a constant default other than NULL.
+func canAddColumn(field Field) bool {
+	if slices.Contains(field.Constraints, "PRIMARY KEY") || slices.Contains(field.Constraints, "UNIQUE") {
+		return false
+	}
+
+	dflt := ""
+
+	for _, constraint := range field.Constraints {
+		if value, ok := strings.CutPrefix(constraint, "DEFAULT "); ok {
+			dflt = strings.ToUpper(strings.TrimSpace(value))
+		}
+	}
+
+	if strings.HasPrefix(dflt, "(") || strings.HasPrefix(dflt, "CURRENT_") {
+		return false
+	}
+
+	return !slices.Contains(field.Constraints, "NOT NULL") || (dflt != "" && dflt != "NULL")
+}
+
+func columnDefinition(field Field) string {
+	definition := []string{field.Name, field.SQLType}
+
+	for _, constraint := range field.Constraints {
+		// ParseSchema strips the parentheses around check expressions
+		if expression, ok := strings.CutPrefix(constraint, "CHECK "); ok {
+			constraint = fmt.Sprintf("CHECK (%s)", expression)
+		}
+
+		definition = append(definition, constraint)
+	}
+
+	return strings.Join(slices.DeleteFunc(definition, func(s string) bool { return s == "" }), " ")
+}
+
+func foreignKeyDefinition(fk ForeignKey) string {
+	definition := fmt.Sprintf("(%s) REFERENCES %s(%s)", fk.FromColumn, fk.Table, fk.ToColumn)
+
+	if fk.OnDelete != "" && fk.OnDelete != "NO ACTION" {
+		definition += " ON DELETE " + fk.OnDelete
+	}
+
+	if fk.OnUpdate != "" && fk.OnUpdate != "NO ACTION" {
+		definition += " ON UPDATE " + fk.OnUpdate
+	}
+
+	return definition
+}
+
+func createTableStatement(table Table) string {
+	definitions := make([]string, 0, len(table.Fields)+len(table.ForeignKeys)+len(table.Checks))
+
+	for _, field := range table.Fields {
+		definitions = append(definitions, columnDefinition(field))
+	}
+
+	for _, fk := range table.ForeignKeys {
+		definitions = append(definitions, "FOREIGN KEY "+foreignKeyDefinition(fk))
+	}
+
+	for _, check := range table.Checks {
+		definition := fmt.Sprintf("CHECK (%s)", check.Expression)
+		if check.Name != "" {
+			definition = fmt.Sprintf("CONSTRAINT %s %s", check.Name, definition)
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n    %s\n);\n", table.Name, strings.Join(definitions, ",\n    "))
+}
+
+func createUniqueIndexStatement(table string, index UniqueIndex) string {
+	name := index.Name
+	if name == "" || strings.HasPrefix(name, "sqlite_autoindex_") {
+		name = fmt.Sprintf("%s_%s_unique", table, strings.Join(index.Fields, "_"))
+	}
+
+	return fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s);\n", name, table, strings.Join(index.Fields, ", "))
+}
@@ -0,0 +1,249 @@
+package generator_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/generator"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestDetectSchemaDrift_NoDrift(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	diff, err := generator.DetectSchemaDrift(filepath.Join(testutils.GetProjectRoot(), "db", "migrations"), dbutils.FromDB(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !diff.IsEmpty() {
+		t.Errorf("expected no drift, got:\n%s", diff)
+	}
+
+	if diff.CorrectiveMigration() != "" {
+		t.Errorf("expected an empty corrective migration, got:\n%s", diff.CorrectiveMigration())
+	}
+}
+
+func TestDetectSchemaDrift(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	drift := []string{
+		"ALTER TABLE users ADD COLUMN nickname TEXT",
+		"DROP INDEX idx_tenant_id_email_unique",
+		"DROP TABLE user_login_attempts",
+		"CREATE TABLE audit_log (id INTEGER PRIMARY KEY, message TEXT NOT NULL)",
+	}
+
+	for _, statement := range drift {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff, err := generator.DetectSchemaDrift(filepath.Join(testutils.GetProjectRoot(), "db", "migrations"), dbutils.FromDB(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diff.MissingTables) != 1 || diff.MissingTables[0].Name != "user_login_attempts" {
+		t.Errorf("expected user_login_attempts to be missing, got %v", diff.MissingTables)
+	}
+
+	if len(diff.ExtraTables) != 1 || diff.ExtraTables[0].Name != "audit_log" {
+		t.Errorf("expected audit_log to be extra, got %v", diff.ExtraTables)
+	}
+
+	if len(diff.Tables) != 1 || diff.Tables[0].Name != "users" {
+		t.Fatalf("expected only users to differ, got %v", diff.Tables)
+	}
+
+	users := diff.Tables[0]
+
+	if len(users.ExtraColumns) != 1 || users.ExtraColumns[0].Name != "nickname" {
+		t.Errorf("expected nickname to be extra, got %v", users.ExtraColumns)
+	}
+
+	if len(users.MissingUniqueIndexes) != 1 || strings.Join(users.MissingUniqueIndexes[0].Fields, ",") != "tenant_id,email" {
+		t.Errorf("expected unique index on tenant_id, email to be missing, got %v", users.MissingUniqueIndexes)
+	}
+
+	migration := diff.CorrectiveMigration()
+
+	expected := []string{
+		"CREATE TABLE IF NOT EXISTS user_login_attempts (",
+		"FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE",
+		"-- ALTER TABLE users DROP COLUMN nickname;",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_id_email_unique ON users (tenant_id, email);",
+		"-- DROP TABLE audit_log;",
+	}
+
+	for _, statement := range expected {
+		if !strings.Contains(migration, statement) {
+			t.Errorf("expected corrective migration to contain %q, got:\n%s", statement, migration)
+		}
+	}
+
+	// applying the migration fixes everything that can be fixed without rebuilding a table
+	if _, err := db.Exec(migration); err != nil {
+		t.Fatalf("failed to apply corrective migration: %v", err)
+	}
+
+	diff, err = generator.DetectSchemaDrift(filepath.Join(testutils.GetProjectRoot(), "db", "migrations"), dbutils.FromDB(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diff.MissingTables) != 0 || len(diff.Tables[0].MissingUniqueIndexes) != 0 {
+		t.Errorf("expected missing objects to be created, got:\n%s", diff)
+	}
+}
+
+func TestDiffSchema_ChangedColumn(t *testing.T) {
+	t.Parallel()
+
+	expected := []generator.Table{{
+		Name: "tenants",
+		Fields: []generator.Field{
+			{Name: "plan", SQLType: "TEXT", Constraints: []string{"NOT NULL", "CHECK plan <> ''"}},
+			{Name: "region", SQLType: "TEXT", Constraints: []string{"NOT NULL", "DEFAULT 'us'"}},
+		},
+	}}
+	actual := []generator.Table{{
+		Name:   "tenants",
+		Fields: []generator.Field{{Name: "plan", SQLType: "varchar(16)", Constraints: []string{"NOT NULL"}}},
+	}}
+
+	diff := generator.DiffSchema(expected, actual)
+
+	report := diff.String()
+	if !strings.Contains(report, "~ column plan: expected plan TEXT NOT NULL CHECK (plan <> ''), found plan varchar(16) NOT NULL") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+
+	migration := diff.CorrectiveMigration()
+	if !strings.Contains(migration, "ALTER TABLE tenants ADD COLUMN region TEXT NOT NULL DEFAULT 'us';") {
+		t.Errorf("expected region to be added, got:\n%s", migration)
+	}
+}
+
+func TestCorrectiveMigration_ColumnsThatNeedARebuild(t *testing.T) {
+	t.Parallel()
+
+	expected := []generator.Table{{
+		Name: "tenants",
+		Fields: []generator.Field{
+			{Name: "id", SQLType: "INTEGER", Constraints: []string{"PRIMARY KEY"}},
+			{Name: "name", SQLType: "TEXT", Constraints: []string{"NOT NULL"}},
+			{Name: "slug", SQLType: "TEXT", Constraints: []string{"NOT NULL", "DEFAULT NULL"}},
+			{Name: "created_at", SQLType: "DATETIME", Constraints: []string{"NOT NULL", "DEFAULT CURRENT_TIMESTAMP"}},
+			{Name: "notes", SQLType: "TEXT"},
+		},
+	}}
+	actual := []generator.Table{{
+		Name:   "tenants",
+		Fields: []generator.Field{{Name: "id", SQLType: "INTEGER", Constraints: []string{"PRIMARY KEY"}}},
+	}}
+
+	migration := generator.DiffSchema(expected, actual).CorrectiveMigration()
+
+	tests := []string{
+		"-- tenants.name cannot be added with ALTER TABLE; rebuild the table\n",
+		"-- tenants.slug cannot be added with ALTER TABLE; rebuild the table\n",
+		"-- tenants.created_at cannot be added with ALTER TABLE; rebuild the table\n",
+		"ALTER TABLE tenants ADD COLUMN notes TEXT;\n",
+	}
+
+	for _, statement := range tests {
+		if !strings.Contains(migration, statement) {
+			t.Errorf("expected corrective migration to contain %q, got:\n%s", statement, migration)
+		}
+	}
+
+	if strings.Contains(migration, "ADD COLUMN name") || strings.Contains(migration, "ADD COLUMN created_at") {
+		t.Errorf("expected NOT NULL columns without a constant default not to be added, got:\n%s", migration)
+	}
+}
+
+func TestDiffSchema_Checks(t *testing.T) {
+	t.Parallel()
+
+	expected := []generator.Table{{
+		Name:   "bookings",
+		Fields: []generator.Field{{Name: "starts_at", SQLType: "TEXT"}, {Name: "ends_at", SQLType: "TEXT"}},
+		Checks: []generator.CheckConstraint{{Name: "valid_range", Expression: "starts_at < ends_at"}},
+	}}
+	actual := []generator.Table{{
+		Name:   "bookings",
+		Fields: []generator.Field{{Name: "starts_at", SQLType: "TEXT"}, {Name: "ends_at", SQLType: "TEXT"}},
+		Checks: []generator.CheckConstraint{{Expression: "starts_at <= ends_at"}},
+	}}
+
+	diff := generator.DiffSchema(expected, actual)
+
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{output: diff.String(), expected: "  - check (starts_at < ends_at) is missing\n"},
+		{output: diff.String(), expected: "  + check (starts_at <= ends_at) is not in the migrations\n"},
+		{output: diff.CorrectiveMigration(), expected: "-- bookings is missing check (starts_at < ends_at); rebuild the table\n"},
+		{
+			output:   diff.CorrectiveMigration(),
+			expected: "-- bookings has check (starts_at <= ends_at) that is not in the migrations; rebuild the table\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if !strings.Contains(tt.output, tt.expected) {
+			t.Errorf("expected %q in:\n%s", tt.expected, tt.output)
+		}
+	}
+
+	missing := generator.DiffSchema(expected, nil).CorrectiveMigration()
+	if !strings.Contains(missing, "CONSTRAINT valid_range CHECK (starts_at < ends_at)") {
+		t.Errorf("expected the missing table to be created with its check, got:\n%s", missing)
+	}
+}
+
+func TestWriteCorrectiveMigration(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for _, name := range []string{"000001_init.up.sql", "000001_init.down.sql", "000002_users.up.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff := generator.SchemaDiff{ExtraTables: []generator.Table{{Name: "audit_log"}}}
+
+	filename, err := diff.WriteCorrectiveMigration(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filepath.Base(filename) != "000003_fix_schema_drift.up.sql" {
+		t.Errorf("expected the next migration number, got %s", filename)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "-- DROP TABLE audit_log;\n" {
+		t.Errorf("unexpected migration content %q", content)
+	}
+}
@@ -12,12 +12,18 @@ const searchHandlerTemplate = `package {{.PackageName}}
 import (
 	"context"
 	"database/sql"
+	{{- if .IncludeJSON}}
+	"encoding/json"
+	{{- end}}
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/gurch101/gowebutils/pkg/app"
 	"github.com/gurch101/gowebutils/pkg/dbutils"
+	{{- if .IncludeDecimal}}
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	{{- end}}
 	"github.com/gurch101/gowebutils/pkg/httputils"
 	"github.com/gurch101/gowebutils/pkg/parser"
 	"github.com/gurch101/gowebutils/pkg/validation"
@@ -35,6 +41,9 @@ type Search{{.SingularTitleCaseName}}Request struct {
 	{{- range .Fields}}
 	{{.TitleCaseName}} *{{.GoType}}
 	{{- end}}
+	{{- range .JSONFilters}}
+	{{.TitleCaseName}} *{{.GoType}}
+	{{- end}}
 	parser.Filters
 }
 
@@ -47,12 +56,18 @@ type Search{{.SingularTitleCaseName}}ResponseData struct {
 	{{- range .ModelFields}}
 	{{.TitleCaseName}} {{.GoType}} ` + "`" + `json:"{{.CamelCaseName}}"` + "`" + `
 	{{- end}}
+	{{- range .Relations}}
+	{{.TitleCaseName}} *{{.StructName}} ` + "`" + `json:"{{.JSONName}}"` + "`" + `
+	{{- end}}
 }
 
 
 func validateSearch{{.SingularTitleCaseName}}Request(queryString url.Values) (*Search{{.SingularTitleCaseName}}Request, []validation.Error) {
 	request := &Search{{.SingularTitleCaseName}}Request{
 		{{- range .Fields}}
+		{{.TitleCaseName}}: parser.ParseQS{{if eq .GoType "bool"}}Bool{{else if (eq .GoType "int64")}}Int64{{else if (eq .GoType "int")}}Int{{else if (eq .GoType "decimal.Decimal")}}Decimal{{else}}String{{end}}(queryString, "{{.JSONName}}", nil),
+		{{- end}}
+		{{- range .JSONFilters}}
 		{{.TitleCaseName}}: parser.ParseQS{{if eq .GoType "bool"}}Bool{{else if (eq .GoType "int64")}}Int64{{else if (eq .GoType "int")}}Int{{else}}String{{end}}(queryString, "{{.JSONName}}", nil),
 		{{- end}}
 	}
@@ -62,6 +77,9 @@ func validateSearch{{.SingularTitleCaseName}}Request(queryString url.Values) (*S
 		{{- range .ModelFields}}
 		"{{.CamelCaseName}}",
 		{{- end}}
+		{{- range .Relations}}
+		"{{.JSONName}}",
+		{{- end}}
 	}, []string{
 		"id",
 		"-id",
@@ -88,6 +106,9 @@ func validateSearch{{.SingularTitleCaseName}}Request(queryString url.Values) (*S
 {{- range .Fields}}
 //	@Param 			{{.JSONName}} query {{.GoType}} false "{{.JSONName}}"
 {{- end}}
+{{- range .JSONFilters}}
+//	@Param 			{{.JSONName}} query {{.GoType}} false "{{.JSONName}}"
+{{- end}}
 //	@Param			fields query string false "csv list of fields to include. By default all fields are included"
 //	@Param      page query int false "page number" minimum(1) default(1)
 //	@Param			pageSize	query		int		false	"page size" minimum(1)  maximum(100) default(25)
@@ -137,7 +158,21 @@ func Search{{.TitleCaseTableName}}(
 	if err != nil {
 		return nil, err
 	}
+	{{range .Relations}}
+	{{.JSONName}}IDs := make([]int64, 0, len(models))
+	for _, model := range models {
+		{{.JSONName}}IDs = append({{.JSONName}}IDs, model.{{.TitleCaseFromColumnName}})
+	}
 
+	{{.JSONName}}s, err := dbutils.LoadManyByID(ctx, db, "{{.Table}}", {{.JSONName}}IDs, {{.StructName}}Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range models {
+		models[i].{{.TitleCaseName}} = {{.JSONName}}s[models[i].{{.TitleCaseFromColumnName}}]
+	}
+	{{end}}
 	return &Search{{.SingularTitleCaseName}}Response{
 		Metadata: pagination,
 		Data:     models,
@@ -151,7 +186,11 @@ func find{{.TitleCaseTableName}}(
 	var models []Search{{.SingularTitleCaseName}}ResponseData
 	var totalRecords int
 
-	dbFields := dbutils.BuildSearchSelectFields("{{.Name}}", request.Fields, nil)
+	dbFields := dbutils.BuildSearchSelectFields("{{.Name}}", request.Fields, {{if .Relations}}map[string]string{
+		{{- range .Relations}}
+		"{{.JSONName}}": "{{$.Name}}.{{.FromColumn}}",
+		{{- end}}
+	}{{else}}nil{{end}})
 
 	err := dbutils.NewQueryBuilder(db).
 		Select(
@@ -165,6 +204,9 @@ func find{{.TitleCaseTableName}}(
 			AndWhere("{{$.Name}}.{{$field.Name}} = ?", request.{{$field.TitleCaseName}}).
 			{{end}}
 		{{end}}
+		{{- range .JSONFilters}}
+		AndWhereJSON("{{$.Name}}.{{.Column}}", "{{.Path}}", request.{{.TitleCaseName}}).
+		{{- end}}
 		OrderBy("{{.Name}}."+request.Sort).
 		Page(request.Page, request.PageSize).
 		QueryContext(ctx, func(rows *sql.Rows) error {
@@ -267,7 +309,13 @@ func TestSearch{{.SingularTitleCaseName}}(t *testing.T) {
 				}
 
         {{- range .ModelFields}}
+        {{- if eq .GoType "decimal.Decimal"}}
+        if !response.Data[0].{{.TitleCaseName}}.Equal(actualRecord.{{.TitleCaseName}}) {
+        {{- else if eq .GoType "json.RawMessage"}}
+        if string(response.Data[0].{{.TitleCaseName}}) != string(actualRecord.{{.TitleCaseName}}) {
+        {{- else}}
         if response.Data[0].{{.TitleCaseName}} != actualRecord.{{.TitleCaseName}} {
+        {{- end}}
             t.Errorf("expected {{.TitleCaseName}} to be %v, got %v", actualRecord.{{.TitleCaseName}}, response.Data[0].{{.TitleCaseName}})
         }
         {{- end}}
@@ -322,12 +370,16 @@ func TestSearch{{.SingularTitleCaseName}}(t *testing.T) {
 }
 `
 
-func newSearchHandlerTemplateData(moduleName string, schema Table) searchHandlerTemplateData {
+func newSearchHandlerTemplateData(moduleName string, schema Table, options *renderOptions) searchHandlerTemplateData {
 	fields := []RequestField{}
 	modelFields := []ModelField{}
+	includeJSON := false
 
 	for _, field := range schema.Fields {
-		if IsRequestField(field) {
+		// JSON columns can't be compared as a whole; their sub-fields are exposed with WithJSONFilters.
+		if field.DataType == SQLJson {
+			includeJSON = true
+		} else if IsRequestField(field) {
 			fields = append(fields, RequestField{
 				Name:          field.Name,
 				TitleCaseName: stringutils.SnakeToTitle(field.Name),
@@ -356,13 +408,23 @@ func newSearchHandlerTemplateData(moduleName string, schema Table) searchHandler
 		SingularTitleCaseName: stringutils.SnakeToTitle(strings.TrimSuffix(schema.Name, "s")),
 		SingularCamelCaseName: stringutils.SnakeToCamel(strings.TrimSuffix(schema.Name, "s")),
 		KebabCaseTableName:    stringutils.SnakeToKebab(schema.Name),
+		IncludeJSON:           includeJSON,
+		IncludeDecimal:        schema.HasDecimal(),
 		Fields:                fields,
+		JSONFilters:           newJSONFilterFields(schema, options),
 		ModelFields:           modelFields,
+		Relations:             newRelations(schema, options),
 	}
 }
 
-func RenderSearchTemplate(moduleName string, schema Table) ([]byte, []byte, error) {
-	data := newSearchHandlerTemplateData(moduleName, schema)
+func RenderSearchTemplate(moduleName string, schema Table, opts ...RenderOption) ([]byte, []byte, error) {
+	options := newRenderOptions(opts)
+
+	if err := validateJSONFilters(schema, options); err != nil {
+		return nil, nil, err
+	}
+
+	data := newSearchHandlerTemplateData(moduleName, schema, options)
 
 	tmpl, err := renderTemplateFile(searchHandlerTemplate, data)
 	if err != nil {
@@ -1,6 +1,7 @@
 package generator_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/gurch101/gowebutils/pkg/generator"
@@ -16,3 +17,63 @@ func TestSearchGen(t *testing.T) {
 	testutils.AssertFileEqualsString(t, "snapshots/search_user.txt", string(searchTemplate))
 	testutils.AssertFileEqualsString(t, "snapshots/search_user_test.txt", string(searchTestTemplate))
 }
+
+func TestSearchGenWithForeignKeyLoaders(t *testing.T) {
+	searchTemplate, _, err := generator.RenderSearchTemplate(
+		"github.com/gurch101/gowebutils",
+		getTestUserSchema(),
+		generator.WithForeignKeyLoaders(getTestSchemaWithTenants()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertFileEqualsString(t, "snapshots/search_user_with_loaders.txt", string(searchTemplate))
+}
+
+func TestSearchGenWithJSONFilters(t *testing.T) {
+	schema := getTestUserSchema()
+	schema.Fields = append(schema.Fields, generator.Field{
+		Name:        "settings",
+		DataType:    generator.SQLJson,
+		Constraints: []string{},
+	})
+
+	searchTemplate, _, err := generator.RenderSearchTemplate(
+		"github.com/gurch101/gowebutils",
+		schema,
+		generator.WithJSONFilters("users",
+			generator.JSONFilter{Column: "settings", Path: "theme", GoType: "string"},
+			generator.JSONFilter{Column: "settings", Path: "notifications.email", GoType: "bool"},
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.AssertFileEqualsString(t, "snapshots/search_user_with_json_filters.txt", string(searchTemplate))
+}
+
+func TestSearchGenWithInvalidJSONFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter generator.JSONFilter
+	}{
+		{name: "quote in path", filter: generator.JSONFilter{Column: "settings", Path: "theme')--", GoType: "string"}},
+		{name: "empty path", filter: generator.JSONFilter{Column: "settings", Path: "", GoType: "string"}},
+		{name: "unsupported type", filter: generator.JSONFilter{Column: "settings", Path: "theme", GoType: "float64"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := generator.RenderSearchTemplate(
+				"github.com/gurch101/gowebutils",
+				getTestUserSchema(),
+				generator.WithJSONFilters("users", tt.filter),
+			)
+			if !errors.Is(err, generator.ErrInvalidJSONFilter) {
+				t.Errorf("expected ErrInvalidJSONFilter, got %v", err)
+			}
+		})
+	}
+}
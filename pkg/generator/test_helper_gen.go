@@ -14,6 +14,9 @@ import (
 	"testing"
 
 	"github.com/gurch101/gowebutils/pkg/dbutils"
+	{{- if .IncludeDecimal}}
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	{{- end}}
 	{{- if .HasUpdate}}
 	"github.com/gurch101/gowebutils/pkg/testutils"
 	"github.com/gurch101/gowebutils/pkg/validation"
@@ -30,6 +33,8 @@ func CreateTest{{.SingularTitleCaseName}}Request(t *testing.T) Create{{.Singular
 		{{- range .Fields}}
 		{{- if or (eq .GoType "int") (eq .GoType "int64")}}
 		{{.TitleCaseName}}: 1,
+		{{- else if eq .GoType "decimal.Decimal"}}
+		{{.TitleCaseName}}: decimal.MustParse("1.50"),
 		{{- else if eq .GoType "bool"}}
 		{{.TitleCaseName}}: true,
 		{{- else if .IsEmail}}
@@ -86,6 +91,8 @@ func CreateTestUpdate{{.SingularTitleCaseName}}Request(t *testing.T) Update{{.Si
 	{{.TitleCaseName}}: testutils.Int64Ptr(1),
 	{{- else if eq .GoType "int"}}
 	{{.TitleCaseName}}: testutils.IntPtr(2),
+	{{- else if eq .GoType "decimal.Decimal"}}
+	{{.TitleCaseName}}: testutils.DecimalPtr(decimal.MustParse("2.25")),
 	{{- else if eq .GoType "bool"}}
 	{{.TitleCaseName}}: testutils.BoolPtr(false),
 	{{- else if .IsEmail}}
@@ -106,6 +113,8 @@ func CreateTestUpdate{{.SingularTitleCaseName}}RequestWithValues(t *testing.T, r
 	{{.TitleCaseName}}: testutils.Int64Ptr(validation.Coalesce(req.{{.TitleCaseName}}, 1)),
 	{{- else if eq .GoType "int"}}
 	{{.TitleCaseName}}: testutils.IntPtr(validation.Coalesce(req.{{.TitleCaseName}}, 2)),
+	{{- else if eq .GoType "decimal.Decimal"}}
+	{{.TitleCaseName}}: testutils.DecimalPtr(validation.Coalesce(req.{{.TitleCaseName}}, decimal.MustParse("2.25"))),
 	{{- else if eq .GoType "bool"}}
 	{{.TitleCaseName}}: testutils.BoolPtr(validation.Coalesce(req.{{.TitleCaseName}}, false)),
 	{{- else if .IsEmail}}
@@ -147,6 +156,7 @@ func newTestHelperTemplateData(moduleName string, schema Table) testHelperTempla
 		Fields:                fields,
 		ForeignKeys:           schema.ForeignKeys,
 		HasUpdate:             schema.HasUpdateAt(),
+		IncludeDecimal:        schema.HasDecimal(),
 	}
 }
 
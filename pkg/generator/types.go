@@ -12,6 +12,23 @@ type Table struct {
 	Fields        []Field
 	UniqueIndexes []UniqueIndex
 	ForeignKeys   []ForeignKey
+	// Checks are the check constraints that reference more than one column. Checks of a single column are
+	// added to its Constraints.
+	Checks []CheckConstraint
+}
+
+// HasDecimal returns true if the table has a DECIMAL column.
+func (t Table) HasDecimal() bool {
+	return collectionutils.Contains(t.Fields, func(field Field) bool {
+		return field.DataType == SQLDecimal
+	})
+}
+
+// HasVersion returns true if the table has a version column used for optimistic locking.
+func (t Table) HasVersion() bool {
+	return collectionutils.Contains(t.Fields, func(field Field) bool {
+		return field.Name == "version"
+	})
 }
 
 func (t Table) HasUpdateAt() bool {
@@ -21,8 +38,10 @@ func (t Table) HasUpdateAt() bool {
 }
 
 type Field struct {
-	Name        string
-	DataType    SQLDataType
+	Name     string
+	DataType SQLDataType
+	// SQLType is the column type as declared in the CREATE TABLE statement.
+	SQLType     string
 	Constraints []string
 }
 
@@ -32,7 +51,9 @@ type UniqueIndex struct {
 }
 
 type CheckConstraint struct {
-	Name       string
+	Name string
+	// Column is set for column constraints.
+	Column     string
 	Expression string
 }
 
@@ -121,6 +142,7 @@ type createHandlerTemplateData struct {
 	KebabCaseTableName    string
 	UniqueConstraint      bool
 	IncludeTime           bool
+	IncludeDecimal        bool
 	RequireValidation     bool
 	UniqueFields          []UniqueField
 	Fields                []RequestField
@@ -148,10 +170,13 @@ type getHandlerTemplateData struct {
 	SingularTitleCaseName string
 	SingularCamelCaseName string
 	KebabCaseTableName    string
+	IncludeDecimal        bool
 	ModelFields           []ModelField
 	CreateFields          []RequestField
 	HasCreatedAt          bool
 	HasUpdatedAt          bool
+	HasVersion            bool
+	Relations             []Relation
 }
 
 type updateHandlerTemplateData struct {
@@ -163,6 +188,7 @@ type updateHandlerTemplateData struct {
 	SingularTitleCaseName string
 	SingularCamelCaseName string
 	RequireValidation     bool
+	IncludeDecimal        bool
 	ModelFields           []ModelField
 	Fields                []RequestField
 	ForeignKeys           []ForeignKey
@@ -177,8 +203,12 @@ type searchHandlerTemplateData struct {
 	SingularTitleCaseName string
 	SingularCamelCaseName string
 	KebabCaseTableName    string
+	IncludeJSON           bool
+	IncludeDecimal        bool
 	Fields                []RequestField
+	JSONFilters           []JSONFilterField
 	ModelFields           []ModelField
+	Relations             []Relation
 }
 
 type modelTemplateData struct {
@@ -189,11 +219,28 @@ type modelTemplateData struct {
 	SingularTitleCaseName string
 	SingularCamelCaseName string
 	IncludeTime           bool
+	IncludeDecimal        bool
 	IncludeValidation     bool
 	ModelFields           []ModelField
 	Fields                []RequestField
 	UniqueFields          []UniqueField
 	ForeignKeys           []ForeignKey
+	Relations             []Relation
+}
+
+type csvHandlerTemplateData struct {
+	PackageName           string
+	Name                  string
+	ModuleName            string
+	HumanName             string
+	TitleCaseTableName    string
+	SingularTitleCaseName string
+	KebabCaseTableName    string
+	RequireValidation     bool
+	IncludeTime           bool
+	IncludeFmt            bool
+	Fields                []RequestField
+	ForeignKeys           []ForeignKey
 }
 
 type testHelperTemplateData struct {
@@ -204,6 +251,7 @@ type testHelperTemplateData struct {
 	SingularTitleCaseName string
 	SingularCamelCaseName string
 	HasUpdate             bool
+	IncludeDecimal        bool
 	Fields                []RequestField
 	UniqueFields          []UniqueField
 	ForeignKeys           []ForeignKey
@@ -247,6 +295,33 @@ func (field RequestField) SwaggerTag() string {
 	return ""
 }
 
+// CSVGetter returns the dbutils.CSVRecord method that parses the field or an empty string
+// if the field cannot be represented in a CSV cell.
+func (field RequestField) CSVGetter() string {
+	switch field.GoType {
+	case "string":
+		return "String"
+	case "int":
+		return "Int"
+	case "int64":
+		return "Int64"
+	case "bool":
+		return "Bool"
+	case "float64":
+		return "Float64"
+	case "decimal.Decimal":
+		return "Decimal"
+	case "time.Time":
+		return "Time"
+	case "time.Duration":
+		return "Duration"
+	case "json.RawMessage":
+		return "JSON"
+	default:
+		return ""
+	}
+}
+
 type ModelField struct {
 	Name          string
 	TitleCaseName string
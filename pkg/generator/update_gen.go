@@ -11,10 +11,14 @@ const updateHandlerTemplate = `package {{.PackageName}}
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gurch101/gowebutils/pkg/app"
 	"github.com/gurch101/gowebutils/pkg/dbutils"
+	{{- if .IncludeDecimal}}
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	{{- end}}
 	"github.com/gurch101/gowebutils/pkg/httputils"
 	"github.com/gurch101/gowebutils/pkg/parser"
 	"github.com/gurch101/gowebutils/pkg/validation"
@@ -47,8 +51,9 @@ type Update{{.SingularTitleCaseName}}Request struct {
 //	@Produce		json
 //	@Param			id		path		int64					true	"{{.SingularTitleCaseName}} ID"
 //	@Param			{{.SingularCamelCaseName}}	body		Update{{.SingularTitleCaseName}}Request	true	"Update {{.SingularCamelCaseName}}"
+//	@Param			If-Match	header		string					false	"ETag of the {{.SingularCamelCaseName}} being updated"
 //	@Success		200		{object}	Get{{.SingularTitleCaseName}}ByIDResponse
-//	@Failure		400,422,404,500	{object}	httputils.ErrorResponse
+//	@Failure		400,412,422,404,500	{object}	httputils.ErrorResponse
 //	@Router			/{{.KebabCaseTableName}}/{id} [patch]
 func (tc *Update{{.SingularTitleCaseName}}Controller) Update{{.SingularTitleCaseName}}Handler(w http.ResponseWriter, r *http.Request) {
 	id, err := parser.ParseIDPathParam(r)
@@ -66,7 +71,14 @@ func (tc *Update{{.SingularTitleCaseName}}Controller) Update{{.SingularTitleCase
 		return
 	}
 
-	resp, err := Update{{.SingularTitleCaseName}}(r.Context(), tc.app.DB(), id, &req)
+	version, err := httputils.IfMatchVersion(r)
+	if err != nil {
+		httputils.HandleErrorResponse(w, r, err)
+
+		return
+	}
+
+	resp, err := Update{{.SingularTitleCaseName}}(r.Context(), tc.app.DB(), id, version, &req)
 	if err != nil {
 		httputils.HandleErrorResponse(w, r, err)
 
@@ -77,7 +89,7 @@ func (tc *Update{{.SingularTitleCaseName}}Controller) Update{{.SingularTitleCase
 		w,
 		http.StatusOK,
 		resp,
-		nil)
+		http.Header{"ETag": []string{httputils.VersionETag(resp.Version)}})
 
 	if err != nil {
 		httputils.ServerErrorResponse(w, r, err)
@@ -88,6 +100,7 @@ func Update{{.SingularTitleCaseName}}(
 	ctx context.Context,
 	db dbutils.DB,
 	id int64,
+	version *int64,
 	req *Update{{.SingularTitleCaseName}}Request,
 ) (*Get{{.SingularTitleCaseName}}ByIDResponse, error) {
 
@@ -96,6 +109,10 @@ func Update{{.SingularTitleCaseName}}(
 		return nil, err
 	}
 
+	if version != nil && *version != model.Version {
+		return nil, httputils.ErrPreconditionFailed
+	}
+
 	{{range .ForeignKeys}}
 	if req.{{.TitleCaseFromColumnName}} != nil && *req.{{.TitleCaseFromColumnName}} != model.{{.TitleCaseFromColumnName}} && !{{.Table}}.{{.SingularTitleCaseTableName}}Exists(ctx, db, *req.{{.TitleCaseFromColumnName}}) {
 		return nil, Err{{.SingularTitleCaseTableName}}NotFound
@@ -122,9 +139,17 @@ func Update{{.SingularTitleCaseName}}(
 	{{- end}}
 
 	if err := update{{.SingularTitleCaseName}}(ctx, db, model); err != nil {
+		// the record changed after it was read, so it no longer matches If-Match either
+		if version != nil && errors.Is(err, dbutils.ErrEditConflict) {
+			return nil, httputils.ErrPreconditionFailed
+		}
+
 		return nil, err
 	}
 
+	// UpdateByID increments the version
+	model.Version++
+
 	return &Get{{.SingularTitleCaseName}}ByIDResponse{
 		{{- range .ModelFields}}
 		{{.TitleCaseName}}: model.{{.TitleCaseName}},
@@ -193,12 +218,35 @@ func TestUpdate{{.SingularTitleCaseName}}Handler(t *testing.T) {
 			t.Errorf("expected ID to be %d, got %d", ID, response.ID)
 		}
 		{{- range .Fields}}
+		{{- if eq .GoType "decimal.Decimal"}}
+		if !response.{{.TitleCaseName}}.Equal(*updateReq.{{.TitleCaseName}}) {
+		{{- else}}
 		if response.{{.TitleCaseName}} != *updateReq.{{.TitleCaseName}} {
+		{{- end}}
 			t.Errorf("expected {{.TitleCaseName}} to be %v, got %v", *updateReq.{{.TitleCaseName}}, response.{{.TitleCaseName}})
 		}
 		{{- end}}
 	})
 
+	t.Run("stale If-Match header", func(t *testing.T) {
+		app := testutils.NewTestApp(t)
+
+		defer app.Close()
+
+		ID, _ := {{.PackageName}}.CreateTest{{.SingularTitleCaseName}}(t, app.DB())
+
+		controller := {{.PackageName}}.NewUpdate{{.SingularTitleCaseName}}Controller(app.App)
+		app.TestRouter.Patch("/{{.KebabCaseTableName}}/{id}", controller.Update{{.SingularTitleCaseName}}Handler)
+
+		req := testutils.CreatePatchRequest(t, fmt.Sprintf("/{{.KebabCaseTableName}}/%d", ID), {{.PackageName}}.Update{{.SingularTitleCaseName}}Request{})
+		req.Header.Set("If-Match", "W/\"99\"")
+		rr := app.MakeRequest(req)
+
+		if rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("expected status code %d, got %d", http.StatusPreconditionFailed, rr.Code)
+		}
+	})
+
 	t.Run("invalid request id", func(t *testing.T) {
 		app := testutils.NewTestApp(t)
 		defer app.Close()
@@ -319,6 +367,7 @@ func newUpdateHandlerTemplateData(moduleName string, schema Table) updateHandler
 		SingularTitleCaseName: stringutils.SnakeToTitle(strings.TrimSuffix(schema.Name, "s")),
 		SingularCamelCaseName: stringutils.SnakeToCamel(strings.TrimSuffix(schema.Name, "s")),
 		RequireValidation:     requireValidation,
+		IncludeDecimal:        schema.HasDecimal(),
 		ModelFields:           modelFields,
 		Fields:                fields,
 		ForeignKeys:           schema.ForeignKeys,
@@ -0,0 +1,204 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+)
+
+// implicitTLSPort is the SMTP port that expects a TLS handshake before the greeting.
+const implicitTLSPort = "465"
+
+// fileServiceProbe is the file that FileServiceReachable looks up. It doesn't need to exist.
+const fileServiceProbe = ".healthcheck"
+
+var (
+	// ErrMigrationsPending is returned by MigrationsApplied when the database is behind the migration files.
+	ErrMigrationsPending = errors.New("migrations pending")
+	// ErrMigrationDirty is returned by MigrationsApplied when the last migration failed part way.
+	ErrMigrationDirty = errors.New("last migration failed")
+	// ErrLowDiskSpace is returned by DiskSpace when the free space is below the minimum.
+	ErrLowDiskSpace = errors.New("low disk space")
+)
+
+// PingDB checks that a connection to db can be made.
+func PingDB(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("failed to ping database: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// WritableDB checks that a write lock on db can be taken by starting and rolling back an immediate transaction.
+// The check waits for a connection of db, so it fails with a timeout while another write holds the lock for longer
+// than the check timeout.
+func WritableDB(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+
+		defer fsutils.CloseAndPanic(conn)
+
+		if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+			return fmt.Errorf("failed to lock database for writing: %w", err)
+		}
+
+		// roll back even if the check timed out so that the connection isn't returned to the pool in a transaction
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK"); err != nil {
+			return fmt.Errorf("failed to roll back database write check: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// MigrationsApplied checks that the last migration recorded by golang-migrate in the schema_migrations table
+// succeeded and, if migrationsDir exists, that it is the latest *.up.sql file in the directory.
+func MigrationsApplied(db *sql.DB, migrationsDir string) CheckFunc {
+	return func(ctx context.Context) error {
+		var (
+			version uint64
+			dirty   bool
+		)
+
+		err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+		if err != nil {
+			return fmt.Errorf("failed to read migration version: %w", err)
+		}
+
+		if dirty {
+			return fmt.Errorf("%w: version %d", ErrMigrationDirty, version)
+		}
+
+		latest, err := latestMigration(migrationsDir)
+		if err != nil {
+			return err
+		}
+
+		if version < latest {
+			return fmt.Errorf("%w: database is at version %d, latest is %d", ErrMigrationsPending, version, latest)
+		}
+
+		return nil
+	}
+}
+
+// latestMigration returns the highest version of the *.up.sql files in dir, or 0 if dir doesn't exist.
+func latestMigration(dir string) (uint64, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var latest uint64
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".up.sql") {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(file.Name(), "_")
+
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}
+
+// FileServiceReachable checks that files can be looked up in the file service.
+func FileServiceReachable(files fsutils.FileService) CheckFunc {
+	return func(ctx context.Context) error {
+		_, err := files.Stat(ctx, fileServiceProbe)
+		if err != nil && !errors.Is(err, fsutils.ErrFileNotFound) {
+			return fmt.Errorf("failed to reach file service: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// SMTPDial checks that the SMTP server at addr (host:port) responds with a greeting. Port 465 is dialed with TLS.
+// It doesn't authenticate.
+func SMTPDial(addr string) CheckFunc {
+	return func(ctx context.Context) error {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return fmt.Errorf("invalid smtp address: %w", err)
+		}
+
+		var conn net.Conn
+
+		if port == implicitTLSPort {
+			//nolint: exhaustruct
+			dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+			conn, err = dialer.DialContext(ctx, "tcp", addr)
+		} else {
+			var dialer net.Dialer
+			conn, err = dialer.DialContext(ctx, "tcp", addr)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to dial smtp server: %w", err)
+		}
+
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		client, err := smtp.NewClient(conn, host)
+		if err != nil {
+			conn.Close()
+
+			return fmt.Errorf("failed to read smtp greeting: %w", err)
+		}
+
+		//nolint: errcheck
+		defer client.Close()
+
+		if err := client.Quit(); err != nil {
+			return fmt.Errorf("failed to quit smtp session: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// DiskSpace checks that the file system holding path has at least minFreeBytes available. It always passes on
+// platforms other than Linux and macOS.
+func DiskSpace(path string, minFreeBytes uint64) CheckFunc {
+	return func(_ context.Context) error {
+		free, ok, err := freeBytes(path)
+		if err != nil {
+			return fmt.Errorf("failed to read free disk space: %w", err)
+		}
+
+		if ok && free < minFreeBytes {
+			return fmt.Errorf("%w: %d bytes free, need %d", ErrLowDiskSpace, free, minFreeBytes)
+		}
+
+		return nil
+	}
+}
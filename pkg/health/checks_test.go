@@ -0,0 +1,183 @@
+package health_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/health"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestPingDB(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+
+	if err := health.PingDB(db)(context.Background()); err != nil {
+		t.Errorf("expected the ping to succeed, got %v", err)
+	}
+
+	db.Close()
+
+	if err := health.PingDB(db)(context.Background()); err == nil {
+		t.Error("expected the ping of a closed database to fail")
+	}
+}
+
+func TestWritableDB(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	db.SetMaxOpenConns(1)
+
+	if err := health.WritableDB(db)(context.Background()); err != nil {
+		t.Errorf("expected the write check to succeed, got %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := health.WritableDB(db)(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the write check to time out while a write holds the connection, got %v", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := health.WritableDB(db)(context.Background()); err != nil {
+		t.Errorf("expected the write check to succeed once the write finished, got %v", err)
+	}
+
+	db.Close()
+
+	if err := health.WritableDB(db)(context.Background()); err == nil {
+		t.Error("expected the write check of a closed database to fail")
+	}
+}
+
+func TestMigrationsApplied(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"000001_init.up.sql", "000001_init.down.sql", "000002_users.up.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		version       int
+		dirty         bool
+		dir           string
+		expectedError error
+	}{
+		{name: "latest", version: 2, dirty: false, dir: dir, expectedError: nil},
+		{name: "pending", version: 1, dirty: false, dir: dir, expectedError: health.ErrMigrationsPending},
+		{name: "dirty", version: 2, dirty: true, dir: dir, expectedError: health.ErrMigrationDirty},
+		{name: "no migrations directory", version: 1, dirty: false, dir: filepath.Join(dir, "missing"), expectedError: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := testutils.SetupTestDB(t)
+			defer db.Close()
+
+			_, err := db.Exec("CREATE TABLE schema_migrations (version uint64, dirty bool)")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = db.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)", tt.version, tt.dirty)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = health.MigrationsApplied(db, tt.dir)(context.Background())
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestFileServiceReachable(t *testing.T) {
+	t.Parallel()
+
+	if err := health.FileServiceReachable(testutils.NewMockFileService())(context.Background()); err != nil {
+		t.Errorf("expected a missing file to pass, got %v", err)
+	}
+}
+
+func TestSMTPDial(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = conn.Write([]byte("220 localhost ESMTP\r\n"))
+
+		reader := bufio.NewReader(conn)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if strings.HasPrefix(line, "QUIT") {
+				_, _ = conn.Write([]byte("221 bye\r\n"))
+
+				return
+			}
+
+			_, _ = conn.Write([]byte("250 localhost\r\n"))
+		}
+	}()
+
+	if err := health.SMTPDial(listener.Addr().String())(context.Background()); err != nil {
+		t.Errorf("expected the dial to succeed, got %v", err)
+	}
+
+	if err := health.SMTPDial("127.0.0.1:1")(context.Background()); err == nil {
+		t.Error("expected the dial to fail")
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	t.Parallel()
+
+	if err := health.DiskSpace(t.TempDir(), 1)(context.Background()); err != nil {
+		t.Errorf("expected free space, got %v", err)
+	}
+
+	err := health.DiskSpace(t.TempDir(), 1<<62)(context.Background())
+	if err != nil && !errors.Is(err, health.ErrLowDiskSpace) {
+		t.Errorf("expected low disk space, got %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package health
+
+// freeBytes isn't supported on this platform, so ok is false.
+func freeBytes(_ string) (uint64, bool, error) {
+	return 0, false, nil
+}
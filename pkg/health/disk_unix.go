@@ -0,0 +1,20 @@
+//go:build linux || darwin
+
+package health
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// freeBytes returns the space available to unprivileged users on the file system holding path.
+func freeBytes(path string) (uint64, bool, error) {
+	var stat syscall.Statfs_t
+
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, false, fmt.Errorf("statfs %s: %w", path, err)
+	}
+
+	//nolint: gosec
+	return stat.Bavail * uint64(stat.Bsize), true, nil
+}
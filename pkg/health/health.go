@@ -0,0 +1,310 @@
+// Package health runs liveness and readiness checks and serves their results to load balancers and orchestrators.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+const (
+	// StatusOK is the status of passing checks and of reports where every check passed.
+	StatusOK = "ok"
+	// StatusError is the status of failing checks.
+	StatusError = "error"
+	// StatusUnavailable is the status of reports where a check failed or the server is shutting down.
+	StatusUnavailable = "unavailable"
+)
+
+// shutdownCheck is the name of the result that fails readiness once shutdown starts.
+const shutdownCheck = "shutdown"
+
+var (
+	// ErrTimeout is returned by checks that don't finish within Config.Timeout.
+	ErrTimeout = errors.New("health check timed out")
+	// ErrShuttingDown is reported by readiness checks once the server starts shutting down.
+	ErrShuttingDown = errors.New("server is shutting down")
+)
+
+// CheckFunc returns an error when the dependency it checks is unhealthy. It should return when ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// Config configures a Checker.
+type Config struct {
+	// Timeout of each check. Defaults to 2 seconds.
+	Timeout time.Duration
+	// CacheTTL is how long check results are reused so that frequent probes don't overload dependencies.
+	// Zero disables caching. ConfigFromEnv defaults it to 5 seconds.
+	CacheTTL time.Duration
+}
+
+// ConfigFromEnv returns the checker configuration set by the HEALTH_* environment variables.
+func ConfigFromEnv() Config {
+	timeout, err := parser.ParseEnvInt("HEALTH_CHECK_TIMEOUT", int(defaultTimeout.Seconds()))
+	if err != nil {
+		panic(err)
+	}
+
+	cacheTTL, err := parser.ParseEnvInt("HEALTH_CACHE_TTL", int(defaultCacheTTL.Seconds()))
+	if err != nil {
+		panic(err)
+	}
+
+	return Config{
+		Timeout:  time.Duration(timeout) * time.Second,
+		CacheTTL: time.Duration(cacheTTL) * time.Second,
+	}
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"durationMs"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// Report is the outcome of the liveness or readiness checks.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// OK returns true if every check passed.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+
+	mu      sync.Mutex
+	result  Result
+	expires time.Time
+}
+
+// Checker runs registered checks. Liveness checks tell whether the process should be restarted; readiness
+// checks tell whether it should receive traffic.
+type Checker struct {
+	config Config
+
+	mu        sync.RWMutex
+	liveness  []*check
+	readiness []*check
+
+	shuttingDown atomic.Bool
+}
+
+// NewChecker creates a checker without any checks.
+func NewChecker(config Config) *Checker {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	if config.CacheTTL < 0 {
+		config.CacheTTL = 0
+	}
+
+	//nolint: exhaustruct
+	return &Checker{config: config}
+}
+
+// AddLivenessCheck registers a check that fails liveness. Only add checks for failures that restarting the
+// process fixes; a dependency that is down should fail readiness instead.
+func (c *Checker) AddLivenessCheck(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.liveness = append(c.liveness, &check{name: name, fn: fn}) //nolint: exhaustruct
+}
+
+// AddReadinessCheck registers a check that fails readiness.
+func (c *Checker) AddReadinessCheck(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readiness = append(c.readiness, &check{name: name, fn: fn}) //nolint: exhaustruct
+}
+
+// Shutdown fails readiness so that load balancers stop sending new requests while in-flight requests finish.
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Live runs the liveness checks.
+func (c *Checker) Live(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.liveness
+	c.mu.RUnlock()
+
+	return c.run(ctx, checks)
+}
+
+// Ready runs the readiness checks. Readiness fails without running the checks once Shutdown is called.
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.shuttingDown.Load() {
+		return Report{
+			Status: StatusUnavailable,
+			Checks: map[string]Result{
+				shutdownCheck: {
+					Status:     StatusError,
+					Error:      ErrShuttingDown.Error(),
+					DurationMS: 0,
+					CheckedAt:  time.Now(),
+				},
+			},
+		}
+	}
+
+	c.mu.RLock()
+	checks := c.readiness
+	c.mu.RUnlock()
+
+	return c.run(ctx, checks)
+}
+
+// run runs checks concurrently.
+func (c *Checker) run(ctx context.Context, checks []*check) Report {
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+
+	for i, chk := range checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[i] = c.runCheck(ctx, chk)
+		}()
+	}
+
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	for i, chk := range checks {
+		report.Checks[chk.name] = results[i]
+
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+
+	return report
+}
+
+// runCheck returns the cached result of chk or runs it. Concurrent probes wait for a single run.
+func (c *Checker) runCheck(ctx context.Context, chk *check) Result {
+	chk.mu.Lock()
+	defer chk.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(chk.expires) {
+		return chk.result
+	}
+
+	// the result is shared with other requests, so it shouldn't fail because this request was canceled
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.config.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- chk.fn(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("%w after %s", ErrTimeout, c.config.Timeout)
+	}
+
+	chk.result = Result{
+		Status:     StatusOK,
+		Error:      "",
+		DurationMS: time.Since(now).Milliseconds(),
+		CheckedAt:  now,
+	}
+
+	if err != nil {
+		chk.result.Status = StatusError
+		chk.result.Error = err.Error()
+	}
+
+	chk.expires = now.Add(c.config.CacheTTL)
+
+	return chk.result
+}
+
+// LivenessHandler responds with the liveness report. See ReadinessHandler.
+func (c *Checker) LivenessHandler(showDetails func(r *http.Request) bool) http.HandlerFunc {
+	return reportHandler(c.Live, showDetails)
+}
+
+// ReadinessHandler responds with 200 OK if every readiness check passed and 503 Service Unavailable otherwise.
+// The result of each check is only included when showDetails returns true, since errors can reveal details
+// about the infrastructure. showDetails may be nil.
+func (c *Checker) ReadinessHandler(showDetails func(r *http.Request) bool) http.HandlerFunc {
+	return reportHandler(c.Ready, showDetails)
+}
+
+func reportHandler(
+	run func(ctx context.Context) Report,
+	showDetails func(r *http.Request) bool,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+
+		if showDetails == nil || !showDetails(r) {
+			report.Checks = nil
+		}
+
+		headers := http.Header{"Cache-Control": []string{"no-store"}}
+
+		err := httputils.WriteJSON(w, status, report, headers)
+		if err != nil {
+			httputils.ServerErrorResponse(w, r, err)
+		}
+	}
+}
+
+// Names returns the names of the liveness and readiness checks in alphabetical order.
+func (c *Checker) Names() (liveness, readiness []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return names(c.liveness), names(c.readiness)
+}
+
+func names(checks []*check) []string {
+	result := make([]string, 0, len(checks))
+	for _, chk := range checks {
+		result = append(result, chk.name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,201 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/health"
+)
+
+var errDown = errors.New("down")
+
+func TestReady(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		checks         map[string]health.CheckFunc
+		expectedStatus string
+		expectedChecks map[string]string
+	}{
+		{
+			name:           "no checks",
+			checks:         nil,
+			expectedStatus: health.StatusOK,
+			expectedChecks: map[string]string{},
+		},
+		{
+			name: "passing checks",
+			checks: map[string]health.CheckFunc{
+				"db":    func(context.Context) error { return nil },
+				"files": func(context.Context) error { return nil },
+			},
+			expectedStatus: health.StatusOK,
+			expectedChecks: map[string]string{"db": health.StatusOK, "files": health.StatusOK},
+		},
+		{
+			name: "failing check",
+			checks: map[string]health.CheckFunc{
+				"db":    func(context.Context) error { return nil },
+				"files": func(context.Context) error { return errDown },
+			},
+			expectedStatus: health.StatusUnavailable,
+			expectedChecks: map[string]string{"db": health.StatusOK, "files": health.StatusError},
+		},
+		{
+			name: "slow check",
+			checks: map[string]health.CheckFunc{
+				"smtp": func(ctx context.Context) error {
+					<-ctx.Done()
+
+					return ctx.Err()
+				},
+			},
+			expectedStatus: health.StatusUnavailable,
+			expectedChecks: map[string]string{"smtp": health.StatusError},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			checker := health.NewChecker(health.Config{Timeout: 50 * time.Millisecond, CacheTTL: 0})
+			for name, fn := range tt.checks {
+				checker.AddReadinessCheck(name, fn)
+			}
+
+			report := checker.Ready(context.Background())
+			if report.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, report.Status)
+			}
+
+			if len(report.Checks) != len(tt.expectedChecks) {
+				t.Fatalf("expected %d checks, got %v", len(tt.expectedChecks), report.Checks)
+			}
+
+			for name, status := range tt.expectedChecks {
+				if report.Checks[name].Status != status {
+					t.Errorf("expected %s to be %s, got %+v", name, status, report.Checks[name])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	t.Parallel()
+
+	checker := health.NewChecker(health.Config{Timeout: 20 * time.Millisecond, CacheTTL: 0})
+	checker.AddReadinessCheck("stuck", func(context.Context) error {
+		// ignores ctx
+		time.Sleep(time.Second)
+
+		return nil
+	})
+
+	start := time.Now()
+	report := checker.Ready(context.Background())
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the check to time out, took %s", elapsed)
+	}
+
+	if report.OK() || report.Checks["stuck"].Error == "" {
+		t.Errorf("expected a timeout error, got %+v", report)
+	}
+}
+
+func TestCheckCaching(t *testing.T) {
+	t.Parallel()
+
+	var runs atomic.Int32
+
+	checker := health.NewChecker(health.Config{Timeout: time.Second, CacheTTL: time.Hour})
+	checker.AddReadinessCheck("db", func(context.Context) error {
+		runs.Add(1)
+
+		return nil
+	})
+
+	checker.Ready(context.Background())
+	checker.Ready(context.Background())
+
+	if runs.Load() != 1 {
+		t.Errorf("expected the cached result to be reused, ran %d times", runs.Load())
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	checker := health.NewChecker(health.Config{})
+	checker.AddReadinessCheck("db", func(context.Context) error { return nil })
+
+	if !checker.Ready(context.Background()).OK() {
+		t.Fatal("expected the checker to be ready")
+	}
+
+	checker.Shutdown()
+
+	if checker.Ready(context.Background()).OK() {
+		t.Error("expected readiness to fail once shutdown starts")
+	}
+
+	if !checker.Live(context.Background()).OK() {
+		t.Error("expected liveness to pass during shutdown")
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	t.Parallel()
+
+	checker := health.NewChecker(health.Config{})
+	checker.AddReadinessCheck("db", func(context.Context) error { return errDown })
+
+	tests := []struct {
+		name        string
+		showDetails func(r *http.Request) bool
+		details     bool
+	}{
+		{name: "no details", showDetails: nil, details: false},
+		{name: "hidden details", showDetails: func(*http.Request) bool { return false }, details: false},
+		{name: "details", showDetails: func(*http.Request) bool { return true }, details: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			checker.ReadinessHandler(tt.showDetails)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("expected 503, got %d", w.Code)
+			}
+
+			if w.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("expected the response not to be cached")
+			}
+
+			var report health.Report
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Status != health.StatusUnavailable {
+				t.Errorf("expected status unavailable, got %s", report.Status)
+			}
+
+			if (report.Checks["db"].Error == errDown.Error()) != tt.details {
+				t.Errorf("expected details %v, got %+v", tt.details, report.Checks)
+			}
+		})
+	}
+}
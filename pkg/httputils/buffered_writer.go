@@ -0,0 +1,72 @@
+package httputils
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// BufferedResponseWriter captures a response so that it can be inspected before it is sent to the client.
+// It doesn't implement http.Flusher, so handlers can't stream responses through it.
+type BufferedResponseWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// NewBufferedResponseWriter creates a BufferedResponseWriter that sends the response to w.
+func NewBufferedResponseWriter(w http.ResponseWriter) *BufferedResponseWriter {
+	return &BufferedResponseWriter{w: w, header: make(http.Header)}
+}
+
+// Header returns the buffered response headers.
+func (b *BufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader records the response status. Only the first call has an effect.
+func (b *BufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// Write appends to the buffered response body.
+func (b *BufferedResponseWriter) Write(data []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+
+	//nolint: wrapcheck
+	return b.body.Write(data)
+}
+
+// Status returns the buffered response status, defaulting to 200 if none was written.
+func (b *BufferedResponseWriter) Status() int {
+	if b.status == 0 {
+		return http.StatusOK
+	}
+
+	return b.status
+}
+
+// Body returns the buffered response body.
+func (b *BufferedResponseWriter) Body() []byte {
+	return b.body.Bytes()
+}
+
+// Send writes the buffered headers, status and body to the underlying response writer.
+func (b *BufferedResponseWriter) Send() error {
+	for key, values := range b.header {
+		b.w.Header()[key] = values
+	}
+
+	b.w.WriteHeader(b.Status())
+
+	if _, err := b.w.Write(b.body.Bytes()); err != nil {
+		return fmt.Errorf("failed to send response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package httputils
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+// ErrCORSWildcardWithCredentials is raised when any origin is allowed to send credentials, which would let
+// every site make authenticated requests on behalf of your users.
+var ErrCORSWildcardWithCredentials = errors.New("cors: the * origin can't be used with AllowCredentials")
+
+const defaultCORSMaxAge = 10 * time.Minute
+
+// CORSConfig configures cross-origin requests.
+type CORSConfig struct {
+	// AllowedOrigins are exact origins such as https://app.example.com, patterns with a wildcard
+	// subdomain such as https://*.example.com, or * for any origin.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers the client may send. * allows any header.
+	// Defaults to Accept, Authorization, Content-Type, X-Requested-With, X-CSRF-Token, Idempotency-Key and If-Match.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers the client may read in addition to the safelisted ones.
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and authorization headers to be sent with requests.
+	AllowCredentials bool
+	// MaxAge is how long clients may cache the result of a preflight request. Not sent when zero.
+	MaxAge time.Duration
+}
+
+// CORSConfigFromEnv reads the CORS configuration from CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS,
+// CORS_ALLOWED_HEADERS, CORS_EXPOSED_HEADERS, CORS_ALLOW_CREDENTIALS and CORS_MAX_AGE (in seconds).
+// Returns false when CORS_ALLOWED_ORIGINS isn't set.
+func CORSConfigFromEnv() (CORSConfig, bool) {
+	allowedOrigins := parser.ParseEnvStringSlice("CORS_ALLOWED_ORIGINS", nil)
+	if len(allowedOrigins) == 0 {
+		return CORSConfig{}, false
+	}
+
+	maxAge, err := parser.ParseEnvInt("CORS_MAX_AGE", int(defaultCORSMaxAge.Seconds()))
+	if err != nil {
+		panic(err)
+	}
+
+	return CORSConfig{
+		AllowedOrigins:   allowedOrigins,
+		AllowedMethods:   parser.ParseEnvStringSlice("CORS_ALLOWED_METHODS", nil),
+		AllowedHeaders:   parser.ParseEnvStringSlice("CORS_ALLOWED_HEADERS", nil),
+		ExposedHeaders:   parser.ParseEnvStringSlice("CORS_EXPOSED_HEADERS", nil),
+		AllowCredentials: parser.ParseEnvBool("CORS_ALLOW_CREDENTIALS", false),
+		MaxAge:           time.Duration(maxAge) * time.Second,
+	}, true
+}
+
+type cors struct {
+	anyOrigin      bool
+	origins        []string
+	originPatterns [][2]string
+	methods        []string
+	anyHeader      bool
+	headers        []string
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+}
+
+// NewCORSMiddleware handles cross-origin requests. Preflight requests are answered with a 204 No Content
+// response and don't reach the handler. Responses to disallowed origins don't have CORS headers, so the
+// browser blocks them. Panics with ErrCORSWildcardWithCredentials if * is used with AllowCredentials.
+func NewCORSMiddleware(config CORSConfig) func(next http.Handler) http.Handler {
+	c := newCORS(config)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				c.handlePreflight(w, r)
+
+				return
+			}
+
+			c.handleRequest(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetCORSMiddleware allows requests from the given origins. See NewCORSMiddleware for more options.
+func GetCORSMiddleware(trustedOrigins []string) func(next http.Handler) http.Handler {
+	return NewCORSMiddleware(CORSConfig{AllowedOrigins: trustedOrigins})
+}
+
+func newCORS(config CORSConfig) *cors {
+	c := &cors{
+		methods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		headers: []string{
+			"accept", "authorization", "content-type", "x-requested-with", "x-csrf-token", "idempotency-key", "if-match",
+		},
+		exposedHeaders: strings.Join(config.ExposedHeaders, ", "),
+		credentials:    config.AllowCredentials,
+	}
+
+	for _, origin := range config.AllowedOrigins {
+		origin = strings.ToLower(origin)
+
+		switch {
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			c.originPatterns = append(c.originPatterns, [2]string{prefix, suffix})
+		default:
+			c.origins = append(c.origins, origin)
+		}
+	}
+
+	if c.anyOrigin && c.credentials {
+		panic(ErrCORSWildcardWithCredentials)
+	}
+
+	if len(config.AllowedMethods) > 0 {
+		c.methods = nil
+		for _, method := range config.AllowedMethods {
+			c.methods = append(c.methods, strings.ToUpper(method))
+		}
+	}
+
+	if len(config.AllowedHeaders) > 0 {
+		c.headers = nil
+		for _, header := range config.AllowedHeaders {
+			if header == "*" {
+				c.anyHeader = true
+			}
+
+			c.headers = append(c.headers, strings.ToLower(header))
+		}
+	}
+
+	if config.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+
+	return c
+}
+
+func (c *cors) isOriginAllowed(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if slices.Contains(c.origins, origin) {
+		return true
+	}
+
+	for _, pattern := range c.originPatterns {
+		prefix, suffix := pattern[0], pattern[1]
+		if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+
+		// the wildcard only matches subdomains, not paths, ports or credentials
+		if !strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:@") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *cors) isMethodAllowed(method string) bool {
+	method = strings.ToUpper(method)
+
+	// simple methods never require a preflight request
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodPost || slices.Contains(c.methods, method)
+}
+
+func (c *cors) areHeadersAllowed(requestedHeaders []string) bool {
+	if c.anyHeader {
+		return true
+	}
+
+	for _, header := range requestedHeaders {
+		if !slices.Contains(c.headers, header) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setOrigin sets the headers shared by preflight and actual requests.
+func (c *cors) setOrigin(w http.ResponseWriter, origin string) {
+	if c.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	requestedHeaders := parseHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+
+	if origin != "" &&
+		c.isOriginAllowed(origin) &&
+		c.isMethodAllowed(r.Header.Get("Access-Control-Request-Method")) &&
+		c.areHeadersAllowed(requestedHeaders) {
+		c.setOrigin(w, origin)
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+
+		if len(requestedHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+		}
+
+		if c.maxAge != "" {
+			w.Header().Set("Access-Control-Max-Age", c.maxAge)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *cors) handleRequest(w http.ResponseWriter, r *http.Request) {
+	if !c.anyOrigin {
+		w.Header().Add("Vary", "Origin")
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !c.isOriginAllowed(origin) {
+		return
+	}
+
+	c.setOrigin(w, origin)
+
+	if c.exposedHeaders != "" {
+		w.Header().Set("Access-Control-Expose-Headers", c.exposedHeaders)
+	}
+}
+
+// parseHeaderList parses a comma-separated list of header names into lower case.
+func parseHeaderList(headers string) []string {
+	var parsed []string
+
+	for _, header := range strings.Split(headers, ",") {
+		if header = strings.ToLower(strings.TrimSpace(header)); header != "" {
+			parsed = append(parsed, header)
+		}
+	}
+
+	return parsed
+}
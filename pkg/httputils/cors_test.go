@@ -0,0 +1,222 @@
+package httputils_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	t.Parallel()
+
+	config := httputils.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		ExposedHeaders:   []string{"RateLimit-Remaining"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		origin          string
+		requestMethod   string
+		requestHeaders  string
+		status          int
+		allowOrigin     string
+		allowHeaders    string
+		exposeHeaders   string
+		maxAge          string
+		reachesHandler  bool
+		allowCredential string
+	}{
+		{
+			name:            "preflight",
+			method:          http.MethodOptions,
+			origin:          "https://app.example.com",
+			requestMethod:   http.MethodPatch,
+			requestHeaders:  "Content-Type, Authorization",
+			status:          http.StatusNoContent,
+			allowOrigin:     "https://app.example.com",
+			allowHeaders:    "content-type, authorization",
+			maxAge:          "3600",
+			allowCredential: "true",
+		},
+		{
+			name:            "preflight with csrf, idempotency and precondition headers",
+			method:          http.MethodOptions,
+			origin:          "https://app.example.com",
+			requestMethod:   http.MethodPatch,
+			requestHeaders:  "X-CSRF-Token, Idempotency-Key, If-Match",
+			status:          http.StatusNoContent,
+			allowOrigin:     "https://app.example.com",
+			allowHeaders:    "x-csrf-token, idempotency-key, if-match",
+			maxAge:          "3600",
+			allowCredential: "true",
+		},
+		{
+			name:           "preflight with a disallowed header",
+			method:         http.MethodOptions,
+			origin:         "https://app.example.com",
+			requestMethod:  http.MethodPatch,
+			requestHeaders: "X-Secret",
+			status:         http.StatusNoContent,
+		},
+		{
+			name:          "preflight with a disallowed method",
+			method:        http.MethodOptions,
+			origin:        "https://app.example.com",
+			requestMethod: "PROPFIND",
+			status:        http.StatusNoContent,
+		},
+		{
+			name:            "subdomain pattern",
+			method:          http.MethodGet,
+			origin:          "https://eu.app.example.org",
+			status:          http.StatusOK,
+			allowOrigin:     "https://eu.app.example.org",
+			exposeHeaders:   "RateLimit-Remaining",
+			reachesHandler:  true,
+			allowCredential: "true",
+		},
+		{
+			name:           "subdomain pattern doesn't match the apex domain",
+			method:         http.MethodGet,
+			origin:         "https://example.org",
+			status:         http.StatusOK,
+			reachesHandler: true,
+		},
+		{
+			name:           "subdomain pattern doesn't match other domains",
+			method:         http.MethodGet,
+			origin:         "https://evil.com/.example.org",
+			status:         http.StatusOK,
+			reachesHandler: true,
+		},
+		{
+			name:           "disallowed origin",
+			method:         http.MethodPost,
+			origin:         "https://evil.com",
+			status:         http.StatusOK,
+			reachesHandler: true,
+		},
+		{
+			name:           "same origin request",
+			method:         http.MethodGet,
+			status:         http.StatusOK,
+			reachesHandler: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reachedHandler := false
+			handler := httputils.NewCORSMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				reachedHandler = true
+
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r := httptest.NewRequest(tt.method, "/api/users/1", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+
+			if tt.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+
+			if tt.requestHeaders != "" {
+				r.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			if reachedHandler != tt.reachesHandler {
+				t.Errorf("expected handler to be reached: %v", tt.reachesHandler)
+			}
+
+			expectedHeaders := map[string]string{
+				"Access-Control-Allow-Origin":      tt.allowOrigin,
+				"Access-Control-Allow-Headers":     tt.allowHeaders,
+				"Access-Control-Expose-Headers":    tt.exposeHeaders,
+				"Access-Control-Max-Age":           tt.maxAge,
+				"Access-Control-Allow-Credentials": tt.allowCredential,
+			}
+
+			for header, expected := range expectedHeaders {
+				if got := rr.Header().Get(header); got != expected {
+					t.Errorf("expected %s %q, got %q", header, expected, got)
+				}
+			}
+
+			if rr.Header().Get("Vary") != "Origin" {
+				t.Errorf("expected to vary by origin, got %q", rr.Header().Values("Vary"))
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware_AnyOrigin(t *testing.T) {
+	t.Parallel()
+
+	handler := httputils.NewCORSMiddleware(httputils.CORSConfig{AllowedOrigins: []string{"*"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	r.Header.Set("Origin", "https://anywhere.com")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("expected *, got %q", got)
+	}
+}
+
+func TestCORSMiddleware_AnyOriginWithCredentials(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, httputils.ErrCORSWildcardWithCredentials) {
+			t.Errorf("expected ErrCORSWildcardWithCredentials, got %v", err)
+		}
+	}()
+
+	httputils.NewCORSMiddleware(httputils.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
+
+func TestCORSConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://*.example.com")
+	t.Setenv("CORS_ALLOWED_METHODS", "GET,PATCH")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("CORS_MAX_AGE", "60")
+
+	config, ok := httputils.CORSConfigFromEnv()
+	if !ok {
+		t.Fatal("expected CORS to be configured")
+	}
+
+	if len(config.AllowedOrigins) != 2 || config.AllowedOrigins[1] != "https://*.example.com" {
+		t.Errorf("unexpected origins %v", config.AllowedOrigins)
+	}
+
+	if len(config.AllowedMethods) != 2 || !config.AllowCredentials || config.MaxAge != time.Minute {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
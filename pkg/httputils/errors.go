@@ -3,14 +3,120 @@ package httputils
 import (
 	"errors"
 	"log/slog"
+	"mime"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fsutils"
 	"github.com/gurch101/gowebutils/pkg/validation"
 )
 
+// Machine-readable codes of the errors sent by the response helpers.
+const (
+	CodeInternalError       = "internal_error"
+	CodeBadRequest          = "bad_request"
+	CodeInvalidJSON         = "invalid_json"
+	CodeInvalidCSV          = "invalid_csv"
+	CodeInvalidCSVRows      = "invalid_csv_rows"
+	CodeUnprocessableEntity = "unprocessable_entity"
+	CodeValidationFailed    = "validation_failed"
+	CodeNotFound            = "not_found"
+	CodeEditConflict        = "edit_conflict"
+	CodeRateLimitExceeded   = "rate_limit_exceeded"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeInvalidCSRFToken    = "invalid_csrf_token"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ErrorFormat is the format of the body of error responses.
+type ErrorFormat int
+
+const (
+	// ErrorFormatEnvelope renders errors as {"errors": [{"field", "code", "message"}]}.
+	ErrorFormatEnvelope ErrorFormat = iota
+	// ErrorFormatProblem renders errors as RFC 7807 application/problem+json.
+	ErrorFormatProblem
+)
+
+// ErrorResponse is the body of an error response in the envelope format.
+type ErrorResponse struct {
+	Errors []validation.Error `json:"errors"`
+	// Rows lists the invalid rows of a rejected CSV import.
+	Rows []dbutils.CSVRowError `json:"rows,omitempty"`
+}
+
+// ProblemDetails is the body of an error response in the RFC 7807 format.
+type ProblemDetails struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      string                `json:"code"`
+	RequestID string                `json:"requestId,omitempty"`
+	Errors    []validation.Error    `json:"errors,omitempty"`
+	Rows      []dbutils.CSVRowError `json:"rows,omitempty"`
+}
+
+type ErrorResponseOption func(*errorResponseOptions)
+
+type errorResponseOptions struct {
+	format         ErrorFormat
+	negotiate      bool
+	problemTypeURL string
+}
+
+// WithErrorFormat sets the format used when the client doesn't ask for one. Defaults to ErrorFormatEnvelope.
+func WithErrorFormat(format ErrorFormat) ErrorResponseOption {
+	return func(options *errorResponseOptions) {
+		options.format = format
+	}
+}
+
+// WithProblemTypeURL sets the base URL of the problem type. The type of a problem is the base URL
+// followed by its error code, e.g. https://example.com/errors/not_found. Defaults to about:blank.
+func WithProblemTypeURL(baseURL string) ErrorResponseOption {
+	return func(options *errorResponseOptions) {
+		options.problemTypeURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithoutErrorContentNegotiation always uses the configured format, regardless of the Accept header.
+func WithoutErrorContentNegotiation() ErrorResponseOption {
+	return func(options *errorResponseOptions) {
+		options.negotiate = false
+	}
+}
+
+var errorResponseConfig atomic.Pointer[errorResponseOptions]
+
+// ConfigureErrorResponses sets how the response helpers render errors. It is safe to call concurrently
+// with requests, but is intended to be called once at startup.
+func ConfigureErrorResponses(opts ...ErrorResponseOption) {
+	options := errorResponseOptions{format: ErrorFormatEnvelope, negotiate: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	errorResponseConfig.Store(&options)
+}
+
+func getErrorResponseOptions() *errorResponseOptions {
+	if options := errorResponseConfig.Load(); options != nil {
+		return options
+	}
+
+	return &errorResponseOptions{format: ErrorFormatEnvelope, negotiate: true}
+}
+
 func logError(r *http.Request, err error) {
 	slog.ErrorContext(
 		r.Context(),
@@ -21,17 +127,35 @@ func logError(r *http.Request, err error) {
 	)
 }
 
-type ErrorResponse struct {
-	Status  int                `json:"status"`
-	Message string             `json:"message"`
-	Errors  []validation.Error `json:"errors,omitempty"`
+// apiError is an error response before it is rendered in the negotiated format.
+type apiError struct {
+	status int
+	code   string
+	detail string
+	errors []validation.Error
+	rows   []dbutils.CSVRowError
 }
 
-func errorResponse(w http.ResponseWriter, r *http.Request, status int, message string) {
-	// Write the response using the writeJSON() helper. If this happens to return an error
-	// then log it, and fall back to sending the client an empty response with a 500 Internal
-	// Server Error status code
-	err := WriteJSON(w, status, ErrorResponse{Status: status, Message: message}, nil)
+func writeError(w http.ResponseWriter, r *http.Request, apiErr apiError) {
+	options := getErrorResponseOptions()
+
+	var err error
+
+	if negotiateErrorFormat(r, options) == ErrorFormatProblem {
+		err = WriteJSON(w, apiErr.status, newProblemDetails(r, options, apiErr), http.Header{
+			ContentTypeHeader: []string{ProblemContentType},
+		})
+	} else {
+		errs := apiErr.errors
+		if len(errs) == 0 {
+			errs = []validation.Error{{Code: apiErr.code, Message: apiErr.detail}}
+		}
+
+		err = WriteJSON(w, apiErr.status, ErrorResponse{Errors: errs, Rows: apiErr.rows}, nil)
+	}
+
+	// If writing the response fails, log it and fall back to sending the client an empty
+	// response with a 500 Internal Server Error status code
 	if err != nil {
 		logError(r, err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -43,83 +167,230 @@ func errorResponse(w http.ResponseWriter, r *http.Request, status int, message s
 	}
 }
 
+func newProblemDetails(r *http.Request, options *errorResponseOptions, apiErr apiError) ProblemDetails {
+	problemType := "about:blank"
+	if options.problemTypeURL != "" {
+		problemType = options.problemTypeURL + "/" + apiErr.code
+	}
+
+	return ProblemDetails{
+		Type:      problemType,
+		Title:     http.StatusText(apiErr.status),
+		Status:    apiErr.status,
+		Detail:    apiErr.detail,
+		Instance:  r.URL.Path,
+		Code:      apiErr.code,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    apiErr.errors,
+		Rows:      apiErr.rows,
+	}
+}
+
+// negotiateErrorFormat picks the problem format when the client prefers application/problem+json over
+// application/json, the envelope format when it prefers application/json, and the configured format otherwise.
+func negotiateErrorFormat(r *http.Request, options *errorResponseOptions) ErrorFormat {
+	if !options.negotiate {
+		return options.format
+	}
+
+	problemQuality := acceptQuality(r, ProblemContentType)
+	jsonQuality := acceptQuality(r, "application/json")
+
+	switch {
+	case problemQuality > jsonQuality:
+		return ErrorFormatProblem
+	case jsonQuality > problemQuality:
+		return ErrorFormatEnvelope
+	default:
+		return options.format
+	}
+}
+
+// acceptQuality returns the quality the Accept header gives to mediaType, ignoring wildcards.
+// Returns 0 if the media type isn't listed.
+func acceptQuality(r *http.Request, mediaType string) float64 {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		acceptedType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || acceptedType != mediaType {
+			continue
+		}
+
+		quality, err := strconv.ParseFloat(params["q"], 64)
+		if err != nil {
+			return 1
+		}
+
+		return quality
+	}
+
+	return 0
+}
+
+// wantsRedirect returns true for page requests made by a browser, which are redirected to the
+// login page instead of receiving an error body.
+func wantsRedirect(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api") {
+		return false
+	}
+
+	return acceptQuality(r, "text/html") > 0
+}
+
+// requestErrors returns the field errors of err, if it has any.
+func requestErrors(err error) []validation.Error {
+	var jsonErr *JSONError
+
+	var formErr *FormError
+
+	var singleValidationErr validation.Error
+
+	switch {
+	case errors.As(err, &jsonErr):
+		return []validation.Error{jsonErr.ValidationError()}
+	case errors.As(err, &formErr):
+		return []validation.Error{formErr.ValidationError()}
+	case errors.As(err, &singleValidationErr):
+		return []validation.Error{singleValidationErr}
+	default:
+		return nil
+	}
+}
+
+func requestErrorCode(err error, code string) string {
+	if errors.Is(err, ErrInvalidJSON) {
+		return CodeInvalidJSON
+	}
+
+	if errors.Is(err, ErrInvalidForm) {
+		return CodeInvalidForm
+	}
+
+	return code
+}
+
 // serverErrorResponse method is used when our application encounters an unexpected problem
 // at runtime. it logs the detailed error message and returns a 500 Internal Server Error.
 func ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 	logError(r, err)
 
-	message := "the server encountered a problem and could not process your request"
-	errorResponse(w, r, http.StatusInternalServerError, message)
+	writeError(w, r, apiError{
+		status: http.StatusInternalServerError,
+		code:   CodeInternalError,
+		detail: "the server encountered a problem and could not process your request",
+	})
 }
 
 // UnprocessableEntityResponse method is used to send a 422 Unprocessable Entity status code.
 func UnprocessableEntityResponse(w http.ResponseWriter, r *http.Request, err error) {
-	errorResponse(w, r, http.StatusUnprocessableEntity, err.Error())
+	writeError(w, r, apiError{
+		status: http.StatusUnprocessableEntity,
+		code:   requestErrorCode(err, CodeUnprocessableEntity),
+		detail: err.Error(),
+		errors: requestErrors(err),
+	})
 }
 
 // BadRequestResponse sends a JSON-formatted error message with 400 Bad Request status code.
 func BadRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	errorResponse(w, r, http.StatusBadRequest, err.Error())
+	writeError(w, r, apiError{
+		status: http.StatusBadRequest,
+		code:   requestErrorCode(err, CodeBadRequest),
+		detail: err.Error(),
+		errors: requestErrors(err),
+	})
 }
 
 // FailedValidationResponse sends JSON-formatted error message to client with 400 Bad Request status code.
 func FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors []validation.Error) {
-	err := WriteJSON(w, http.StatusBadRequest, ErrorResponse{Status: http.StatusBadRequest, Message: "validation errors", Errors: errors}, nil)
-	if err != nil {
-		logError(r, err)
-		w.WriteHeader(http.StatusInternalServerError)
+	writeError(w, r, apiError{
+		status: http.StatusBadRequest,
+		code:   CodeValidationFailed,
+		detail: "validation errors",
+		errors: errors,
+	})
+}
 
-		_, err = w.Write([]byte("internal server error"))
-		if err != nil {
-			logError(r, err)
-		}
-	}
+// FailedCSVImportResponse sends the invalid rows of a CSV import with a 400 Bad Request status code.
+func FailedCSVImportResponse(w http.ResponseWriter, r *http.Request, rows []dbutils.CSVRowError) {
+	writeError(w, r, apiError{
+		status: http.StatusBadRequest,
+		code:   CodeInvalidCSVRows,
+		detail: "invalid csv rows",
+		rows:   rows,
+	})
 }
 
 // NotFoundResponse method is used to send a 404 Not Found status code.
 func NotFoundResponse(w http.ResponseWriter, r *http.Request) {
-	message := "the requested resource could not be found"
-	errorResponse(w, r, http.StatusNotFound, message)
+	writeError(w, r, apiError{
+		status: http.StatusNotFound,
+		code:   CodeNotFound,
+		detail: "the requested resource could not be found",
+	})
 }
 
 // EditConflictResponse method is used to send a 409 Conflict status code. This can occur
 // when we try to create a new record in the database and another user has updated the same record concurrently.
 func EditConflictResponse(w http.ResponseWriter, r *http.Request) {
-	message := "unable to update the record due to an edit conflict, please try again"
-	errorResponse(w, r, http.StatusConflict, message)
+	writeError(w, r, apiError{
+		status: http.StatusConflict,
+		code:   CodeEditConflict,
+		detail: "unable to update the record due to an edit conflict, please try again",
+	})
 }
 
 // RateLimitExceededResponse method is used to send a 429 Too Many Requests status code.
 // The rate limit middleware will return this status code if a request exceeds the rate limit.
 func RateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
-	message := "rate limit exceeded"
-	errorResponse(w, r, http.StatusTooManyRequests, message)
+	writeError(w, r, apiError{
+		status: http.StatusTooManyRequests,
+		code:   CodeRateLimitExceeded,
+		detail: "rate limit exceeded",
+	})
 }
 
 // UnauthorizedResponse method is used to send a 401 Unauthorized status code.
 // This can occur if a user tries to access a protected resource without supplying valid credentials.
-// If the request is made to an api endpoint, we will return a JSON response. Otherwise, we will redirect
-// the user to the login page.
+// Browsers requesting a page outside of /api are redirected to the login page instead.
 func UnauthorizedResponse(w http.ResponseWriter, r *http.Request) {
-	if strings.HasPrefix(r.URL.Path, "/api") {
-		message := "You must be authenticated to access this resource"
-		errorResponse(w, r, http.StatusUnauthorized, message)
-	} else {
+	if wantsRedirect(r) {
 		http.Redirect(w, r, "/login", http.StatusTemporaryRedirect)
+
+		return
 	}
+
+	writeError(w, r, apiError{
+		status: http.StatusUnauthorized,
+		code:   CodeUnauthorized,
+		detail: "You must be authenticated to access this resource",
+	})
 }
 
 // ForbiddenResponse method is used to send a 403 Forbidden status code.
 // This can occur if a user tries to access a resource that they don't have permission to access.
-// If the request is made to an api endpoint, we will return a JSON response. Otherwise, we will redirect
-// the user to the login page.
+// Browsers requesting a page outside of /api are redirected to the login page instead.
 func ForbiddenResponse(w http.ResponseWriter, r *http.Request) {
-	if strings.HasPrefix(r.URL.Path, "/api") {
-		message := "You do not have permission to access this resource"
-		errorResponse(w, r, http.StatusForbidden, message)
-	} else {
+	if wantsRedirect(r) {
 		http.Redirect(w, r, "/login", http.StatusTemporaryRedirect)
+
+		return
 	}
+
+	writeError(w, r, apiError{
+		status: http.StatusForbidden,
+		code:   CodeForbidden,
+		detail: "You do not have permission to access this resource",
+	})
+}
+
+// InvalidCSRFTokenResponse method is used to send a 403 Forbidden status code when a request's CSRF
+// token is missing or doesn't match the session's token.
+func InvalidCSRFTokenResponse(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, apiError{
+		status: http.StatusForbidden,
+		code:   CodeInvalidCSRFToken,
+		detail: err.Error(),
+	})
 }
 
 // HandleErrorResponse method is a utility function that will return the appropriate
@@ -129,15 +400,27 @@ func HandleErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 
 	var singleValidationErr validation.Error
 
+	var csvImportErr dbutils.CSVImportError
+
 	switch {
 	case errors.As(err, &singleValidationErr):
 		FailedValidationResponse(w, r, []validation.Error{singleValidationErr})
 	case errors.As(err, &validationErr):
 		FailedValidationResponse(w, r, validationErr.Errors)
-	case errors.Is(err, dbutils.ErrRecordNotFound):
+	case errors.Is(err, ErrInvalidJSON), errors.Is(err, ErrInvalidForm):
+		UnprocessableEntityResponse(w, r, err)
+	case errors.Is(err, ErrUnsupportedMediaType):
+		UnsupportedMediaTypeResponse(w, r, err)
+	case errors.As(err, &csvImportErr):
+		FailedCSVImportResponse(w, r, csvImportErr.Rows)
+	case errors.Is(err, dbutils.ErrCSVMalformed), errors.Is(err, dbutils.ErrCSVInvalidHeader), errors.Is(err, dbutils.ErrCSVTooManyRows):
+		writeError(w, r, apiError{status: http.StatusBadRequest, code: CodeInvalidCSV, detail: err.Error()})
+	case errors.Is(err, dbutils.ErrRecordNotFound), errors.Is(err, fsutils.ErrFileNotFound):
 		NotFoundResponse(w, r)
 	case errors.Is(err, dbutils.ErrEditConflict):
 		EditConflictResponse(w, r)
+	case errors.Is(err, ErrPreconditionFailed):
+		PreconditionFailedResponse(w, r)
 	default:
 		ServerErrorResponse(w, r, err)
 	}
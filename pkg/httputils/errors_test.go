@@ -0,0 +1,196 @@
+package httputils_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/validation"
+)
+
+func TestHandleErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		errors []validation.Error
+	}{
+		{
+			name:   "validation errors",
+			err:    validation.ValidationError{Errors: []validation.Error{{Field: "name", Code: validation.CodeRequired, Message: "Name is required"}}},
+			status: http.StatusBadRequest,
+			errors: []validation.Error{{Field: "name", Code: validation.CodeRequired, Message: "Name is required"}},
+		},
+		{
+			name:   "not found",
+			err:    dbutils.ErrRecordNotFound,
+			status: http.StatusNotFound,
+			errors: []validation.Error{{Code: httputils.CodeNotFound, Message: "the requested resource could not be found"}},
+		},
+		{
+			name:   "internal errors are not leaked",
+			err:    errors.New("disk full"),
+			status: http.StatusInternalServerError,
+			errors: []validation.Error{{
+				Code:    httputils.CodeInternalError,
+				Message: "the server encountered a problem and could not process your request",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			httputils.HandleErrorResponse(rr, httptest.NewRequest(http.MethodGet, "/api/users", nil), tt.err)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			var response httputils.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(response.Errors, tt.errors) {
+				t.Errorf("expected errors %+v, got %+v", tt.errors, response.Errors)
+			}
+		})
+	}
+}
+
+func TestReadJSONErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/users", bytes.NewBufferString(`{"name":1}`))
+	rr := httptest.NewRecorder()
+
+	_, err := httputils.ReadJSON[request](rr, r)
+	if !errors.Is(err, httputils.ErrInvalidJSON) {
+		t.Fatalf("expected ErrInvalidJSON, got %v", err)
+	}
+
+	httputils.UnprocessableEntityResponse(rr, r, err)
+
+	expected := `{"errors":[{"field":"name","code":"invalid_type","message":"body contains incorrect JSON type for field \"name\""}]}` + "\n"
+	if rr.Body.String() != expected {
+		t.Errorf("expected %s, got %s", expected, rr.Body.String())
+	}
+}
+
+func TestProblemDetailsContentNegotiation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{name: "no accept header", accept: "", contentType: "application/json"},
+		{name: "problem json", accept: "application/problem+json", contentType: httputils.ProblemContentType},
+		{name: "prefers json", accept: "application/json, application/problem+json;q=0.5", contentType: "application/json"},
+		{name: "prefers problem json", accept: "application/json;q=0.5, application/problem+json", contentType: httputils.ProblemContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+			r.Header.Set("Accept", tt.accept)
+
+			rr := httptest.NewRecorder()
+			httputils.NotFoundResponse(rr, r)
+
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected content type %q, got %q", tt.contentType, got)
+			}
+		})
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	httputils.ConfigureErrorResponses(
+		httputils.WithErrorFormat(httputils.ErrorFormatProblem),
+		httputils.WithProblemTypeURL("https://example.com/errors/"),
+	)
+	defer httputils.ConfigureErrorResponses()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/users", nil)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "req-1"))
+	r.Header.Set("Accept", "*/*")
+
+	rr := httptest.NewRecorder()
+	httputils.FailedValidationResponse(rr, r, []validation.Error{{Field: "email", Code: validation.CodeInvalidEmail, Message: "Email is invalid"}})
+
+	var problem httputils.ProblemDetails
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := httputils.ProblemDetails{
+		Type:      "https://example.com/errors/validation_failed",
+		Title:     "Bad Request",
+		Status:    http.StatusBadRequest,
+		Detail:    "validation errors",
+		Instance:  "/api/users",
+		Code:      httputils.CodeValidationFailed,
+		RequestID: "req-1",
+		Errors:    []validation.Error{{Field: "email", Code: validation.CodeInvalidEmail, Message: "Email is invalid"}},
+	}
+
+	if !reflect.DeepEqual(problem, expected) {
+		t.Errorf("expected %+v, got %+v", expected, problem)
+	}
+
+	if rr.Header().Get("Content-Type") != httputils.ProblemContentType {
+		t.Errorf("expected problem content type, got %q", rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestUnauthorizedResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		status int
+	}{
+		{name: "browser page request", path: "/dashboard", accept: "text/html,application/xhtml+xml", status: http.StatusTemporaryRedirect},
+		{name: "fetch page request", path: "/dashboard", accept: "application/json", status: http.StatusUnauthorized},
+		{name: "api request", path: "/api/users", accept: "text/html", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("Accept", tt.accept)
+
+			rr := httptest.NewRecorder()
+			httputils.UnauthorizedResponse(rr, r)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package httputils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CodePreconditionFailed is the code of the error sent when an If-Match header doesn't match.
+const CodePreconditionFailed = "precondition_failed"
+
+// ErrPreconditionFailed is returned when the If-Match header of a request doesn't match the current version
+// of the resource.
+var ErrPreconditionFailed = errors.New("the resource has been modified since it was fetched")
+
+const etagHashLength = 16
+
+// VersionETag returns a weak ETag for the version of a row, e.g. W/"3".
+func VersionETag(version int64) string {
+	return `W/"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// BodyETag returns a weak ETag from a hash of a response body.
+func BodyETag(body []byte) string {
+	hash := sha256.Sum256(body)
+
+	return `W/"` + hex.EncodeToString(hash[:])[:etagHashLength] + `"`
+}
+
+// NotModified sets the ETag header of the response and reports whether the If-None-Match header of a GET or HEAD
+// request matches it. If it does, a 304 Not Modified response is sent and the handler should return.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if !etagListContains(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// WriteJSONWithETag writes data as JSON with an ETag computed from the body, or sends a 304 Not Modified response
+// if the If-None-Match header matches it. Use VersionETag with NotModified instead when the response is a single
+// row, which avoids building the response for unmodified rows.
+func WriteJSONWithETag(w http.ResponseWriter, r *http.Request, status int, data any, headers http.Header) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %w", err)
+	}
+
+	for key, value := range headers {
+		w.Header()[key] = value
+	}
+
+	if NotModified(w, r, BodyETag(js)) {
+		return nil
+	}
+
+	SetJSONContentTypeResponseHeader(w)
+	w.WriteHeader(status)
+
+	if _, err := w.Write(js); err != nil {
+		return fmt.Errorf("failed to write json: %w", err)
+	}
+
+	return nil
+}
+
+// IfMatchVersion returns the row version in the If-Match header of the request, or nil if the header isn't set or
+// is *. Returns ErrPreconditionFailed if the header isn't an ETag created by VersionETag.
+func IfMatchVersion(r *http.Request) (*int64, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		//nolint: nilnil
+		return nil, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`), 10, 64)
+	if err != nil {
+		return nil, ErrPreconditionFailed
+	}
+
+	return &version, nil
+}
+
+// PreconditionFailedResponse method is used to send a 412 Precondition Failed status code when the resource was
+// modified since the client fetched it.
+func PreconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, apiError{
+		status: http.StatusPreconditionFailed,
+		code:   CodePreconditionFailed,
+		detail: ErrPreconditionFailed.Error(),
+	})
+}
+
+// etagListContains reports whether a comma-separated If-None-Match list contains etag using the weak comparison.
+func etagListContains(list, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,154 @@
+package httputils_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+func TestNotModified(t *testing.T) {
+	t.Parallel()
+
+	etag := httputils.VersionETag(3)
+
+	tests := []struct {
+		name           string
+		method         string
+		ifNoneMatch    string
+		expectedResult bool
+		expectedStatus int
+	}{
+		{"no header", http.MethodGet, "", false, http.StatusOK},
+		{"matching etag", http.MethodGet, `W/"3"`, true, http.StatusNotModified},
+		{"strong matching etag", http.MethodHead, `"3"`, true, http.StatusNotModified},
+		{"matching etag in list", http.MethodGet, `W/"1", W/"3"`, true, http.StatusNotModified},
+		{"wildcard", http.MethodGet, "*", true, http.StatusNotModified},
+		{"stale etag", http.MethodGet, `W/"2"`, false, http.StatusOK},
+		{"unsafe method", http.MethodPost, `W/"3"`, false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			w := httptest.NewRecorder()
+
+			if result := httputils.NotModified(w, r, etag); result != tt.expectedResult {
+				t.Errorf("expected %v, got %v", tt.expectedResult, result)
+			}
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("expected ETag %s, got %s", etag, got)
+			}
+		})
+	}
+}
+
+func TestWriteJSONWithETag(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]string{"name": "test"}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if err := httputils.WriteJSONWithETag(w, r, http.StatusOK, data, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.Len() == 0 {
+		t.Fatalf("expected 200 with an ETag and body, got %d %q %q", w.Code, etag, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", etag)
+
+	if err := httputils.WriteJSONWithETag(w, r, http.StatusOK, data, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected empty 304, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+
+	if err := httputils.WriteJSONWithETag(w, r, http.StatusOK, map[string]string{"name": "changed"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("expected 200 with a new ETag, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		ifMatch         string
+		expectedVersion *int64
+		expectedErr     error
+	}{
+		{"no header", "", nil, nil},
+		{"wildcard", "*", nil, nil},
+		{"weak etag", `W/"4"`, ptr(4), nil},
+		{"strong etag", `"4"`, ptr(4), nil},
+		{"invalid etag", `W/"abc"`, nil, httputils.ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			version, err := httputils.IfMatchVersion(r)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			switch {
+			case tt.expectedVersion == nil && version != nil:
+				t.Errorf("expected no version, got %d", *version)
+			case tt.expectedVersion != nil && (version == nil || *version != *tt.expectedVersion):
+				t.Errorf("expected version %d, got %v", *tt.expectedVersion, version)
+			}
+		})
+	}
+}
+
+func TestPreconditionFailedErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPatch, "/", nil)
+
+	httputils.HandleErrorResponse(w, r, httputils.ErrPreconditionFailed)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+}
+
+func ptr(v int64) *int64 {
+	return &v
+}
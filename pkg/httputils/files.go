@@ -0,0 +1,268 @@
+package httputils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+)
+
+// CodeRangeNotSatisfiable is the code of the error sent when the Range header of a request is outside of the file.
+const CodeRangeNotSatisfiable = "range_not_satisfiable"
+
+var errRangeNotSatisfiable = errors.New("the requested range is not satisfiable")
+
+type serveFileOptions struct {
+	attachment  bool
+	fileName    string
+	contentType string
+}
+
+// ServeFileOption configures ServeFile.
+type ServeFileOption func(*serveFileOptions)
+
+// WithAttachment makes browsers download the file as fileName instead of displaying it. The name of the stored
+// file is used if fileName is empty.
+func WithAttachment(fileName string) ServeFileOption {
+	return func(o *serveFileOptions) {
+		o.attachment = true
+		o.fileName = fileName
+	}
+}
+
+// WithFileContentType overrides the content type of the stored file.
+func WithFileContentType(contentType string) ServeFileOption {
+	return func(o *serveFileOptions) {
+		o.contentType = contentType
+	}
+}
+
+// ServeFile streams a file from files to the response. The Content-Type, Content-Length, Content-Disposition,
+// ETag and Last-Modified headers are set from the stored file. Conditional requests are answered with a 304 Not
+// Modified and a single byte range in the Range header is served with a 206 Partial Content. Requests for multiple
+// ranges get the whole file.
+func ServeFile(
+	w http.ResponseWriter,
+	r *http.Request,
+	files fsutils.FileService,
+	fileName string,
+	opts ...ServeFileOption,
+) {
+	//nolint: exhaustruct
+	options := &serveFileOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var (
+		body io.ReadCloser
+		info fsutils.ObjectInfo
+		err  error
+	)
+
+	rangeHeader := r.Header.Get("Range")
+
+	// only fetch the contents when they are needed as is
+	if r.Method == http.MethodHead || rangeHeader != "" {
+		info, err = files.Stat(r.Context(), fileName)
+	} else {
+		body, info, err = files.Open(r.Context(), fileName)
+	}
+
+	if err != nil {
+		HandleErrorResponse(w, r, err)
+
+		return
+	}
+
+	if body != nil {
+		defer body.Close()
+	}
+
+	if notModifiedFile(w, r, info) {
+		return
+	}
+
+	offset, length, status := int64(0), info.Size, http.StatusOK
+
+	if rangeHeader != "" && ifRangeMatches(r, info) {
+		start, end, ok, err := parseRange(rangeHeader, info.Size)
+		if err != nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+			writeError(w, r, apiError{
+				status: http.StatusRequestedRangeNotSatisfiable,
+				code:   CodeRangeNotSatisfiable,
+				detail: err.Error(),
+			})
+
+			return
+		}
+
+		if ok {
+			offset, length, status = start, end-start+1, http.StatusPartialContent
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, info.Size))
+		}
+	}
+
+	if r.Method != http.MethodHead && body == nil {
+		body, _, err = files.OpenRange(r.Context(), fileName, offset, length)
+		if err != nil {
+			w.Header().Del("Content-Range")
+			HandleErrorResponse(w, r, err)
+
+			return
+		}
+
+		defer body.Close()
+	}
+
+	setFileHeaders(w, info, options)
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err := io.CopyN(w, body, length); err != nil {
+		// the status has been sent so the client only sees a truncated body
+		logError(r, fmt.Errorf("failed to stream file %s: %w", fileName, err))
+	}
+}
+
+// notModifiedFile sets the ETag and Last-Modified headers of the response and sends a 304 Not Modified if the
+// client has the current version of the file.
+func notModifiedFile(w http.ResponseWriter, r *http.Request, info fsutils.ObjectInfo) bool {
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	if !info.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if info.ETag != "" && r.Header.Get("If-None-Match") != "" {
+		return NotModified(w, r, info.ETag)
+	}
+
+	if info.ETag != "" {
+		w.Header().Set("ETag", info.ETag)
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || info.LastModified.IsZero() || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+
+	if info.LastModified.Truncate(time.Second).After(ifModifiedSince) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// ifRangeMatches reports whether the Range header should be used. Clients send If-Range with the ETag or
+// modification date of a partially downloaded file so that a changed file is sent in full.
+func ifRangeMatches(r *http.Request, info fsutils.ObjectInfo) bool {
+	ifRange := r.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) {
+		return ifRange == info.ETag
+	}
+
+	modifiedAt, err := http.ParseTime(ifRange)
+
+	return err == nil && info.LastModified.Truncate(time.Second).Equal(modifiedAt)
+}
+
+// parseRange returns the first and last byte of a single range in a Range header. ok is false if the header should
+// be ignored because it has multiple ranges or isn't valid.
+func parseRange(header string, size int64) (start, end int64, ok bool, err error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+
+	startValue, endValue, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+
+	// suffix ranges such as bytes=-500 request the last 500 bytes
+	if startValue == "" {
+		suffix, err := strconv.ParseInt(endValue, 10, 64)
+		if err != nil || suffix < 0 {
+			return 0, 0, false, nil
+		}
+
+		if suffix == 0 || size == 0 {
+			return 0, 0, false, errRangeNotSatisfiable
+		}
+
+		return max(size-suffix, 0), size - 1, true, nil
+	}
+
+	start, err = strconv.ParseInt(startValue, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, nil
+	}
+
+	end = size - 1
+
+	if endValue != "" {
+		end, err = strconv.ParseInt(endValue, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false, nil
+		}
+	}
+
+	if start >= size {
+		return 0, 0, false, errRangeNotSatisfiable
+	}
+
+	return start, min(end, size-1), true, nil
+}
+
+func setFileHeaders(w http.ResponseWriter, info fsutils.ObjectInfo, options *serveFileOptions) {
+	w.Header().Set("Content-Type", fileContentType(info, options))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	disposition, fileName := "inline", path.Base(info.Name)
+	if options.attachment {
+		disposition = "attachment"
+
+		if options.fileName != "" {
+			fileName = options.fileName
+		}
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": fileName}))
+}
+
+// fileContentType returns the content type of a file, falling back to its extension when the store doesn't know it.
+func fileContentType(info fsutils.ObjectInfo, options *serveFileOptions) string {
+	if options.contentType != "" {
+		return options.contentType
+	}
+
+	switch info.ContentType {
+	case "", "binary/octet-stream", "application/octet-stream":
+		if contentType := mime.TypeByExtension(path.Ext(info.Name)); contentType != "" {
+			return contentType
+		}
+
+		return "application/octet-stream"
+	default:
+		return info.ContentType
+	}
+}
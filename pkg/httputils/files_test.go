@@ -0,0 +1,162 @@
+package httputils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestServeFile(t *testing.T) {
+	t.Parallel()
+
+	modifiedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		method          string
+		headers         map[string]string
+		options         []httputils.ServeFileOption
+		missing         bool
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "whole file",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "0123456789",
+			expectedHeaders: map[string]string{
+				"Content-Type":        "application/pdf",
+				"Content-Length":      "10",
+				"Content-Disposition": `inline; filename=report.pdf`,
+				"ETag":                `"abc"`,
+				"Last-Modified":       modifiedAt.Format(http.TimeFormat),
+				"Accept-Ranges":       "bytes",
+			},
+		},
+		{
+			name:           "attachment",
+			method:         http.MethodGet,
+			options:        []httputils.ServeFileOption{httputils.WithAttachment("Q1 report.pdf")},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "0123456789",
+			expectedHeaders: map[string]string{
+				"Content-Disposition": `attachment; filename="Q1 report.pdf"`,
+			},
+		},
+		{
+			name:            "head",
+			method:          http.MethodHead,
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: map[string]string{"Content-Length": "10"},
+		},
+		{
+			name:           "if-none-match",
+			method:         http.MethodGet,
+			headers:        map[string]string{"If-None-Match": `"abc"`},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "if-modified-since",
+			method:         http.MethodGet,
+			headers:        map[string]string{"If-Modified-Since": modifiedAt.Format(http.TimeFormat)},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "range",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Range": "bytes=2-4"},
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "234",
+			expectedHeaders: map[string]string{
+				"Content-Range":  "bytes 2-4/10",
+				"Content-Length": "3",
+			},
+		},
+		{
+			name:            "open-ended range",
+			method:          http.MethodGet,
+			headers:         map[string]string{"Range": "bytes=7-"},
+			expectedStatus:  http.StatusPartialContent,
+			expectedBody:    "789",
+			expectedHeaders: map[string]string{"Content-Range": "bytes 7-9/10"},
+		},
+		{
+			name:            "suffix range",
+			method:          http.MethodGet,
+			headers:         map[string]string{"Range": "bytes=-2"},
+			expectedStatus:  http.StatusPartialContent,
+			expectedBody:    "89",
+			expectedHeaders: map[string]string{"Content-Range": "bytes 8-9/10"},
+		},
+		{
+			name:            "unsatisfiable range",
+			method:          http.MethodGet,
+			headers:         map[string]string{"Range": "bytes=20-"},
+			expectedStatus:  http.StatusRequestedRangeNotSatisfiable,
+			expectedHeaders: map[string]string{"Content-Range": "bytes */10"},
+		},
+		{
+			name:           "multiple ranges",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Range": "bytes=0-1,4-5"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "0123456789",
+		},
+		{
+			name:           "stale if-range",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Range": "bytes=2-4", "If-Range": `"old"`},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "0123456789",
+		},
+		{
+			name:           "missing file",
+			method:         http.MethodGet,
+			missing:        true,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := testutils.NewMockFileService()
+			if !tt.missing {
+				files.DownloadedFile = []byte("0123456789")
+				//nolint: exhaustruct
+				files.DownloadedFileInfo = fsutils.ObjectInfo{ETag: `"abc"`, LastModified: modifiedAt}
+			}
+
+			r := httptest.NewRequest(tt.method, "/files/report.pdf", nil)
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+
+			w := httptest.NewRecorder()
+
+			httputils.ServeFile(w, r, files, "reports/report.pdf", tt.options...)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+
+			for key, value := range tt.expectedHeaders {
+				if got := w.Header().Get(key); got != value {
+					t.Errorf("expected %s %q, got %q", key, value, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package httputils
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/validation"
+)
+
+var (
+	// ErrInvalidForm is returned when the body is not a valid form.
+	ErrInvalidForm = errors.New("invalid form")
+	// ErrUnsupportedMediaType is returned by ReadBody when the content type of the request can't be decoded.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrUnsupportedFormField is returned when a form value is sent for a field of a type that can't be decoded.
+	ErrUnsupportedFormField = errors.New("unsupported form field type")
+)
+
+// Codes of the errors returned by ReadForm and ReadMultipart.
+const (
+	CodeInvalidForm          = "invalid_form"
+	CodeMalformedForm        = "malformed_form"
+	CodeFileTooLarge         = "file_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+)
+
+const (
+	defaultMaxFormBytes      = 1_048_576
+	defaultMaxMultipartBytes = 32 << 20
+	defaultMaxFileBytes      = 10 << 20
+	// multipartMemoryBytes is the size of the files kept in memory before the rest are written to temporary files.
+	multipartMemoryBytes = 10 << 20
+)
+
+// formDateLayouts are the layouts used to parse time.Time fields, including the values of date and datetime-local
+// inputs.
+//
+//nolint:gochecknoglobals
+var formDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// FormError is returned by ReadForm and ReadMultipart when the request body can't be decoded. It wraps
+// ErrInvalidForm. Field is set when the error is caused by a single field of the form.
+type FormError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *FormError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidForm, e.Message)
+}
+
+func (e *FormError) Unwrap() error {
+	return ErrInvalidForm
+}
+
+// ValidationError returns the error as a validation error so it can be rendered with field errors.
+func (e *FormError) ValidationError() validation.Error {
+	return validation.Error{Field: e.Field, Code: e.Code, Message: e.Message}
+}
+
+type formOptions struct {
+	maxBytes     int64
+	maxFileBytes int64
+}
+
+// FormOption configures ReadForm, ReadMultipart and ReadBody.
+type FormOption func(*formOptions)
+
+// WithMaxBodyBytes sets the maximum size of the request body. Defaults to 1MB for forms and 32MB for multipart
+// forms.
+func WithMaxBodyBytes(maxBytes int64) FormOption {
+	return func(o *formOptions) {
+		o.maxBytes = maxBytes
+	}
+}
+
+// WithMaxFileBytes sets the maximum size of each file of a multipart form. Defaults to 10MB.
+func WithMaxFileBytes(maxBytes int64) FormOption {
+	return func(o *formOptions) {
+		o.maxFileBytes = maxBytes
+	}
+}
+
+func newFormOptions(maxBytes int64, opts []FormOption) *formOptions {
+	options := &formOptions{maxBytes: maxBytes, maxFileBytes: defaultMaxFileBytes}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+// ReadForm decodes an application/x-www-form-urlencoded request body into T. Fields are matched by their form tag,
+// or their json tag if they don't have one, so that request types can be shared with ReadJSON. Unlike ReadJSON,
+// unknown fields are ignored since browsers send fields such as submit buttons and CSRF tokens.
+func ReadForm[T any](w http.ResponseWriter, r *http.Request, opts ...FormOption) (T, error) {
+	var dst T
+
+	options := newFormOptions(defaultMaxFormBytes, opts)
+	r.Body = http.MaxBytesReader(w, r.Body, options.maxBytes)
+
+	if err := r.ParseForm(); err != nil {
+		return dst, handleParseFormError(err, options.maxBytes)
+	}
+
+	if err := decodeForm(&dst, r.PostForm, nil, options); err != nil {
+		return dst, err
+	}
+
+	return dst, nil
+}
+
+// ReadMultipart decodes a multipart/form-data request body into T. Fields are matched like ReadForm. Files are
+// decoded into *multipart.FileHeader and []*multipart.FileHeader fields, and files larger than the maximum file
+// size are rejected. Files that don't fit in memory are stored in temporary files. net/http doesn't remove them for
+// requests passed through middleware, so callers must defer r.MultipartForm.RemoveAll() once ReadMultipart succeeds.
+func ReadMultipart[T any](w http.ResponseWriter, r *http.Request, opts ...FormOption) (T, error) {
+	var dst T
+
+	options := newFormOptions(defaultMaxMultipartBytes, opts)
+	r.Body = http.MaxBytesReader(w, r.Body, options.maxBytes)
+
+	if err := r.ParseMultipartForm(multipartMemoryBytes); err != nil {
+		return dst, handleParseFormError(err, options.maxBytes)
+	}
+
+	if err := decodeForm(&dst, r.MultipartForm.Value, r.MultipartForm.File, options); err != nil {
+		_ = r.MultipartForm.RemoveAll()
+
+		return dst, err
+	}
+
+	return dst, nil
+}
+
+// ReadBody decodes the request body into T with ReadJSON, ReadForm or ReadMultipart depending on its content type.
+// Returns ErrUnsupportedMediaType for any other content type. Callers must remove r.MultipartForm, if set, like
+// ReadMultipart.
+func ReadBody[T any](w http.ResponseWriter, r *http.Request, opts ...FormOption) (T, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	switch mediaType {
+	case "application/json":
+		return ReadJSON[T](w, r)
+	case "application/x-www-form-urlencoded":
+		return ReadForm[T](w, r, opts...)
+	case "multipart/form-data":
+		return ReadMultipart[T](w, r, opts...)
+	default:
+		var dst T
+
+		return dst, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, r.Header.Get("Content-Type"))
+	}
+}
+
+// UnsupportedMediaTypeResponse method is used to send a 415 Unsupported Media Type status code.
+func UnsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, apiError{
+		status: http.StatusUnsupportedMediaType,
+		code:   CodeUnsupportedMediaType,
+		detail: err.Error(),
+	})
+}
+
+// handleParseFormError handles errors returned by http.Request.ParseForm and ParseMultipartForm.
+func handleParseFormError(err error, maxBytes int64) error {
+	var maxBytesError *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesError):
+		return &FormError{Code: CodeBodyTooLarge, Message: fmt.Sprintf("body must not be larger than %d bytes", maxBytes)}
+	case errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		return &FormError{Code: CodeMalformedForm, Message: "body must be a multipart form"}
+	default:
+		return &FormError{Code: CodeMalformedForm, Message: "body contains a badly-formed form"}
+	}
+}
+
+// decodeForm sets the fields of dst, a pointer to a struct, from values and files.
+func decodeForm(dst any, values url.Values, files map[string][]*multipart.FileHeader, options *formOptions) error {
+	val := reflect.ValueOf(dst).Elem()
+	if val.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("form destination must be a struct, got %s", val.Type()))
+	}
+
+	typ := val.Type()
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		name := formFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		fieldVal := val.Field(i)
+
+		switch fieldVal.Interface().(type) {
+		case *multipart.FileHeader:
+			if err := checkFileSizes(name, files[name], options.maxFileBytes); err != nil {
+				return err
+			}
+
+			if len(files[name]) > 0 {
+				fieldVal.Set(reflect.ValueOf(files[name][0]))
+			}
+
+			continue
+		case []*multipart.FileHeader:
+			if err := checkFileSizes(name, files[name], options.maxFileBytes); err != nil {
+				return err
+			}
+
+			fieldVal.Set(reflect.ValueOf(files[name]))
+
+			continue
+		}
+
+		formValues, ok := values[name]
+		if !ok {
+			continue
+		}
+
+		if err := setFormField(fieldVal, formValues); err != nil {
+			return &FormError{
+				Field:   name,
+				Code:    CodeInvalidType,
+				Message: fmt.Sprintf("body contains incorrect type for field %q", name),
+			}
+		}
+	}
+
+	return nil
+}
+
+// formFieldName returns the name of the form field of a struct field, or an empty string if it should be skipped.
+func formFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	tag, ok := field.Tag.Lookup("form")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}
+
+func checkFileSizes(name string, files []*multipart.FileHeader, maxBytes int64) error {
+	for _, file := range files {
+		if file.Size > maxBytes {
+			return &FormError{
+				Field:   name,
+				Code:    CodeFileTooLarge,
+				Message: fmt.Sprintf("file %q must not be larger than %d bytes", file.Filename, maxBytes),
+			}
+		}
+	}
+
+	return nil
+}
+
+// setFormField sets field from the values of a form field. Slices get every value, other types get the first one.
+// Empty values leave pointers nil and other fields unchanged, except for strings.
+func setFormField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), 0, len(values))
+
+		for _, value := range values {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setFormElem(elem, value); err != nil {
+				return err
+			}
+
+			slice = reflect.Append(slice, elem)
+		}
+
+		field.Set(slice)
+
+		return nil
+	}
+
+	value := ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+
+	if value == "" && field.Kind() != reflect.String {
+		return nil
+	}
+
+	return setFormElem(field, value)
+}
+
+// setFormElem sets field, which may be a pointer, from a single form value.
+func setFormElem(field reflect.Value, value string) error {
+	if field.Kind() != reflect.Pointer {
+		return setFormValue(field, value)
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	ptr := reflect.New(field.Type().Elem())
+	if err := setFormValue(ptr.Elem(), value); err != nil {
+		return err
+	}
+
+	field.Set(ptr)
+
+	return nil
+}
+
+// setFormValue sets field from a single form value.
+func setFormValue(field reflect.Value, value string) error {
+	if _, ok := field.Interface().(time.Time); ok {
+		return setFormTime(field, value)
+	}
+
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		//nolint: wrapcheck
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	//nolint: exhaustive
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		// checkboxes are sent as "on" when checked and aren't sent otherwise
+		if value == "on" {
+			field.SetBool(true)
+
+			return nil
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse bool: %w", err)
+		}
+
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse int: %w", err)
+		}
+
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse uint: %w", err)
+		}
+
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse float: %w", err)
+		}
+
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormField, field.Type())
+	}
+
+	return nil
+}
+
+func setFormTime(field reflect.Value, value string) error {
+	for _, layout := range formDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			field.Set(reflect.ValueOf(parsed))
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("failed to parse time %q", value)
+}
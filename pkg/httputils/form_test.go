@@ -0,0 +1,332 @@
+package httputils_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/decimal"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+type formRequest struct {
+	Name     string            `json:"name"`
+	Age      *int              `json:"age"`
+	Active   bool              `json:"active"`
+	Tags     []string          `json:"tags"`
+	Price    decimal.Decimal   `json:"price"`
+	Birthday time.Time         `form:"birthday"`
+	Ignored  string            `form:"-"`
+	Scores   []*int            `json:"scores"`
+	Meta     map[string]string `json:"meta"`
+}
+
+func TestReadForm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		body          string
+		expectedField string
+		expectedCode  string
+		check         func(t *testing.T, req formRequest)
+	}{
+		{
+			name: "all fields",
+			body: "name=test&age=30&active=on&tags=a&tags=b&price=1.25&birthday=2000-01-02&Ignored=x&csrf_token=abc",
+			check: func(t *testing.T, req formRequest) {
+				t.Helper()
+
+				if req.Name != "test" || req.Age == nil || *req.Age != 30 || !req.Active {
+					t.Errorf("unexpected request %+v", req)
+				}
+
+				if len(req.Tags) != 2 || req.Tags[0] != "a" || req.Tags[1] != "b" {
+					t.Errorf("expected tags [a b], got %v", req.Tags)
+				}
+
+				if req.Price.String() != "1.25" {
+					t.Errorf("expected price 1.25, got %s", req.Price)
+				}
+
+				if !req.Birthday.Equal(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("expected birthday 2000-01-02, got %s", req.Birthday)
+				}
+
+				if req.Ignored != "" {
+					t.Errorf("expected ignored field to be empty, got %s", req.Ignored)
+				}
+			},
+		},
+		{
+			name: "empty values",
+			body: "name=&age=",
+			check: func(t *testing.T, req formRequest) {
+				t.Helper()
+
+				if req.Name != "" || req.Age != nil || req.Active {
+					t.Errorf("unexpected request %+v", req)
+				}
+			},
+		},
+		{
+			name:          "invalid int",
+			body:          "age=abc",
+			expectedField: "age",
+			expectedCode:  httputils.CodeInvalidType,
+		},
+		{
+			name: "pointer slice",
+			body: "scores=1&scores=2",
+			check: func(t *testing.T, req formRequest) {
+				t.Helper()
+
+				if len(req.Scores) != 2 || *req.Scores[0] != 1 || *req.Scores[1] != 2 {
+					t.Errorf("expected scores [1 2], got %v", req.Scores)
+				}
+			},
+		},
+		{
+			name:          "unsupported type",
+			body:          "meta=x",
+			expectedField: "meta",
+			expectedCode:  httputils.CodeInvalidType,
+		},
+		{
+			name:          "invalid time",
+			body:          "birthday=yesterday",
+			expectedField: "birthday",
+			expectedCode:  httputils.CodeInvalidType,
+		},
+		{
+			name:         "body too large",
+			body:         "name=" + strings.Repeat("a", 1_048_576),
+			expectedCode: httputils.CodeBodyTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			req, err := httputils.ReadForm[formRequest](httptest.NewRecorder(), r)
+			assertFormError(t, err, tt.expectedField, tt.expectedCode)
+
+			if tt.check != nil {
+				tt.check(t, req)
+			}
+		})
+	}
+}
+
+type uploadRequest struct {
+	Name        string                  `json:"name"`
+	Avatar      *multipart.FileHeader   `form:"avatar"`
+	Attachments []*multipart.FileHeader `form:"attachments"`
+}
+
+func TestReadMultipart(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		avatar        string
+		expectedField string
+		expectedCode  string
+	}{
+		{name: "valid", avatar: "small"},
+		{name: "file too large", avatar: strings.Repeat("a", 11), expectedField: "avatar", expectedCode: httputils.CodeFileTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newMultipartRequest(t, map[string]string{"name": "test"}, map[string][]string{
+				"avatar":      {tt.avatar},
+				"attachments": {"one", "two"},
+			})
+
+			req, err := httputils.ReadMultipart[uploadRequest](httptest.NewRecorder(), r, httputils.WithMaxFileBytes(10))
+			assertFormError(t, err, tt.expectedField, tt.expectedCode)
+
+			if err != nil {
+				return
+			}
+
+			if req.Name != "test" || req.Avatar == nil || req.Avatar.Filename != "avatar0.txt" || len(req.Attachments) != 2 {
+				t.Fatalf("unexpected request %+v", req)
+			}
+
+			file, err := req.Avatar.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			var contents bytes.Buffer
+			if _, err := contents.ReadFrom(file); err != nil {
+				t.Fatal(err)
+			}
+
+			if contents.String() != tt.avatar {
+				t.Errorf("expected avatar %q, got %q", tt.avatar, contents.String())
+			}
+		})
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expectedErr error
+	}{
+		{"json", "application/json", `{"name":"test"}`, nil},
+		{"form", "application/x-www-form-urlencoded", "name=test", nil},
+		{"form with charset", "application/x-www-form-urlencoded; charset=utf-8", "name=test", nil},
+		{"unsupported", "text/plain", "name=test", httputils.ErrUnsupportedMediaType},
+		{"missing content type", "", "name=test", httputils.ErrUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			req, err := httputils.ReadBody[formRequest](httptest.NewRecorder(), r)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if tt.expectedErr == nil && req.Name != "test" {
+				t.Errorf("expected name test, got %q", req.Name)
+			}
+		})
+	}
+
+	t.Run("multipart", func(t *testing.T) {
+		t.Parallel()
+
+		r := newMultipartRequest(t, map[string]string{"name": "test"}, nil)
+
+		req, err := httputils.ReadBody[formRequest](httptest.NewRecorder(), r)
+		if err != nil || req.Name != "test" {
+			t.Errorf("expected name test, got %q (%v)", req.Name, err)
+		}
+	})
+}
+
+func TestFormErrorResponses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "invalid form",
+			err:            &httputils.FormError{Field: "age", Code: httputils.CodeInvalidType, Message: "bad"},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   httputils.CodeInvalidType,
+		},
+		{
+			name:           "unsupported media type",
+			err:            httputils.ErrUnsupportedMediaType,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedCode:   httputils.CodeUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			httputils.HandleErrorResponse(w, httptest.NewRequest(http.MethodPost, "/", nil), tt.err)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if !strings.Contains(w.Body.String(), tt.expectedCode) {
+				t.Errorf("expected code %s in %s", tt.expectedCode, w.Body.String())
+			}
+		})
+	}
+}
+
+func assertFormError(t *testing.T, err error, expectedField, expectedCode string) {
+	t.Helper()
+
+	if expectedCode == "" {
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		return
+	}
+
+	var formErr *httputils.FormError
+	if !errors.As(err, &formErr) || !errors.Is(err, httputils.ErrInvalidForm) {
+		t.Fatalf("expected form error, got %v", err)
+	}
+
+	if formErr.Field != expectedField || formErr.Code != expectedCode {
+		t.Errorf("expected %s/%s, got %s/%s", expectedField, expectedCode, formErr.Field, formErr.Code)
+	}
+}
+
+func newMultipartRequest(t *testing.T, values map[string]string, files map[string][]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	for key, value := range values {
+		if err := writer.WriteField(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for key, contents := range files {
+		for i, content := range contents {
+			part, err := writer.CreateFormFile(key, key+strconv.Itoa(i)+".txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := part.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return r
+}
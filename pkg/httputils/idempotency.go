@@ -0,0 +1,241 @@
+package httputils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+// IdempotencyKeyHeader is the request header holding the client's idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Machine-readable codes of the errors sent by the idempotency middleware.
+const (
+	CodeInvalidIdempotencyKey  = "invalid_idempotency_key"
+	CodeIdempotencyKeyInUse    = "idempotency_key_in_use"
+	CodeIdempotencyKeyMismatch = "idempotency_key_mismatch"
+)
+
+// ErrIdempotencyKeyInUse is returned when a request with the same idempotency key is still being processed.
+var ErrIdempotencyKeyInUse = errors.New("a request with this idempotency key is in progress")
+
+// ErrIdempotencyKeyMismatch is returned when an idempotency key is reused with a different request.
+var ErrIdempotencyKeyMismatch = errors.New("idempotency key was used with a different request")
+
+const (
+	defaultIdempotencyTTL     = 24 * time.Hour
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestSize  = 1_048_576
+	idempotencyCleanupTimeout = 10 * time.Second
+	// idempotencyLockTimeout is how long a key stays reserved by a request that never completes, e.g.
+	// because the process crashed.
+	idempotencyLockTimeout = time.Minute
+)
+
+// StoredResponse is a response recorded for an idempotency key.
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore records the responses to requests with an idempotency key. Stores must be safe for
+// concurrent use.
+type IdempotencyStore interface {
+	// Begin reserves key for a request with the given fingerprint. Returns the stored response if the key was
+	// already used, ErrIdempotencyKeyInUse if a request with the key is in progress and ErrIdempotencyKeyMismatch
+	// if the key was used with a different fingerprint.
+	Begin(ctx context.Context, key, fingerprint string) (*StoredResponse, error)
+	// Complete stores the response for a reserved key until ttl has passed.
+	Complete(ctx context.Context, key string, response StoredResponse, ttl time.Duration) error
+	// Release removes the reservation of a key so that the request can be retried.
+	Release(ctx context.Context, key string) error
+	// DeleteExpired deletes expired keys.
+	DeleteExpired(ctx context.Context) error
+}
+
+// IdempotencyConfig configures NewIdempotencyMiddleware.
+type IdempotencyConfig struct {
+	// TTL is how long responses are stored. Defaults to IDEMPOTENCY_TTL seconds or 24 hours.
+	TTL time.Duration
+	// Scope returns the owner of the request's key, such as the signed in user, so that clients can't replay
+	// each other's responses. Requests with an empty scope aren't made idempotent.
+	Scope func(r *http.Request) string
+}
+
+// NewIdempotencyMiddleware makes requests with an Idempotency-Key header safe to retry. The first response to a
+// key is stored and replayed to retries with an Idempotent-Replayed header. Retries that are sent while the first
+// request is in progress get a 409 Conflict response, and reusing a key with a different method, path or body
+// gets a 422 Unprocessable Entity response. Server errors aren't stored so that the request can be retried.
+func NewIdempotencyMiddleware(store IdempotencyStore, config IdempotencyConfig) func(next http.Handler) http.Handler {
+	if config.TTL == 0 {
+		ttl, err := parser.ParseEnvInt("IDEMPOTENCY_TTL", int(defaultIdempotencyTTL.Seconds()))
+		if err != nil {
+			panic(err)
+		}
+
+		config.TTL = time.Duration(ttl) * time.Second
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+			if idempotencyKey == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			scope := ""
+			if config.Scope != nil {
+				scope = config.Scope(r)
+			}
+
+			if scope == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				writeError(w, r, apiError{
+					status: http.StatusBadRequest,
+					code:   CodeInvalidIdempotencyKey,
+					detail: "the idempotency key must be at most " + strconv.Itoa(maxIdempotencyKeyLength) + " characters",
+				})
+
+				return
+			}
+
+			fingerprint, err := fingerprintRequest(w, r)
+			if err != nil {
+				writeError(w, r, apiError{
+					status: http.StatusRequestEntityTooLarge,
+					code:   CodeBodyTooLarge,
+					detail: "body must not be larger than " + strconv.Itoa(maxIdempotentRequestSize) + " bytes",
+				})
+
+				return
+			}
+
+			key := scope + ":" + idempotencyKey
+
+			stored, err := store.Begin(r.Context(), key, fingerprint)
+
+			switch {
+			case errors.Is(err, ErrIdempotencyKeyInUse):
+				w.Header().Set("Retry-After", "1")
+				writeError(w, r, apiError{status: http.StatusConflict, code: CodeIdempotencyKeyInUse, detail: err.Error()})
+
+				return
+			case errors.Is(err, ErrIdempotencyKeyMismatch):
+				writeError(w, r, apiError{status: http.StatusUnprocessableEntity, code: CodeIdempotencyKeyMismatch, detail: err.Error()})
+
+				return
+			case err != nil:
+				ServerErrorResponse(w, r, err)
+
+				return
+			case stored != nil:
+				replayResponse(w, r, stored)
+
+				return
+			}
+
+			buffer := NewBufferedResponseWriter(w)
+
+			defer func() {
+				if rec := recover(); rec != nil {
+					releaseIdempotencyKey(r, store, key)
+					panic(rec)
+				}
+			}()
+
+			next.ServeHTTP(buffer, r)
+
+			if buffer.Status() >= http.StatusInternalServerError {
+				releaseIdempotencyKey(r, store, key)
+			} else {
+				err = store.Complete(r.Context(), key, StoredResponse{
+					Status: buffer.Status(),
+					Header: buffer.Header().Clone(),
+					Body:   bytes.Clone(buffer.Body()),
+				}, config.TTL)
+				if err != nil {
+					slog.ErrorContext(r.Context(), "failed to store idempotent response", "error", err)
+				}
+			}
+
+			if err := buffer.Send(); err != nil {
+				slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+			}
+		})
+	}
+}
+
+// StartIdempotencyCleanup deletes expired keys from store every interval until ctx is done.
+func StartIdempotencyCleanup(ctx context.Context, store IdempotencyStore, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cleanupCtx, cancel := context.WithTimeout(ctx, idempotencyCleanupTimeout)
+				if err := store.DeleteExpired(cleanupCtx); err != nil {
+					slog.ErrorContext(ctx, "failed to delete expired idempotency keys", "error", err)
+				}
+
+				cancel()
+			}
+		}
+	}()
+}
+
+// fingerprintRequest hashes the method, URL and body of the request. The body is restored so that the
+// handler can read it.
+func fingerprintRequest(w http.ResponseWriter, r *http.Request) (string, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestSize))
+	if err != nil {
+		return "", err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func replayResponse(w http.ResponseWriter, r *http.Request, stored *StoredResponse) {
+	for key, values := range stored.Header {
+		w.Header()[key] = values
+	}
+
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+
+	if _, err := w.Write(stored.Body); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+func releaseIdempotencyKey(r *http.Request, store IdempotencyStore, key string) {
+	if err := store.Release(r.Context(), key); err != nil {
+		slog.ErrorContext(r.Context(), "failed to release idempotency key", "error", err)
+	}
+}
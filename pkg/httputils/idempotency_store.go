@@ -0,0 +1,191 @@
+package httputils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type idempotencyEntry struct {
+	fingerprint string
+	response    *StoredResponse
+	expiresAt   time.Time
+}
+
+// MemoryIdempotencyStore keeps idempotency keys in memory. Keys aren't shared between processes.
+type MemoryIdempotencyStore struct {
+	mutex   sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+// NewMemoryIdempotencyStore creates an in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]*idempotencyEntry)}
+}
+
+// Begin reserves key for a request with the given fingerprint. See IdempotencyStore.
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key, fingerprint string) (*StoredResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.expiresAt.Before(now) {
+		s.entries[key] = &idempotencyEntry{fingerprint: fingerprint, expiresAt: now.Add(idempotencyLockTimeout)}
+
+		return nil, nil
+	}
+
+	switch {
+	case entry.fingerprint != fingerprint:
+		return nil, ErrIdempotencyKeyMismatch
+	case entry.response == nil:
+		return nil, ErrIdempotencyKeyInUse
+	}
+
+	return entry.response, nil
+}
+
+// Complete stores the response for a reserved key until ttl has passed.
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, response StoredResponse, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		entry.response = &response
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	return nil
+}
+
+// Release removes the reservation of a key so that the request can be retried.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
+
+// DeleteExpired deletes expired keys.
+func (s *MemoryIdempotencyStore) DeleteExpired(_ context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	for key, entry := range s.entries {
+		if entry.expiresAt.Before(now) {
+			delete(s.entries, key)
+		}
+	}
+
+	return nil
+}
+
+// SQLiteIdempotencyStore keeps idempotency keys in the idempotency_keys table so that they are shared by every
+// process using the database.
+type SQLiteIdempotencyStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteIdempotencyStore creates an idempotency store backed by the idempotency_keys table. db must be writable.
+func NewSQLiteIdempotencyStore(db *sql.DB) *SQLiteIdempotencyStore {
+	return &SQLiteIdempotencyStore{db: db}
+}
+
+// Begin reserves key for a request with the given fingerprint. See IdempotencyStore. Expired keys are
+// reserved with a single statement so that concurrent requests can't both reserve the same key.
+func (s *SQLiteIdempotencyStore) Begin(ctx context.Context, key, fingerprint string) (*StoredResponse, error) {
+	now := time.Now()
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at) VALUES (?1, ?2, ?3)
+		ON CONFLICT (key) DO UPDATE SET fingerprint = ?2, status = NULL, header = NULL, body = NULL, expires_at = ?3
+		WHERE expires_at < ?4`,
+		key, fingerprint, now.Add(idempotencyLockTimeout).UnixNano(), now.UnixNano(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	if reserved, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	} else if reserved == 1 {
+		return nil, nil
+	}
+
+	var (
+		storedFingerprint string
+		status            sql.NullInt64
+		header            sql.NullString
+		body              []byte
+	)
+
+	err = s.db.QueryRowContext(ctx, "SELECT fingerprint, status, header, body FROM idempotency_keys WHERE key = ?", key).
+		Scan(&storedFingerprint, &status, &header, &body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the key was released after the insert; let the client retry
+		return nil, ErrIdempotencyKeyInUse
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	switch {
+	case storedFingerprint != fingerprint:
+		return nil, ErrIdempotencyKeyMismatch
+	case !status.Valid:
+		return nil, ErrIdempotencyKeyInUse
+	}
+
+	response := &StoredResponse{Status: int(status.Int64), Header: http.Header{}, Body: body}
+	if err := json.Unmarshal([]byte(header.String), &response.Header); err != nil {
+		return nil, fmt.Errorf("failed to decode idempotent response: %w", err)
+	}
+
+	return response, nil
+}
+
+// Complete stores the response for a reserved key until ttl has passed.
+func (s *SQLiteIdempotencyStore) Complete(ctx context.Context, key string, response StoredResponse, ttl time.Duration) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status = ?, header = ?, body = ?, expires_at = ? WHERE key = ?",
+		response.Status, string(header), response.Body, time.Now().Add(ttl).UnixNano(), key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes the reservation of a key so that the request can be retried.
+func (s *SQLiteIdempotencyStore) Release(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = ?", key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes expired keys.
+func (s *SQLiteIdempotencyStore) DeleteExpired(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < ?", time.Now().UnixNano()); err != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package httputils_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	stores := map[string]httputils.IdempotencyStore{
+		"memory": httputils.NewMemoryIdempotencyStore(),
+		"sqlite": httputils.NewSQLiteIdempotencyStore(db),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			started := make(chan struct{})
+			release := make(chan struct{})
+
+			handler := httputils.NewIdempotencyMiddleware(store, httputils.IdempotencyConfig{
+				TTL:   time.Hour,
+				Scope: func(r *http.Request) string { return r.Header.Get("X-User") },
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				if r.URL.Path == "/slow" {
+					started <- struct{}{}
+					<-release
+				}
+
+				if r.URL.Path == "/fail" {
+					w.WriteHeader(http.StatusInternalServerError)
+
+					return
+				}
+
+				var body map[string]string
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+
+				w.Header().Set("Location", "/tenants/1")
+				_ = httputils.WriteJSON(w, http.StatusCreated, map[string]any{"id": calls, "name": body["name"]}, nil)
+			}))
+
+			send := func(path, key, user, body string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+				if key != "" {
+					r.Header.Set(httputils.IdempotencyKeyHeader, key)
+				}
+
+				r.Header.Set("X-User", user)
+
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, r)
+
+				return rr
+			}
+
+			first := send("/tenants", "key-1", "1", `{"name":"acme"}`)
+			if first.Code != http.StatusCreated || calls != 1 {
+				t.Fatalf("expected the first request to be handled, got %d", first.Code)
+			}
+
+			retry := send("/tenants", "key-1", "1", `{"name":"acme"}`)
+			if calls != 1 {
+				t.Error("expected the retry not to be handled")
+			}
+
+			if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() ||
+				retry.Header().Get("Location") != "/tenants/1" || retry.Header().Get("Idempotent-Replayed") != "true" {
+				t.Errorf("expected the first response to be replayed, got %d %v %s", retry.Code, retry.Header(), retry.Body)
+			}
+
+			if rr := send("/tenants", "key-1", "1", `{"name":"other"}`); rr.Code != http.StatusUnprocessableEntity ||
+				!strings.Contains(rr.Body.String(), httputils.CodeIdempotencyKeyMismatch) {
+				t.Errorf("expected a mismatched payload to be rejected, got %d %s", rr.Code, rr.Body)
+			}
+
+			if rr := send("/tenants", "key-1", "2", `{"name":"acme"}`); rr.Code != http.StatusCreated || calls != 2 {
+				t.Errorf("expected keys to be scoped to the user, got %d", rr.Code)
+			}
+
+			if rr := send("/tenants", "", "1", `{"name":"acme"}`); rr.Code != http.StatusCreated || calls != 3 {
+				t.Errorf("expected requests without a key to be handled, got %d", rr.Code)
+			}
+
+			if rr := send("/tenants", "key-1", "", `{"name":"acme"}`); rr.Code != http.StatusCreated || calls != 4 {
+				t.Errorf("expected requests without a scope to be handled, got %d", rr.Code)
+			}
+
+			send("/fail", "key-2", "1", `{}`)
+			send("/fail", "key-2", "1", `{}`)
+
+			if calls != 6 {
+				t.Error("expected server errors not to be stored")
+			}
+
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+
+				send("/slow", "key-3", "1", `{"name":"acme"}`)
+			}()
+
+			<-started
+
+			if rr := send("/slow", "key-3", "1", `{"name":"acme"}`); rr.Code != http.StatusConflict || rr.Header().Get("Retry-After") == "" {
+				t.Errorf("expected a request in progress to conflict, got %d %v", rr.Code, rr.Header())
+			}
+
+			close(release)
+			<-done
+
+			if rr := send("/tenants", strings.Repeat("k", 256), "1", `{}`); rr.Code != http.StatusBadRequest {
+				t.Errorf("expected a long key to be rejected, got %d", rr.Code)
+			}
+		})
+	}
+}
+
+func TestSQLiteIdempotencyStore_Expiry(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	store := httputils.NewSQLiteIdempotencyStore(db)
+	ctx := context.Background()
+
+	if _, err := store.Begin(ctx, "1:key", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Complete(ctx, "1:key", httputils.StoredResponse{Status: http.StatusCreated}, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// expired keys can be reused with a different request
+	response, err := store.Begin(ctx, "1:key", "b")
+	if response != nil || err != nil {
+		t.Fatalf("expected the expired key to be reserved, got %v %v", response, err)
+	}
+
+	if _, err := store.Begin(ctx, "1:key", "a"); !errors.Is(err, httputils.ErrIdempotencyKeyMismatch) {
+		t.Errorf("expected ErrIdempotencyKeyMismatch, got %v", err)
+	}
+
+	if err := store.Complete(ctx, "1:key", httputils.StoredResponse{Status: http.StatusCreated}, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM idempotency_keys").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 0 {
+		t.Errorf("expected expired keys to be deleted, got %d", count)
+	}
+}
@@ -7,11 +7,43 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/validation"
 )
 
 // ErrInvalidJSON is returned when the body is not valid JSON.
 var ErrInvalidJSON = errors.New("invalid JSON")
 
+// Codes of the errors returned by ReadJSON.
+const (
+	CodeMalformedJSON = "malformed_json"
+	CodeEmptyBody     = "empty_body"
+	CodeBodyTooLarge  = "body_too_large"
+	CodeUnknownField  = "unknown_field"
+	CodeInvalidType   = "invalid_type"
+)
+
+// JSONError is returned by ReadJSON when the request body can't be decoded. It wraps ErrInvalidJSON.
+// Field is set when the error is caused by a single key of the body.
+type JSONError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidJSON, e.Message)
+}
+
+func (e *JSONError) Unwrap() error {
+	return ErrInvalidJSON
+}
+
+// ValidationError returns the error as a validation error so it can be rendered with field errors.
+func (e *JSONError) ValidationError() validation.Error {
+	return validation.Error{Field: e.Field, Code: e.Code, Message: e.Message}
+}
+
 // ReadJSON decodes request Body into corresponding Go type. It triages for any potential errors
 // and returns corresponding appropriate errors.
 func ReadJSON[T any](w http.ResponseWriter, r *http.Request) (T, error) {
@@ -50,24 +82,31 @@ func handleDecodeError(err error, maxBytes int) error {
 
 	switch {
 	case errors.As(err, &syntaxError):
-		return fmt.Errorf("%w: body contains badly-formed JSON at (character %d)", ErrInvalidJSON, syntaxError.Offset)
+		return &JSONError{
+			Code:    CodeMalformedJSON,
+			Message: fmt.Sprintf("body contains badly-formed JSON at (character %d)", syntaxError.Offset),
+		}
 
 	case errors.Is(err, io.ErrUnexpectedEOF):
-		return fmt.Errorf("%w: body contains badly-formed JSON", ErrInvalidJSON)
+		return &JSONError{Code: CodeMalformedJSON, Message: "body contains badly-formed JSON"}
 
 	case errors.As(err, &unmarshalTypeError):
 		return handleUnmarshalTypeError(unmarshalTypeError)
 
 	case errors.Is(err, io.EOF):
-		return fmt.Errorf("%w: body must not be empty", ErrInvalidJSON)
+		return &JSONError{Code: CodeEmptyBody, Message: "body must not be empty"}
 
 	case strings.HasPrefix(err.Error(), "json: unknown field "):
 		fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
 
-		return fmt.Errorf("%w: body contains unknown key %s", ErrInvalidJSON, fieldName)
+		return &JSONError{
+			Field:   strings.Trim(fieldName, `"`),
+			Code:    CodeUnknownField,
+			Message: "body contains unknown key " + fieldName,
+		}
 
 	case err.Error() == "http: request body too large":
-		return fmt.Errorf("%w: body must not be larger than %d bytes", ErrInvalidJSON, maxBytes)
+		return &JSONError{Code: CodeBodyTooLarge, Message: fmt.Sprintf("body must not be larger than %d bytes", maxBytes)}
 
 	case errors.As(err, &invalidUnmarshalError):
 		panic(err)
@@ -80,16 +119,23 @@ func handleDecodeError(err error, maxBytes int) error {
 // handleUnmarshalTypeError handles json.UnmarshalTypeError and returns a custom error.
 func handleUnmarshalTypeError(err *json.UnmarshalTypeError) error {
 	if err.Field != "" {
-		return fmt.Errorf("%w: body contains incorrect JSON type for field %q", ErrInvalidJSON, err.Field)
+		return &JSONError{
+			Field:   err.Field,
+			Code:    CodeInvalidType,
+			Message: fmt.Sprintf("body contains incorrect JSON type for field %q", err.Field),
+		}
 	}
 
-	return fmt.Errorf("%w: body contains incorrect JSON type (at character %d)", ErrInvalidJSON, err.Offset)
+	return &JSONError{
+		Code:    CodeInvalidType,
+		Message: fmt.Sprintf("body contains incorrect JSON type (at character %d)", err.Offset),
+	}
 }
 
 // ensureSingleJSONValue ensures the request body contains only a single JSON value.
 func ensureSingleJSONValue(dec *json.Decoder) error {
 	if err := dec.Decode(&struct{}{}); err != io.EOF {
-		return fmt.Errorf("%w: body must only contain a single JSON value", ErrInvalidJSON)
+		return &JSONError{Code: CodeMalformedJSON, Message: "body must only contain a single JSON value"}
 	}
 
 	return nil
@@ -105,16 +151,17 @@ func WriteJSON(w http.ResponseWriter, status int, data interface{}, headers http
 	// Append a newline to make it easier to view in terminal applications.
 	jsonPayload = append(jsonPayload, '\n')
 
+	SetJSONContentTypeResponseHeader(w)
+
 	// At this point, we know that we won't encounter any more errors before writing the response,
 	// so it's safe to add any headers that we want to include. We loop through the header map
-	// and add each header to the http.ResponseWriter header map. Note that it's OK if the
-	// provided header map is nil. Go doesn't through an error if you try to range over (
-	// or generally, read from) a nil map
+	// and add each header to the http.ResponseWriter header map, which lets callers override the
+	// content type. Note that it's OK if the provided header map is nil. Go doesn't through an
+	// error if you try to range over (or generally, read from) a nil map
 	for key, value := range headers {
 		w.Header()[key] = value
 	}
 
-	SetJSONContentTypeResponseHeader(w)
 	w.WriteHeader(status)
 
 	if _, err := w.Write(jsonPayload); err != nil {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gurch101/gowebutils/pkg/tracing"
 )
 
 type contextKey string
@@ -34,6 +35,10 @@ func (h *idHandler) Handle(ctx context.Context, record slog.Record) error {
 		record.AddAttrs(slog.String("user_id", id))
 	}
 
+	if sc, ok := tracing.SpanContextFromContext(ctx); ok {
+		record.AddAttrs(slog.String("trace_id", sc.TraceID.String()), slog.String("span_id", sc.SpanID.String()))
+	}
+
 	err := h.Handler.Handle(ctx, record)
 	if err != nil {
 		return fmt.Errorf("failed to log record: %w", err)
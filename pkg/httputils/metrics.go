@@ -0,0 +1,64 @@
+package httputils
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gurch101/gowebutils/pkg/metrics"
+)
+
+// unmatchedRoute is the route label of requests that don't match a route, so that unknown paths don't create
+// new time series.
+const unmatchedRoute = "unmatched"
+
+// otherMethod is the method label of requests with a non-standard method, so that clients can't create new time
+// series by sending arbitrary methods.
+const otherMethod = "OTHER"
+
+//nolint:gochecknoglobals
+var (
+	httpRequests = metrics.Default.NewCounter("http_requests_total",
+		"Number of HTTP requests by method, route pattern and status code.", "method", "route", "status")
+	httpRequestDuration = metrics.Default.NewHistogram("http_request_duration_seconds",
+		"Latency of HTTP requests by method and route pattern.", nil, "method", "route")
+	httpRequestsInFlight = metrics.Default.NewGauge("http_requests_in_flight",
+		"Number of HTTP requests being served.").WithLabelValues()
+	rateLimitRejections = metrics.Default.NewCounter("rate_limit_rejections_total",
+		"Number of requests rejected by a rate limit policy.", "policy")
+)
+
+// MetricsMiddleware counts the requests and records the latency of each route in metrics.Default. Requests are
+// labelled by the chi route pattern rather than the path, so add it to the router before routes are mounted.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		defer func() {
+			method := methodLabel(r.Method)
+			route := routePattern(r)
+
+			httpRequests.WithLabelValues(method, route, strconv.Itoa(responseStatus(ww))).Inc()
+			httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// methodLabel returns the method label of a request, mapping methods that aren't defined by RFC 9110 or RFC 5789 to
+// OTHER.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return otherMethod
+	}
+}
@@ -0,0 +1,75 @@
+package httputils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/metrics"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	t.Parallel()
+
+	router := chi.NewRouter()
+	router.Use(httputils.MetricsMiddleware)
+	router.Get("/metrics-test/users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.Post("/metrics-test/users", func(_ http.ResponseWriter, _ *http.Request) {})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/metrics-test/users/1", nil),
+		httptest.NewRequest(http.MethodGet, "/metrics-test/users/2", nil),
+		httptest.NewRequest(http.MethodPost, "/metrics-test/users", nil),
+		httptest.NewRequest("BOGUS", "/metrics-test/users", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var b strings.Builder
+	if err := metrics.Default.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`http_requests_total{method="GET",route="/metrics-test/users/{id}",status="204"} 2`,
+		`http_requests_total{method="POST",route="/metrics-test/users",status="200"} 1`,
+		`http_requests_total{method="OTHER",route="unmatched",status="405"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/metrics-test/users/{id}"} 2`,
+		"# TYPE http_requests_in_flight gauge",
+	} {
+		if !strings.Contains(b.String(), expected+"\n") {
+			t.Errorf("expected metrics to contain %q, got %s", expected, b.String())
+		}
+	}
+}
+
+func TestRateLimitRejectionMetrics(t *testing.T) {
+	t.Parallel()
+
+	policy := httputils.RateLimitPolicy{
+		Name:  "metrics-test",
+		Limit: httputils.RateLimit{Rate: 1, Burst: 1},
+		Key:   func(_ *http.Request) string { return "key" },
+	}
+	handler := httputils.NewRateLimitMiddleware(httputils.NewMemoryRateLimitStore(), policy)(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+
+	for range 3 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	var b strings.Builder
+	if err := metrics.Default.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `rate_limit_rejections_total{policy="metrics-test"} 2` + "\n"
+	if !strings.Contains(b.String(), expected) {
+		t.Errorf("expected metrics to contain %q, got %s", expected, b.String())
+	}
+}
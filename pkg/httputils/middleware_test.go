@@ -0,0 +1,49 @@
+package httputils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+func TestQueryTrackingMiddleware(t *testing.T) {
+	t.Setenv("QUERY_TRACKING_ENABLED", "true")
+
+	existing := dbutils.NewQueryTracker(0)
+
+	tests := []struct {
+		name    string
+		tracker *dbutils.QueryTracker
+	}{
+		{name: "new tracker"},
+		{name: "existing tracker", tracker: existing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *dbutils.QueryTracker
+
+			handler := httputils.QueryTrackingMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got, _ = dbutils.QueryTrackerFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.tracker != nil {
+				req = req.WithContext(dbutils.ContextWithQueryTracker(req.Context(), tt.tracker))
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got == nil {
+				t.Fatal("expected a query tracker in the request context")
+			}
+
+			if tt.tracker != nil && got != tt.tracker {
+				t.Error("expected the existing query tracker to be reused")
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package httputils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+// RateLimit allows Burst requests at once, refilled at Rate requests per second.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// window is the time it takes to refill a full burst.
+func (l RateLimit) window() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+func (l RateLimit) interval() time.Duration {
+	return time.Duration(float64(time.Second) / l.Rate)
+}
+
+// RateLimitResult is the outcome of a request against a rate limit.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the limit is fully replenished.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed. It is zero when the request is allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore records requests against rate limits. Stores must be safe for concurrent use.
+type RateLimitStore interface {
+	// Take records a request for key and reports whether it is allowed by limit.
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// gcra applies the generic cell rate algorithm to a request made at now. tat is the theoretical arrival
+// time stored for the key, or the zero time for a new key. Returns the tat to store if the request is allowed.
+func gcra(now, tat time.Time, limit RateLimit) (time.Time, RateLimitResult) {
+	interval := limit.interval()
+	window := limit.window()
+
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-window)
+
+	if now.Before(allowAt) {
+		return tat, RateLimitResult{
+			Limit:      limit.Burst,
+			Reset:      tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}
+	}
+
+	return newTAT, RateLimitResult{
+		Allowed:   true,
+		Limit:     limit.Burst,
+		Remaining: int((window - newTAT.Sub(now)) / interval),
+		Reset:     newTAT.Sub(now),
+	}
+}
+
+// RateLimitKeyFunc returns the key a request is limited by. Requests with an empty key are not limited.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitPolicy limits requests that share a key. Each policy has its own buckets, so a request
+// can be limited by several policies, e.g. per IP and per user.
+type RateLimitPolicy struct {
+	// Name prefixes the keys of the policy in the store.
+	Name  string
+	Limit RateLimit
+	Key   RateLimitKeyFunc
+}
+
+// RateLimitByIP limits requests by the client IP address. Use RealIPMiddleware to resolve the
+// client IP address when running behind a proxy.
+func RateLimitByIP(r *http.Request) string {
+	return remoteIP(r)
+}
+
+// RateLimitByHeader limits requests by the value of a header, such as an API key. The value is hashed
+// before it is stored. Requests without the header are not limited.
+func RateLimitByHeader(header string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		value := r.Header.Get(header)
+		if value == "" {
+			return ""
+		}
+
+		hash := sha256.Sum256([]byte(value))
+
+		return hex.EncodeToString(hash[:])
+	}
+}
+
+// NewRateLimitMiddleware limits requests with the given policies. A request that exceeds any policy receives
+// a 429 Too Many Requests response. The RateLimit-* headers describe the policy closest to its limit. Requests
+// are allowed when the store fails.
+func NewRateLimitMiddleware(store RateLimitStore, policies ...RateLimitPolicy) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var (
+				limited  *RateLimitPolicy
+				headers  *RateLimitPolicy
+				exceeded RateLimitResult
+				closest  RateLimitResult
+			)
+
+			for i := range policies {
+				policy := &policies[i]
+
+				key := policy.Key(r)
+				if key == "" {
+					continue
+				}
+
+				result, err := store.Take(r.Context(), policy.Name+":"+key, policy.Limit)
+				if err != nil {
+					slog.ErrorContext(r.Context(), "failed to apply rate limit", "policy", policy.Name, "error", err)
+
+					continue
+				}
+
+				if headers == nil || result.Remaining < closest.Remaining {
+					headers, closest = policy, result
+				}
+
+				if !result.Allowed && (limited == nil || result.RetryAfter > exceeded.RetryAfter) {
+					limited, exceeded = policy, result
+				}
+			}
+
+			if limited != nil {
+				setRateLimitHeaders(w, limited, exceeded)
+				w.Header().Set("Retry-After", formatSeconds(exceeded.RetryAfter))
+				rateLimitRejections.WithLabelValues(limited.Name).Inc()
+				RateLimitExceededResponse(w, r)
+
+				return
+			}
+
+			if headers != nil {
+				setRateLimitHeaders(w, headers, closest)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, policy *RateLimitPolicy, result RateLimitResult) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", formatSeconds(result.Reset))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", policy.Limit.Burst, formatSeconds(policy.Limit.window())))
+}
+
+// formatSeconds rounds d up to whole seconds.
+func formatSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+type RateLimitConfig struct {
+	enabled bool
+	rate    float64
+	burst   int
+}
+
+const (
+	defaultRateLimitRate = 10
+
+	defaultRateLimitBurst = 20
+)
+
+func getRateLimitConfig() *RateLimitConfig {
+	rateLimitConfig := &RateLimitConfig{
+		enabled: parser.ParseEnvBool("RATE_LIMIT_ENABLED", true),
+		rate:    defaultRateLimitRate,
+		burst:   defaultRateLimitBurst,
+	}
+	if !rateLimitConfig.enabled {
+		return rateLimitConfig
+	}
+
+	rateLimit, err := parser.ParseEnvFloat64("RATE_LIMIT_RATE", rateLimitConfig.rate)
+	if err != nil {
+		panic(err)
+	}
+
+	rateLimitConfig.rate = rateLimit
+
+	burst, err := parser.ParseEnvInt("RATE_LIMIT_BURST", rateLimitConfig.burst)
+	if err != nil {
+		panic(err)
+	}
+
+	rateLimitConfig.burst = burst
+
+	return rateLimitConfig
+}
+
+// RateLimitMiddleware is a middleware that limits the number of requests per second per IP address.
+// Limits are kept in memory, so each process has its own limits. See RateLimitMiddlewareWithStore.
+func RateLimitMiddleware(next http.Handler) http.Handler {
+	return RateLimitMiddlewareWithStore(NewMemoryRateLimitStore())(next)
+}
+
+// RateLimitMiddlewareWithStore limits the number of requests per second per IP address with the limits set by
+// RATE_LIMIT_RATE and RATE_LIMIT_BURST. Use a shared store to apply the limits across processes.
+func RateLimitMiddlewareWithStore(store RateLimitStore) func(next http.Handler) http.Handler {
+	rateLimitConfig := getRateLimitConfig()
+
+	if !rateLimitConfig.enabled {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	slog.Info("rate limit middleware enabled", "rate", rateLimitConfig.rate, "burst", rateLimitConfig.burst)
+
+	return NewRateLimitMiddleware(store, RateLimitPolicy{
+		Name:  "ip",
+		Limit: RateLimit{Rate: rateLimitConfig.rate, Burst: rateLimitConfig.burst},
+		Key:   RateLimitByIP,
+	})
+}
+
+const (
+	defaultAuthRateLimitRate = 0.1
+
+	defaultAuthRateLimitBurst = 5
+)
+
+// AuthRateLimitPolicy returns the stricter per IP address policy for the login routes, set by
+// RATE_LIMIT_AUTH_RATE and RATE_LIMIT_AUTH_BURST. Returns false when rate limiting is disabled.
+func AuthRateLimitPolicy() (RateLimitPolicy, bool) {
+	if !parser.ParseEnvBool("RATE_LIMIT_ENABLED", true) {
+		return RateLimitPolicy{}, false
+	}
+
+	rate, err := parser.ParseEnvFloat64("RATE_LIMIT_AUTH_RATE", defaultAuthRateLimitRate)
+	if err != nil {
+		panic(err)
+	}
+
+	burst, err := parser.ParseEnvInt("RATE_LIMIT_AUTH_BURST", defaultAuthRateLimitBurst)
+	if err != nil {
+		panic(err)
+	}
+
+	return RateLimitPolicy{Name: "auth", Limit: RateLimit{Rate: rate, Burst: burst}, Key: RateLimitByIP}, true
+}
@@ -0,0 +1,121 @@
+package httputils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateLimitPruneInterval is how often stores delete keys that have fully replenished.
+const rateLimitPruneInterval = time.Minute
+
+// MemoryRateLimitStore keeps rate limits in memory. Limits aren't shared between processes.
+type MemoryRateLimitStore struct {
+	mutex      sync.Mutex
+	tats       map[string]time.Time
+	lastPruned time.Time
+}
+
+// NewMemoryRateLimitStore creates an in-memory rate limit store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{tats: make(map[string]time.Time), lastPruned: time.Now()}
+}
+
+// Take records a request for key and reports whether it is allowed by limit.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(s.lastPruned) > rateLimitPruneInterval {
+		for k, tat := range s.tats {
+			if tat.Before(now) {
+				delete(s.tats, k)
+			}
+		}
+
+		s.lastPruned = now
+	}
+
+	tat, result := gcra(now, s.tats[key], limit)
+	if result.Allowed {
+		s.tats[key] = tat
+	}
+
+	return result, nil
+}
+
+// SQLiteRateLimitStore keeps rate limits in the rate_limits table so that they are shared by every
+// process using the database.
+type SQLiteRateLimitStore struct {
+	db         *sql.DB
+	mutex      sync.Mutex
+	lastPruned time.Time
+}
+
+// NewSQLiteRateLimitStore creates a rate limit store backed by the rate_limits table. db must be writable.
+func NewSQLiteRateLimitStore(db *sql.DB) *SQLiteRateLimitStore {
+	return &SQLiteRateLimitStore{db: db, lastPruned: time.Now()}
+}
+
+// Take records a request for key and reports whether it is allowed by limit. The bucket is updated with a
+// single statement so that concurrent processes can't both take the last request.
+func (s *SQLiteRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	now := time.Now()
+
+	if err := s.prune(ctx, now); err != nil {
+		return RateLimitResult{}, err
+	}
+
+	var tat int64
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limits (key, tat) VALUES (?1, ?2 + ?3)
+		ON CONFLICT (key) DO UPDATE SET tat = max(tat, ?2) + ?3
+		WHERE max(tat, ?2) + ?3 - ?4 <= ?2
+		RETURNING tat`,
+		key, now.UnixNano(), limit.interval().Nanoseconds(), limit.window().Nanoseconds(),
+	).Scan(&tat)
+
+	switch {
+	case err == nil:
+		_, result := gcra(now, time.Unix(0, tat).Add(-limit.interval()), limit)
+
+		return result, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return RateLimitResult{}, fmt.Errorf("failed to take rate limit: %w", err)
+	}
+
+	// the update was skipped because the request isn't allowed
+	err = s.db.QueryRowContext(ctx, "SELECT tat FROM rate_limits WHERE key = ?", key).Scan(&tat)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to get rate limit: %w", err)
+	}
+
+	_, result := gcra(now, time.Unix(0, tat), limit)
+
+	return result, nil
+}
+
+// prune deletes keys that have fully replenished at most once per rateLimitPruneInterval.
+func (s *SQLiteRateLimitStore) prune(ctx context.Context, now time.Time) error {
+	s.mutex.Lock()
+	if now.Sub(s.lastPruned) < rateLimitPruneInterval {
+		s.mutex.Unlock()
+
+		return nil
+	}
+
+	s.lastPruned = now
+	s.mutex.Unlock()
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE tat < ?", now.UnixNano()); err != nil {
+		return fmt.Errorf("failed to prune rate limits: %w", err)
+	}
+
+	return nil
+}
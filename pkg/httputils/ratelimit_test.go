@@ -0,0 +1,144 @@
+package httputils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	policy := httputils.RateLimitPolicy{
+		Name:  "ip",
+		Limit: httputils.RateLimit{Rate: 1, Burst: 2},
+		Key:   httputils.RateLimitByIP,
+	}
+
+	handler := httputils.NewRateLimitMiddleware(httputils.NewMemoryRateLimitStore(), policy)(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		status     int
+		remaining  string
+		retryAfter string
+	}{
+		{name: "first request", remoteAddr: "1.1.1.1:1234", status: http.StatusOK, remaining: "1"},
+		{name: "second request", remoteAddr: "1.1.1.1:1234", status: http.StatusOK, remaining: "0"},
+		{name: "burst exceeded", remoteAddr: "1.1.1.1:5678", status: http.StatusTooManyRequests, remaining: "0", retryAfter: "1"},
+		{name: "other client", remoteAddr: "2.2.2.2:1234", status: http.StatusOK, remaining: "1"},
+	}
+
+	// subtests share the store, so they run in order
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		r.RemoteAddr = tt.remoteAddr
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, rr.Code)
+		}
+
+		if got := rr.Header().Get("RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("%s: expected RateLimit-Remaining %q, got %q", tt.name, tt.remaining, got)
+		}
+
+		if got := rr.Header().Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("%s: expected Retry-After %q, got %q", tt.name, tt.retryAfter, got)
+		}
+
+		if got := rr.Header().Get("RateLimit-Policy"); got != "2;w=2" {
+			t.Errorf("%s: expected RateLimit-Policy 2;w=2, got %q", tt.name, got)
+		}
+	}
+}
+
+func TestRateLimitMiddleware_MultiplePolicies(t *testing.T) {
+	t.Parallel()
+
+	handler := httputils.NewRateLimitMiddleware(
+		httputils.NewMemoryRateLimitStore(),
+		httputils.RateLimitPolicy{Name: "ip", Limit: httputils.RateLimit{Rate: 10, Burst: 10}, Key: httputils.RateLimitByIP},
+		httputils.RateLimitPolicy{Name: "api-key", Limit: httputils.RateLimit{Rate: 1, Burst: 1}, Key: httputils.RateLimitByHeader("X-API-Key")},
+	)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		if apiKey != "" {
+			r.Header.Set("X-API-Key", apiKey)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		return rr
+	}
+
+	if rr := request("key"); rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("expected the api key policy headers, got %d %v", rr.Code, rr.Header())
+	}
+
+	if rr := request("key"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the api key to be limited, got %d", rr.Code)
+	}
+
+	if rr := request(""); rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "10" {
+		t.Errorf("expected requests without an api key to be limited by ip only, got %d %v", rr.Code, rr.Header())
+	}
+}
+
+func TestSQLiteRateLimitStore(t *testing.T) {
+	t.Parallel()
+
+	db := testutils.SetupTestDB(t)
+	defer fsutils.CloseAndPanic(db)
+
+	limit := httputils.RateLimit{Rate: 1, Burst: 2}
+
+	// two stores on the same database behave like two processes
+	stores := []*httputils.SQLiteRateLimitStore{httputils.NewSQLiteRateLimitStore(db), httputils.NewSQLiteRateLimitStore(db)}
+
+	expected := []httputils.RateLimitResult{
+		{Allowed: true, Limit: 2, Remaining: 1},
+		{Allowed: true, Limit: 2, Remaining: 0},
+		{Allowed: false, Limit: 2, Remaining: 0},
+	}
+
+	for i, want := range expected {
+		result, err := stores[i%2].Take(context.Background(), "ip:1.1.1.1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Allowed != want.Allowed || result.Limit != want.Limit || result.Remaining != want.Remaining {
+			t.Errorf("request %d: expected %+v, got %+v", i+1, want, result)
+		}
+
+		if !result.Allowed && result.RetryAfter <= 0 {
+			t.Errorf("request %d: expected a retry after, got %v", i+1, result.RetryAfter)
+		}
+	}
+
+	result, err := stores[0].Take(context.Background(), "ip:2.2.2.2", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Allowed {
+		t.Error("expected keys to be limited separately")
+	}
+}
@@ -0,0 +1,115 @@
+package httputils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ErrInvalidTrustedProxy is returned when a trusted proxy is not an IP address or CIDR range.
+var ErrInvalidTrustedProxy = errors.New("invalid trusted proxy")
+
+// ParseTrustedProxies parses a comma-separated list of IP addresses and CIDR ranges, e.g. "10.0.0.0/8, ::1".
+func ParseTrustedProxies(proxies string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+
+	for _, proxy := range strings.Split(proxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidTrustedProxy, proxy)
+			}
+
+			prefixes = append(prefixes, prefix.Masked())
+
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTrustedProxy, proxy)
+		}
+
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+// RealIPMiddleware sets the remote address of the request to the client IP address when the request
+// was forwarded by a trusted proxy. X-Forwarded-For is read from right to left, skipping trusted
+// proxies, so a client can't spoof its address by sending the header itself. X-Real-IP is used when
+// X-Forwarded-For is missing. Requests from other addresses are left unchanged.
+func RealIPMiddleware(trustedProxies []netip.Prefix) func(next http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		addr = addr.Unmap()
+		for _, prefix := range trustedProxies {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remoteAddr, err := netip.ParseAddr(remoteIP(r))
+			if err != nil || !isTrusted(remoteAddr) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if clientIP, ok := forwardedClientIP(r, isTrusted); ok {
+				r.RemoteAddr = net.JoinHostPort(clientIP.String(), "0")
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// remoteIP returns the IP address of RemoteAddr, which doesn't have a port if it was set by another middleware.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func forwardedClientIP(r *http.Request, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var forwardedFor []string
+	if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		forwardedFor = strings.Split(strings.Join(values, ","), ",")
+	}
+
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwardedFor[i]))
+		if err != nil {
+			// everything to the left of an invalid entry can't be trusted
+			return netip.Addr{}, false
+		}
+
+		if !isTrusted(addr) {
+			return addr.Unmap(), true
+		}
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
@@ -0,0 +1,106 @@
+package httputils_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	t.Parallel()
+
+	trustedProxies, err := httputils.ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		realIP        string
+		expectedAddr  string
+		expectedIPKey string
+	}{
+		{
+			name:          "untrusted client can't spoof its address",
+			remoteAddr:    "1.1.1.1:1234",
+			forwardedFor:  "2.2.2.2",
+			expectedAddr:  "1.1.1.1:1234",
+			expectedIPKey: "1.1.1.1",
+		},
+		{
+			name:          "trusted proxy",
+			remoteAddr:    "10.0.0.1:1234",
+			forwardedFor:  "2.2.2.2",
+			expectedAddr:  "2.2.2.2:0",
+			expectedIPKey: "2.2.2.2",
+		},
+		{
+			name:          "spoofed entries to the left of the client are ignored",
+			remoteAddr:    "10.0.0.1:1234",
+			forwardedFor:  "3.3.3.3, 2.2.2.2, 192.168.1.1",
+			expectedAddr:  "2.2.2.2:0",
+			expectedIPKey: "2.2.2.2",
+		},
+		{
+			name:          "x-real-ip",
+			remoteAddr:    "192.168.1.1:1234",
+			realIP:        "2.2.2.2",
+			expectedAddr:  "2.2.2.2:0",
+			expectedIPKey: "2.2.2.2",
+		},
+		{
+			name:          "invalid forwarded address",
+			remoteAddr:    "10.0.0.1:1234",
+			forwardedFor:  "not-an-ip",
+			expectedAddr:  "10.0.0.1:1234",
+			expectedIPKey: "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var remoteAddr, ipKey string
+
+			handler := httputils.RealIPMiddleware(trustedProxies)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				remoteAddr = r.RemoteAddr
+				ipKey = httputils.RateLimitByIP(r)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if remoteAddr != tt.expectedAddr {
+				t.Errorf("expected remote address %q, got %q", tt.expectedAddr, remoteAddr)
+			}
+
+			if ipKey != tt.expectedIPKey {
+				t.Errorf("expected ip %q, got %q", tt.expectedIPKey, ipKey)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := httputils.ParseTrustedProxies("10.0.0.0/8, nope"); !errors.Is(err, httputils.ErrInvalidTrustedProxy) {
+		t.Errorf("expected ErrInvalidTrustedProxy, got %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+package httputils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+// CSPNonceKey is replaced with the request's nonce in ContentSecurityPolicy, e.g. 'nonce-r4nd0m'.
+const CSPNonceKey = "{nonce}"
+
+// DefaultCSPReportPath is where CSP violation reports are sent by default.
+const DefaultCSPReportPath = "/csp-report"
+
+const (
+	cspNonceContextKey       = contextKey("csp_nonce")
+	cspReportEndpoint        = "csp-endpoint"
+	cspNonceBytes            = 16
+	maxCSPReportSize         = 64 * 1024
+	defaultHSTSMaxAge        = 365 * 24 * time.Hour
+	defaultCSP               = "default-src 'self'; script-src 'self' " + CSPNonceKey + "; style-src 'self' " + CSPNonceKey + "; object-src 'none'; base-uri 'self'; frame-ancestors 'none'"
+	defaultReferrerPolicy    = "strict-origin-when-cross-origin"
+	defaultPermissionsPolicy = "camera=(), microphone=(), geolocation=()"
+)
+
+// SecurityHeadersConfig configures the headers set by NewSecurityHeadersMiddleware. Headers with an empty
+// value are not sent.
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge is how long browsers should only connect over HTTPS. Strict-Transport-Security isn't sent when zero.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// ContentSecurityPolicy is the policy sent with every response. Each occurrence of CSPNonceKey is replaced
+	// with a nonce generated for the request.
+	ContentSecurityPolicy string
+	// CSPReportOnly sends the policy with Content-Security-Policy-Report-Only so violations are reported but not blocked.
+	CSPReportOnly bool
+	// CSPReportPath is where browsers send violation reports. Reports aren't requested when empty.
+	CSPReportPath     string
+	FrameOptions      string
+	ReferrerPolicy    string
+	PermissionsPolicy string
+}
+
+// DefaultSecurityHeadersConfig returns a strict configuration suitable for server-rendered applications.
+func DefaultSecurityHeadersConfig() SecurityHeadersConfig {
+	return SecurityHeadersConfig{
+		HSTSMaxAge:            defaultHSTSMaxAge,
+		HSTSIncludeSubdomains: false,
+		ContentSecurityPolicy: defaultCSP,
+		CSPReportPath:         DefaultCSPReportPath,
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        defaultReferrerPolicy,
+		PermissionsPolicy:     defaultPermissionsPolicy,
+	}
+}
+
+// SecurityHeadersConfigFromEnv overrides DefaultSecurityHeadersConfig with HSTS_MAX_AGE (in seconds),
+// HSTS_INCLUDE_SUBDOMAINS, HSTS_PRELOAD, CSP, CSP_REPORT_ONLY, CSP_REPORT_PATH, FRAME_OPTIONS, REFERRER_POLICY
+// and PERMISSIONS_POLICY. Returns false when SECURITY_HEADERS_ENABLED is false.
+func SecurityHeadersConfigFromEnv() (SecurityHeadersConfig, bool) {
+	if !parser.ParseEnvBool("SECURITY_HEADERS_ENABLED", true) {
+		return SecurityHeadersConfig{}, false
+	}
+
+	defaults := DefaultSecurityHeadersConfig()
+
+	hstsMaxAge, err := parser.ParseEnvInt("HSTS_MAX_AGE", int(defaults.HSTSMaxAge.Seconds()))
+	if err != nil {
+		panic(err)
+	}
+
+	return SecurityHeadersConfig{
+		HSTSMaxAge:            time.Duration(hstsMaxAge) * time.Second,
+		HSTSIncludeSubdomains: parser.ParseEnvBool("HSTS_INCLUDE_SUBDOMAINS", defaults.HSTSIncludeSubdomains),
+		HSTSPreload:           parser.ParseEnvBool("HSTS_PRELOAD", defaults.HSTSPreload),
+		ContentSecurityPolicy: parser.ParseEnvString("CSP", defaults.ContentSecurityPolicy),
+		CSPReportOnly:         parser.ParseEnvBool("CSP_REPORT_ONLY", defaults.CSPReportOnly),
+		CSPReportPath:         parser.ParseEnvString("CSP_REPORT_PATH", defaults.CSPReportPath),
+		FrameOptions:          parser.ParseEnvString("FRAME_OPTIONS", defaults.FrameOptions),
+		ReferrerPolicy:        parser.ParseEnvString("REFERRER_POLICY", defaults.ReferrerPolicy),
+		PermissionsPolicy:     parser.ParseEnvString("PERMISSIONS_POLICY", defaults.PermissionsPolicy),
+	}, true
+}
+
+// NewSecurityHeadersMiddleware sets HSTS, Content-Security-Policy, X-Content-Type-Options, X-Frame-Options,
+// Referrer-Policy and Permissions-Policy headers on every response. When the policy uses CSPNonceKey, a nonce is
+// generated for each request and can be read with CSPNonce.
+func NewSecurityHeadersMiddleware(config SecurityHeadersConfig) func(next http.Handler) http.Handler {
+	static := http.Header{}
+	static.Set("X-Content-Type-Options", "nosniff")
+
+	if config.HSTSMaxAge > 0 {
+		hsts := "max-age=" + strconv.Itoa(int(config.HSTSMaxAge.Seconds()))
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+
+		static.Set("Strict-Transport-Security", hsts)
+	}
+
+	if config.FrameOptions != "" {
+		static.Set("X-Frame-Options", config.FrameOptions)
+	}
+
+	if config.ReferrerPolicy != "" {
+		static.Set("Referrer-Policy", config.ReferrerPolicy)
+	}
+
+	if config.PermissionsPolicy != "" {
+		static.Set("Permissions-Policy", config.PermissionsPolicy)
+	}
+
+	csp := config.ContentSecurityPolicy
+	if csp != "" && config.CSPReportPath != "" {
+		csp += "; report-uri " + config.CSPReportPath + "; report-to " + cspReportEndpoint
+		static.Set("Reporting-Endpoints", cspReportEndpoint+`="`+config.CSPReportPath+`"`)
+	}
+
+	cspHeader := "Content-Security-Policy"
+	if config.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+
+	usesNonce := strings.Contains(csp, CSPNonceKey)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for header, values := range static {
+				w.Header()[header] = values
+			}
+
+			if usesNonce {
+				nonce := newCSPNonce()
+				w.Header().Set(cspHeader, strings.ReplaceAll(csp, CSPNonceKey, "'nonce-"+nonce+"'"))
+				r = r.WithContext(context.WithValue(r.Context(), cspNonceContextKey, nonce))
+			} else if csp != "" {
+				w.Header().Set(cspHeader, csp)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSPNonce returns the Content-Security-Policy nonce for the request, or an empty string if there isn't one.
+// Use it in the nonce attribute of inline scripts and styles.
+func CSPNonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(cspNonceContextKey).(string)
+
+	return nonce
+}
+
+func newCSPNonce() string {
+	b := make([]byte, cspNonceBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// CSPViolation is a Content-Security-Policy violation reported by a browser.
+type CSPViolation struct {
+	DocumentURL        string `json:"documentURL"`
+	BlockedURL         string `json:"blockedURL"`
+	EffectiveDirective string `json:"effectiveDirective"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"sourceFile"`
+	LineNumber         int    `json:"lineNumber"`
+	Sample             string `json:"sample"`
+}
+
+// legacyCSPReport is the application/csp-report format sent for the report-uri directive.
+type legacyCSPReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		BlockedURI         string `json:"blocked-uri"`
+		EffectiveDirective string `json:"effective-directive"`
+		ViolatedDirective  string `json:"violated-directive"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ScriptSample       string `json:"script-sample"`
+	} `json:"csp-report"`
+}
+
+// report is an entry in the application/reports+json format sent for the report-to directive.
+type report struct {
+	Type string       `json:"type"`
+	Body CSPViolation `json:"body"`
+}
+
+// CSPReportHandler logs the CSP violation reports sent by browsers as warnings. It accepts both the
+// application/csp-report and application/reports+json formats and always responds with 204 No Content.
+func CSPReportHandler(w http.ResponseWriter, r *http.Request) {
+	violations, err := parseCSPReport(r.Header.Get(ContentTypeHeader), http.MaxBytesReader(w, r.Body, maxCSPReportSize))
+	if err != nil {
+		slog.DebugContext(r.Context(), "invalid csp report", "error", err)
+	}
+
+	for _, violation := range violations {
+		slog.WarnContext(
+			r.Context(),
+			"csp violation",
+			"document_url", violation.DocumentURL,
+			"blocked_url", violation.BlockedURL,
+			"directive", violation.EffectiveDirective,
+			"disposition", violation.Disposition,
+			"source_file", violation.SourceFile,
+			"line_number", violation.LineNumber,
+			"sample", violation.Sample,
+		)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseCSPReport(contentType string, body io.Reader) ([]CSPViolation, error) {
+	if strings.HasPrefix(contentType, "application/reports+json") {
+		var reports []report
+		if err := json.NewDecoder(body).Decode(&reports); err != nil {
+			return nil, err
+		}
+
+		var violations []CSPViolation
+
+		for _, report := range reports {
+			if report.Type == "csp-violation" {
+				violations = append(violations, report.Body)
+			}
+		}
+
+		return violations, nil
+	}
+
+	var legacy legacyCSPReport
+	if err := json.NewDecoder(body).Decode(&legacy); err != nil {
+		return nil, err
+	}
+
+	directive := legacy.Report.EffectiveDirective
+	if directive == "" {
+		directive = legacy.Report.ViolatedDirective
+	}
+
+	return []CSPViolation{{
+		DocumentURL:        legacy.Report.DocumentURI,
+		BlockedURL:         legacy.Report.BlockedURI,
+		EffectiveDirective: directive,
+		Disposition:        legacy.Report.Disposition,
+		SourceFile:         legacy.Report.SourceFile,
+		LineNumber:         legacy.Report.LineNumber,
+		Sample:             legacy.Report.ScriptSample,
+	}}, nil
+}
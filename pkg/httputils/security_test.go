@@ -0,0 +1,167 @@
+package httputils_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var nonce string
+
+	handler := httputils.NewSecurityHeadersMiddleware(httputils.DefaultSecurityHeadersConfig())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce = httputils.CSPNonce(r.Context())
+
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expectedHeaders := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Permissions-Policy":        "camera=(), microphone=(), geolocation=()",
+		"Reporting-Endpoints":       `csp-endpoint="/csp-report"`,
+	}
+
+	for header, expected := range expectedHeaders {
+		if got := rr.Header().Get(header); got != expected {
+			t.Errorf("expected %s %q, got %q", header, expected, got)
+		}
+	}
+
+	if nonce == "" {
+		t.Fatal("expected a nonce")
+	}
+
+	csp := rr.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "script-src 'self' 'nonce-"+nonce+"'") || !strings.Contains(csp, "report-uri /csp-report") {
+		t.Errorf("unexpected policy %q", csp)
+	}
+
+	firstNonce := nonce
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if nonce == firstNonce {
+		t.Error("expected a new nonce for each request")
+	}
+}
+
+func TestSecurityHeadersMiddleware_ReportOnly(t *testing.T) {
+	t.Parallel()
+
+	handler := httputils.NewSecurityHeadersMiddleware(httputils.SecurityHeadersConfig{
+		ContentSecurityPolicy: "default-src 'self'",
+		CSPReportOnly:         true,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if httputils.CSPNonce(r.Context()) != "" {
+			t.Error("expected no nonce when the policy doesn't use one")
+		}
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rr.Header().Get("Content-Security-Policy-Report-Only"); got != "default-src 'self'" {
+		t.Errorf("unexpected policy %q", got)
+	}
+
+	for _, header := range []string{"Content-Security-Policy", "Strict-Transport-Security", "X-Frame-Options"} {
+		if rr.Header().Get(header) != "" {
+			t.Errorf("expected %s not to be set", header)
+		}
+	}
+}
+
+func TestSecurityHeadersConfigFromEnv(t *testing.T) {
+	t.Setenv("HSTS_MAX_AGE", "60")
+	t.Setenv("HSTS_PRELOAD", "true")
+
+	config, ok := httputils.SecurityHeadersConfigFromEnv()
+	if !ok {
+		t.Fatal("expected security headers to be enabled")
+	}
+
+	if config.HSTSMaxAge != time.Minute || !config.HSTSPreload || config.ReferrerPolicy != "strict-origin-when-cross-origin" {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	t.Setenv("SECURITY_HEADERS_ENABLED", "false")
+
+	if _, ok := httputils.SecurityHeadersConfigFromEnv(); ok {
+		t.Error("expected security headers to be disabled")
+	}
+}
+
+func TestCSPReportHandler(t *testing.T) {
+	var logs bytes.Buffer
+
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	defer slog.SetDefault(defaultLogger)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "report-uri",
+			contentType: "application/csp-report",
+			body:        `{"csp-report":{"document-uri":"https://example.com/","blocked-uri":"inline","violated-directive":"script-src-elem"}}`,
+			expected:    `"directive":"script-src-elem"`,
+		},
+		{
+			name:        "report-to",
+			contentType: "application/reports+json",
+			body:        `[{"type":"csp-violation","body":{"documentURL":"https://example.com/","blockedURL":"https://evil.com/x.js","effectiveDirective":"script-src-elem"}}]`,
+			expected:    `"blocked_url":"https://evil.com/x.js"`,
+		},
+		{
+			name:        "invalid report",
+			contentType: "application/csp-report",
+			body:        `nope`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+
+			r := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/csp-report", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			rr := httptest.NewRecorder()
+			httputils.CSPReportHandler(rr, r)
+
+			if rr.Code != http.StatusNoContent {
+				t.Errorf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+			}
+
+			if tt.expected == "" && logs.Len() > 0 {
+				t.Errorf("expected no violation to be logged, got %s", logs.String())
+			}
+
+			if !strings.Contains(logs.String(), tt.expected) {
+				t.Errorf("expected %s to be logged, got %s", tt.expected, logs.String())
+			}
+		})
+	}
+}
@@ -23,35 +23,89 @@ const writeTimeout = 10 * time.Second
 
 const shutdownTimeout = 5 * time.Second
 
-func ServeHTTP(handler http.Handler, logger *slog.Logger) error {
+// ServerOption configures ServeHTTP.
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	onShutdownSignal []func()
+	onShutdown       []func()
+	tls              *TLSConfig
+}
+
+// WithOnShutdownSignal calls f as soon as SIGINT or SIGTERM is received, before SHUTDOWN_DRAIN_DELAY, so that
+// readiness checks can fail while the server keeps serving requests.
+func WithOnShutdownSignal(f func()) ServerOption {
+	return func(options *serverOptions) {
+		options.onShutdownSignal = append(options.onShutdownSignal, f)
+	}
+}
+
+// WithOnShutdown calls f when the server stops accepting connections so that long-lived connections, which the
+// server doesn't wait for, can be closed.
+func WithOnShutdown(f func()) ServerOption {
+	return func(options *serverOptions) {
+		options.onShutdown = append(options.onShutdown, f)
+	}
+}
+
+// WithTLS serves HTTPS with the certificate in config.
+func WithTLS(config TLSConfig) ServerOption {
+	return func(options *serverOptions) {
+		options.tls = &config
+	}
+}
+
+// ServeHTTP starts the server and shuts it down gracefully on SIGINT or SIGTERM. The server keeps accepting
+// connections for SHUTDOWN_DRAIN_DELAY seconds after the signal, which gives load balancers time to notice that
+// readiness is failing, and then waits for in-flight requests to finish.
+func ServeHTTP(handler http.Handler, logger *slog.Logger, opts ...ServerOption) error {
+	var options serverOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	port, err := parser.ParseEnvInt("SERVER_PORT", defaultPort)
 	if err != nil {
 		return fmt.Errorf("invalid server port: %w", err)
 	}
 
-	//nolint: exhaustruct
-	tlsConfig := &tls.Config{
-		MinVersion:       tls.VersionTLS13,
-		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+	drainDelay, err := parser.ParseEnvInt("SHUTDOWN_DRAIN_DELAY", 0)
+	if err != nil {
+		return fmt.Errorf("invalid shutdown drain delay: %w", err)
 	}
 
 	//nolint: exhaustruct
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", port),
 		Handler:           handler,
-		TLSConfig:         tlsConfig,
 		IdleTimeout:       time.Minute,
 		ReadHeaderTimeout: readTimeout,
 		WriteTimeout:      writeTimeout,
 		ErrorLog:          NewSlogErrorWriter(logger),
 	}
 
+	for _, f := range options.onShutdown {
+		server.RegisterOnShutdown(f)
+	}
+
+	if options.tls != nil {
+		if err := configureTLS(server, logger, *options.tls, port); err != nil {
+			return err
+		}
+	}
+
 	shutdownError := make(chan error)
-	go gracefulShutdown(server, logger, shutdownError)
+	go gracefulShutdown(server, logger, options.onShutdownSignal, time.Duration(drainDelay)*time.Second, shutdownError)
 
 	slog.Info("server started", "port", port)
 
-	serverErr := startServer(server)
+	var serverErr error
+	if server.TLSConfig != nil {
+		// the certificate comes from TLSConfig.GetCertificate
+		serverErr = server.ListenAndServeTLS("", "")
+	} else {
+		serverErr = server.ListenAndServe()
+	}
 
 	if serverErr != nil && !errors.Is(serverErr, http.ErrServerClosed) {
 		return fmt.Errorf("server error %w", serverErr)
@@ -66,12 +120,24 @@ func ServeHTTP(handler http.Handler, logger *slog.Logger) error {
 	return nil
 }
 
-func gracefulShutdown(server *http.Server, logger *slog.Logger, shutdownErr chan<- error) {
+func gracefulShutdown(
+	server *http.Server,
+	logger *slog.Logger,
+	onShutdownSignal []func(),
+	drainDelay time.Duration,
+	shutdownErr chan<- error,
+) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	s := <-quit
 
-	logger.Info("shutting down server", "signal", s.String())
+	logger.Info("shutting down server", "signal", s.String(), "drain_delay", drainDelay.String())
+
+	for _, f := range onShutdownSignal {
+		f()
+	}
+
+	time.Sleep(drainDelay)
 
 	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
@@ -79,12 +145,51 @@ func gracefulShutdown(server *http.Server, logger *slog.Logger, shutdownErr chan
 	shutdownErr <- server.Shutdown(ctx)
 }
 
-func startServer(server *http.Server) error {
-	if _, err := os.Stat("./tls/cert.pem"); err == nil {
-		//nolint: wrapcheck
-		return server.ListenAndServeTLS("./tls/cert.pem", "./tls/key.pem")
+// configureTLS serves HTTPS from server with a certificate that is reloaded when its files change, and starts the
+// HTTP redirect listener. Both stop when server shuts down.
+func configureTLS(server *http.Server, logger *slog.Logger, config TLSConfig, port int) error {
+	reloader, err := NewCertificateReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return err
 	}
 
-	//nolint: wrapcheck
-	return server.ListenAndServe()
+	//nolint: exhaustruct
+	server.TLSConfig = &tls.Config{
+		MinVersion:       tls.VersionTLS13,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		GetCertificate:   reloader.GetCertificate,
+	}
+
+	if config.ReloadInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		server.RegisterOnShutdown(cancel)
+
+		go reloader.Watch(ctx, config.ReloadInterval)
+	}
+
+	if config.RedirectPort > 0 {
+		//nolint: exhaustruct
+		redirectServer := &http.Server{
+			Addr:              fmt.Sprintf(":%d", config.RedirectPort),
+			Handler:           NewHTTPSRedirectHandler(port),
+			ReadHeaderTimeout: readTimeout,
+			WriteTimeout:      writeTimeout,
+			ErrorLog:          NewSlogErrorWriter(logger),
+		}
+
+		server.RegisterOnShutdown(func() {
+			_ = redirectServer.Close()
+		})
+
+		go func() {
+			err := redirectServer.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("https redirect server error", "error", err)
+			}
+		}()
+
+		logger.Info("https redirect server started", "port", config.RedirectPort)
+	}
+
+	return nil
 }
@@ -0,0 +1,175 @@
+package httputils
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+const (
+	defaultCertFile = "./tls/cert.pem"
+	defaultKeyFile  = "./tls/key.pem"
+)
+
+const defaultCertReloadInterval = time.Minute
+
+const httpsPort = 443
+
+// ErrCertificateNotFound is returned when TLS_CERT_FILE is set to a file that doesn't exist.
+var ErrCertificateNotFound = errors.New("tls certificate not found")
+
+// TLSConfig configures HTTPS.
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM encoded files with the certificate chain and private key.
+	CertFile string
+	KeyFile  string
+	// ReloadInterval is how often the files are checked for changes, so that renewed certificates are served
+	// without a restart. Zero disables reloading.
+	ReloadInterval time.Duration
+	// RedirectPort is the port of a plain HTTP listener that redirects requests to HTTPS. Zero disables it.
+	RedirectPort int
+}
+
+// TLSConfigFromEnv returns the HTTPS configuration set by the TLS_* env vars. ok is false when there is no
+// certificate at TLS_CERT_FILE, which defaults to ./tls/cert.pem.
+func TLSConfigFromEnv() (TLSConfig, bool) {
+	certFile := parser.ParseEnvString("TLS_CERT_FILE", "")
+
+	if _, err := os.Stat(cmp.Or(certFile, defaultCertFile)); err != nil {
+		if certFile != "" {
+			panic(fmt.Errorf("%w: %s", ErrCertificateNotFound, certFile))
+		}
+
+		return TLSConfig{}, false
+	}
+
+	reloadInterval, err := parser.ParseEnvInt("TLS_RELOAD_INTERVAL", int(defaultCertReloadInterval.Seconds()))
+	if err != nil {
+		panic(err)
+	}
+
+	redirectPort, err := parser.ParseEnvInt("TLS_REDIRECT_PORT", 0)
+	if err != nil {
+		panic(err)
+	}
+
+	return TLSConfig{
+		CertFile:       cmp.Or(certFile, defaultCertFile),
+		KeyFile:        parser.ParseEnvString("TLS_KEY_FILE", defaultKeyFile),
+		ReloadInterval: time.Duration(reloadInterval) * time.Second,
+		RedirectPort:   redirectPort,
+	}, true
+}
+
+// CertificateReloader serves the certificate in a pair of files and reloads it when the files change.
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.RWMutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// NewCertificateReloader loads the certificate in certFile and keyFile.
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	//nolint: exhaustruct
+	reloader := &CertificateReloader{certFile: certFile, keyFile: keyFile}
+
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// GetCertificate returns the current certificate. Use it as tls.Config.GetCertificate.
+func (r *CertificateReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Reload loads the certificate if either file changed since it was last loaded. The current certificate is kept
+// if the files can't be loaded, e.g. when only one of them has been replaced so far.
+func (r *CertificateReloader) Reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat certificate: %w", err)
+	}
+
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat private key: %w", err)
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && certInfo.ModTime().Equal(r.certModTime) && keyInfo.ModTime().Equal(r.keyModTime)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+
+	return nil
+}
+
+// Watch calls Reload every interval until ctx is done.
+func (r *CertificateReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(); err != nil {
+				slog.Error("failed to reload tls certificate", "error", err)
+			}
+		}
+	}
+}
+
+// NewHTTPSRedirectHandler redirects requests to the same URL over HTTPS on port.
+func NewHTTPSRedirectHandler(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			// the host doesn't have a port
+			host = r.Host
+		}
+
+		if port != httpsPort {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+
+		// 308 keeps the method and body of the request
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,191 @@
+package httputils_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/httputils"
+)
+
+// writeCertificate writes a self-signed certificate with the given serial number and its key to dir.
+func writeCertificate(t *testing.T, dir string, serial int64, modTime time.Time) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//nolint: exhaustruct
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	files := map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	}
+
+	for file, block := range files {
+		if err := os.WriteFile(file, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return certFile, keyFile
+}
+
+func servedSerial(t *testing.T, reloader *httputils.CertificateReloader) int64 {
+	t.Helper()
+
+	cert, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf.SerialNumber.Int64()
+}
+
+func TestCertificateReloader(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+
+	certFile, keyFile := writeCertificate(t, dir, 1, now.Add(-time.Minute))
+
+	reloader, err := httputils.NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serial := servedSerial(t, reloader); serial != 1 {
+		t.Fatalf("expected certificate 1, got %d", serial)
+	}
+
+	writeCertificate(t, dir, 2, now)
+
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if serial := servedSerial(t, reloader); serial != 2 {
+		t.Errorf("expected the renewed certificate to be served, got %d", serial)
+	}
+
+	// a renewal that has only replaced the key so far
+	if err := os.WriteFile(keyFile, []byte("invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(keyFile, now.Add(time.Minute), now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reloader.Reload(); err == nil {
+		t.Error("expected an invalid key to fail")
+	}
+
+	if serial := servedSerial(t, reloader); serial != 2 {
+		t.Errorf("expected the current certificate to be kept, got %d", serial)
+	}
+}
+
+func TestNewCertificateReloaderMissingFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_, err := httputils.NewCertificateReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	if err == nil {
+		t.Error("expected missing files to fail")
+	}
+}
+
+func TestHTTPSRedirectHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		port     int
+		location string
+	}{
+		{
+			name:     "default port",
+			method:   http.MethodGet,
+			target:   "http://example.com/users?page=2",
+			port:     443,
+			location: "https://example.com/users?page=2",
+		},
+		{
+			name:     "custom port",
+			method:   http.MethodGet,
+			target:   "http://example.com:8081/users",
+			port:     8080,
+			location: "https://example.com:8080/users",
+		},
+		{
+			name:     "post",
+			method:   http.MethodPost,
+			target:   "http://example.com/users",
+			port:     443,
+			location: "https://example.com/users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			httputils.NewHTTPSRedirectHandler(tt.port).ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != http.StatusPermanentRedirect {
+				t.Errorf("expected 308, got %d", w.Code)
+			}
+
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("expected %s, got %s", tt.location, location)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package httputils
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gurch101/gowebutils/pkg/tracing"
+)
+
+// TracingMiddleware records a server span for each request with the default tracer. The span continues the trace
+// in the traceparent header of the request, if any, and is named after the chi route pattern once the request
+// is routed. Requests pass through untouched when tracing is disabled.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tracing.Default() == nil {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		ctx, span := tracing.Start(tracing.Extract(r.Context(), r.Header), r.Method,
+			tracing.WithKind(tracing.SpanKindServer),
+			tracing.WithAttributes(
+				slog.String("http.request.method", r.Method),
+				slog.String("url.path", r.URL.Path),
+				slog.String("client.address", r.RemoteAddr),
+			))
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			route := routePattern(r)
+			if route != unmatchedRoute {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(slog.String("http.route", route))
+			}
+
+			status := responseStatus(ww)
+			span.SetAttributes(slog.Int("http.response.status_code", status))
+
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(tracing.StatusError, http.StatusText(status))
+			}
+		}()
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+	})
+}
+
+// routePattern returns the chi route pattern that matched r, or unmatchedRoute.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+
+	return unmatchedRoute
+}
+
+// responseStatus returns the status code written to ww.
+func responseStatus(ww middleware.WrapResponseWriter) int {
+	if status := ww.Status(); status != 0 {
+		return status
+	}
+
+	// the handler didn't write a response or hijacked the connection
+	return http.StatusOK
+}
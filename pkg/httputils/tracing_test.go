@@ -0,0 +1,78 @@
+package httputils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+	"github.com/gurch101/gowebutils/pkg/tracing"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := testutils.SetupTestTracer(t)
+
+	var handlerSpan tracing.SpanContext
+
+	router := chi.NewRouter()
+	router.Use(httputils.TracingMiddleware)
+	router.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan, _ = tracing.SpanContextFromContext(r.Context())
+
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	spans := recorder.SpansNamed("GET /users/{id}")
+	if len(spans) != 1 {
+		t.Fatalf("expected a span named after the route, got %v", recorder.Spans())
+	}
+
+	span := spans[0]
+
+	if span.Kind != tracing.SpanKindServer {
+		t.Errorf("expected a server span, got %v", span.Kind)
+	}
+
+	if span.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		span.ParentSpanID.String() != "00f067aa0ba902b7" {
+		t.Errorf("expected the trace in the traceparent header to be continued, got %+v", span.SpanContext)
+	}
+
+	if handlerSpan.SpanID != span.SpanContext.SpanID {
+		t.Errorf("expected the handler context to carry the server span")
+	}
+
+	if span.Status.Code != tracing.StatusError {
+		t.Errorf("expected a 500 to fail the span, got %v", span.Status)
+	}
+
+	if unmatched := recorder.SpansNamed(http.MethodGet); len(unmatched) != 1 {
+		t.Errorf("expected unmatched requests to be named after the method, got %v", recorder.Spans())
+	}
+}
+
+func TestTracingMiddlewareDisabled(t *testing.T) {
+	t.Parallel()
+
+	var traced bool
+
+	handler := httputils.TracingMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, traced = tracing.SpanContextFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if traced {
+		t.Error("expected requests to pass through when tracing is disabled")
+	}
+}
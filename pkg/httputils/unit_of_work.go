@@ -0,0 +1,57 @@
+package httputils
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+)
+
+// UnitOfWorkMiddleware runs each request inside a single transaction. The transaction is
+// stored in the request context so that dbutils helpers and QueryBuilder join it automatically.
+// The response is buffered and the transaction is committed if the handler responds with a
+// 2xx or 3xx status. It is rolled back on error responses and panics.
+//
+// Handlers must use the request context for all queries while the unit of work is open;
+// the write pool has a single connection, so bypassing the context will block.
+func UnitOfWorkMiddleware(db *dbutils.DBPool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uow, ctx, err := dbutils.BeginUnitOfWork(r.Context(), db)
+			if err != nil {
+				ServerErrorResponse(w, r, err)
+
+				return
+			}
+
+			buffer := NewBufferedResponseWriter(w)
+
+			defer func() {
+				if rec := recover(); rec != nil {
+					rollbackUnitOfWork(r, uow)
+					panic(rec)
+				}
+			}()
+
+			next.ServeHTTP(buffer, r.WithContext(ctx))
+
+			if buffer.Status() >= http.StatusBadRequest {
+				rollbackUnitOfWork(r, uow)
+			} else if err := uow.Commit(); err != nil {
+				ServerErrorResponse(w, r, err)
+
+				return
+			}
+
+			if err := buffer.Send(); err != nil {
+				slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+			}
+		})
+	}
+}
+
+func rollbackUnitOfWork(r *http.Request, uow *dbutils.UnitOfWork) {
+	if err := uow.Rollback(); err != nil {
+		slog.ErrorContext(r.Context(), "db error", "message", err)
+	}
+}
@@ -0,0 +1,140 @@
+package httputils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestUnitOfWorkMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		status          int
+		shouldPanic     bool
+		expectCommitted bool
+	}{
+		{name: "commits on 2xx", status: http.StatusCreated, expectCommitted: true},
+		{name: "commits on 3xx", status: http.StatusSeeOther, expectCommitted: true},
+		{name: "rolls back on 4xx", status: http.StatusUnprocessableEntity},
+		{name: "rolls back on 5xx", status: http.StatusInternalServerError},
+		{name: "rolls back on panic", shouldPanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := dbutils.FromDB(testutils.SetupTestDB(t))
+			defer db.Close()
+
+			afterCommitCalled := false
+
+			handler := httputils.UnitOfWorkMiddleware(db)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := r.Context()
+
+				id, err := dbutils.Insert(ctx, db, "tenants", map[string]any{
+					"tenant_name":   "UoW Tenant",
+					"contact_email": "uow@example.com",
+					"plan":          "free",
+				})
+				if err != nil {
+					t.Fatalf("Failed to insert tenant: %v", err)
+				}
+
+				// reads made with the request context see the uncommitted insert
+				if !dbutils.Exists(ctx, db, "tenants", *id) {
+					t.Errorf("Expected inserted tenant to be visible within the unit of work")
+				}
+
+				dbutils.AfterCommit(ctx, func() {
+					afterCommitCalled = true
+				})
+
+				if tt.shouldPanic {
+					panic("handler failed")
+				}
+
+				w.WriteHeader(tt.status)
+			}))
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/tenants", nil)
+
+			func() {
+				defer func() {
+					if rec := recover(); rec == nil && tt.shouldPanic {
+						t.Errorf("Expected panic to be re-raised")
+					}
+				}()
+
+				handler.ServeHTTP(rr, req)
+			}()
+
+			if !tt.shouldPanic && rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			committed := dbutils.ExistsBy(context.Background(), db, "tenants", map[string]any{"tenant_name": "UoW Tenant"})
+			if committed != tt.expectCommitted {
+				t.Errorf("Expected committed to be %v, got %v", tt.expectCommitted, committed)
+			}
+
+			if afterCommitCalled != tt.expectCommitted {
+				t.Errorf("Expected after commit callback called to be %v, got %v", tt.expectCommitted, afterCommitCalled)
+			}
+		})
+	}
+}
+
+func TestUnitOfWorkMiddleware_NestedTransaction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		db   func(pool *dbutils.DBPool) dbutils.DB
+	}{
+		{name: "pool", db: func(pool *dbutils.DBPool) dbutils.DB { return pool }},
+		{name: "write pool", db: func(pool *dbutils.DBPool) dbutils.DB { return pool.WriteDB() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := dbutils.FromDB(testutils.SetupTestDB(t))
+			defer db.Close()
+
+			handler := httputils.UnitOfWorkMiddleware(db)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				err := dbutils.WithTransaction(r.Context(), tt.db(db), func(tx dbutils.DB) error {
+					return dbutils.UpdateByID(r.Context(), tx, "tenants", 1, 1, map[string]any{"tenant_name": "Nested"})
+				})
+				if err != nil {
+					t.Fatalf("Failed to update tenant: %v", err)
+				}
+
+				w.WriteHeader(http.StatusBadRequest)
+			}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/tenants/1", nil))
+
+			var name string
+
+			err := dbutils.GetByID(context.Background(), db, "tenants", 1, map[string]any{"tenant_name": &name})
+			if err != nil {
+				t.Fatalf("Failed to get tenant: %v", err)
+			}
+
+			if name != "Acme" {
+				t.Errorf("Expected nested transaction to be rolled back with the unit of work, got '%s'", name)
+			}
+		})
+	}
+}
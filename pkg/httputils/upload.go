@@ -0,0 +1,295 @@
+package httputils
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
+)
+
+// Codes of the errors returned by StreamUploads.
+const (
+	CodeUnsupportedFileType = "unsupported_file_type"
+	CodeTooManyFiles        = "too_many_files"
+)
+
+const (
+	defaultMaxUploadFiles = 10
+	sniffLength           = 512
+	uploadNameBytes       = 16
+)
+
+var errFileTooLarge = errors.New("file too large")
+
+// UploadConfig configures StreamUploads and NewUploadHandler.
+type UploadConfig struct {
+	// MaxBodyBytes is the maximum size of the request body. Defaults to 32MB.
+	MaxBodyBytes int64
+	// MaxFileBytes is the maximum size of each file. Defaults to 10MB.
+	MaxFileBytes int64
+	// MaxFiles is the maximum number of files in a request. Defaults to 10.
+	MaxFiles int
+	// AllowedContentTypes are the content types files may have, e.g. image/png or image/*. The content type is
+	// detected from the contents of the file, not from the name or the type sent by the client. Every type is
+	// allowed if empty.
+	AllowedContentTypes []string
+	// FileName returns the name a file is stored under. Defaults to a random name with the extension of the
+	// uploaded file.
+	FileName func(r *http.Request, part *multipart.Part) string
+}
+
+// UploadedFile describes a file stored by StreamUploads.
+type UploadedFile struct {
+	Field       string `json:"field"`
+	FileName    string `json:"fileName"`
+	Name        string `json:"name"`
+	Location    string `json:"location"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+// StreamUploads streams every file of a multipart/form-data request to files without buffering them in memory
+// or on disk. Other fields are skipped. If a file is rejected, the files stored by the request are deleted and
+// a FormError is returned.
+func StreamUploads(
+	w http.ResponseWriter,
+	r *http.Request,
+	files fsutils.FileService,
+	config UploadConfig,
+) ([]UploadedFile, error) {
+	config = withUploadDefaults(config)
+	r.Body = http.MaxBytesReader(w, r.Body, config.MaxBodyBytes)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, handleParseFormError(err, config.MaxBodyBytes)
+	}
+
+	var uploaded []UploadedFile
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return uploaded, nil
+		}
+
+		if err != nil {
+			deleteUploads(r, files, uploaded)
+
+			return nil, handleParseFormError(err, config.MaxBodyBytes)
+		}
+
+		if part.FileName() == "" {
+			continue
+		}
+
+		if len(uploaded) == config.MaxFiles {
+			deleteUploads(r, files, uploaded)
+
+			return nil, &FormError{
+				Field:   part.FormName(),
+				Code:    CodeTooManyFiles,
+				Message: fmt.Sprintf("body must not contain more than %d files", config.MaxFiles),
+			}
+		}
+
+		file, err := streamUpload(r, part, files, config)
+		if err != nil {
+			deleteUploads(r, files, uploaded)
+
+			return nil, err
+		}
+
+		uploaded = append(uploaded, file)
+	}
+}
+
+// NewUploadHandler returns a handler that stores the files of a multipart/form-data request with StreamUploads and
+// responds with the stored files.
+func NewUploadHandler(files fsutils.FileService, config UploadConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uploaded, err := StreamUploads(w, r, files, config)
+		if err != nil {
+			HandleErrorResponse(w, r, err)
+
+			return
+		}
+
+		if err := WriteJSON(w, http.StatusCreated, map[string]any{"files": uploaded}, nil); err != nil {
+			ServerErrorResponse(w, r, err)
+		}
+	}
+}
+
+func withUploadDefaults(config UploadConfig) UploadConfig {
+	if config.MaxBodyBytes == 0 {
+		config.MaxBodyBytes = defaultMaxMultipartBytes
+	}
+
+	if config.MaxFileBytes == 0 {
+		config.MaxFileBytes = defaultMaxFileBytes
+	}
+
+	if config.MaxFiles == 0 {
+		config.MaxFiles = defaultMaxUploadFiles
+	}
+
+	if config.FileName == nil {
+		config.FileName = randomUploadName
+	}
+
+	return config
+}
+
+func streamUpload(
+	r *http.Request,
+	part *multipart.Part,
+	files fsutils.FileService,
+	config UploadConfig,
+) (UploadedFile, error) {
+	field := part.FormName()
+	body := bufio.NewReaderSize(part, sniffLength)
+
+	head, err := body.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return UploadedFile{}, handleParseFormError(err, config.MaxBodyBytes)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !contentTypeAllowed(contentType, config.AllowedContentTypes) {
+		return UploadedFile{}, &FormError{
+			Field:   field,
+			Code:    CodeUnsupportedFileType,
+			Message: fmt.Sprintf("file %q must be one of %s", part.FileName(), strings.Join(config.AllowedContentTypes, ", ")),
+		}
+	}
+
+	limited := &limitedFileReader{reader: body, remaining: config.MaxFileBytes}
+	name := config.FileName(r, part)
+
+	location, err := files.UploadFileContext(r.Context(), name, limited)
+
+	switch {
+	case limited.exceeded:
+		// the store may have kept what it read before the limit was hit
+		deleteUploads(r, files, []UploadedFile{{Name: name}})
+
+		return UploadedFile{}, &FormError{
+			Field:   field,
+			Code:    CodeFileTooLarge,
+			Message: fmt.Sprintf("file %q must not be larger than %d bytes", part.FileName(), config.MaxFileBytes),
+		}
+	case err != nil:
+		return UploadedFile{}, fmt.Errorf("failed to upload %s: %w", part.FileName(), err)
+	}
+
+	return UploadedFile{
+		Field:       field,
+		FileName:    part.FileName(),
+		Name:        name,
+		Location:    location,
+		ContentType: contentType,
+		Size:        config.MaxFileBytes - limited.remaining,
+	}, nil
+}
+
+// contentTypeAllowed reports whether contentType matches one of allowed. Wildcards such as image/* match every
+// subtype.
+func contentTypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, allowedType := range allowed {
+		if allowedType == contentType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowedType, "*"); ok && strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func deleteUploads(r *http.Request, files fsutils.FileService, uploaded []UploadedFile) {
+	if len(uploaded) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(uploaded))
+	for _, file := range uploaded {
+		names = append(names, file.Name)
+	}
+
+	if err := files.DeleteFilesContext(context.WithoutCancel(r.Context()), names); err != nil {
+		logError(r, fmt.Errorf("failed to delete rejected uploads: %w", err))
+	}
+}
+
+func randomUploadName(_ *http.Request, part *multipart.Part) string {
+	b := make([]byte, uploadNameBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b) + uploadExtension(part.FileName())
+}
+
+// uploadExtension returns the lowercased extension of an uploaded file name, or an empty string if it has
+// characters other than letters and digits.
+func uploadExtension(fileName string) string {
+	ext := strings.ToLower(path.Ext(path.Base(fileName)))
+
+	for _, r := range strings.TrimPrefix(ext, ".") {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return ""
+		}
+	}
+
+	return ext
+}
+
+// limitedFileReader fails once more than remaining bytes have been read so that the upload is aborted.
+type limitedFileReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedFileReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		l.exceeded = true
+
+		return 0, errFileTooLarge
+	}
+
+	// read one byte past the limit to detect files that are too large
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+
+	if l.remaining < 0 {
+		l.exceeded = true
+
+		return 0, errFileTooLarge
+	}
+
+	//nolint: wrapcheck
+	return n, err
+}
@@ -0,0 +1,143 @@
+package httputils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+)
+
+func TestNewUploadHandler(t *testing.T) {
+	t.Parallel()
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("a", 8)
+
+	type file struct {
+		field    string
+		name     string
+		contents string
+	}
+
+	tests := []struct {
+		name           string
+		files          []file
+		expectedStatus int
+		expectedCode   string
+		expectedStored int
+	}{
+		{
+			name:           "valid files",
+			files:          []file{{"avatar", "me.PNG", png}, {"avatar", "me2.png", png}},
+			expectedStatus: http.StatusCreated,
+			expectedStored: 2,
+		},
+		{
+			name:           "disallowed content type",
+			files:          []file{{"avatar", "me.png", png}, {"avatar", "me.png", "plain text"}},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   httputils.CodeUnsupportedFileType,
+		},
+		{
+			name:           "file too large",
+			files:          []file{{"avatar", "me.png", png + strings.Repeat("a", 20)}},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   httputils.CodeFileTooLarge,
+		},
+		{
+			name:           "too many files",
+			files:          []file{{"a", "1.png", png}, {"a", "2.png", png}, {"a", "3.png", png}},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   httputils.CodeTooManyFiles,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body bytes.Buffer
+
+			writer := multipart.NewWriter(&body)
+
+			if err := writer.WriteField("description", "profile picture"); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, f := range tt.files {
+				part, err := writer.CreateFormFile(f.field, f.name)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := part.Write([]byte(f.contents)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/uploads", &body)
+			r.Header.Set("Content-Type", writer.FormDataContentType())
+
+			w := httptest.NewRecorder()
+			files := testutils.NewMockFileService()
+
+			//nolint: exhaustruct
+			httputils.NewUploadHandler(files, httputils.UploadConfig{
+				MaxFileBytes:        20,
+				MaxFiles:            2,
+				AllowedContentTypes: []string{"image/*"},
+			})(w, r)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				if !strings.Contains(w.Body.String(), tt.expectedCode) {
+					t.Errorf("expected code %s in %s", tt.expectedCode, w.Body.String())
+				}
+
+				// stored files are deleted when a request is rejected
+				for name := range files.UploadedFiles {
+					if !slices.Contains(files.DeletedFileNames, name) {
+						t.Errorf("expected %s to be deleted, got %v", name, files.DeletedFileNames)
+					}
+				}
+
+				return
+			}
+
+			var resp struct {
+				Files []httputils.UploadedFile `json:"files"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(resp.Files) != tt.expectedStored || len(files.UploadedFiles) != tt.expectedStored {
+				t.Fatalf("expected %d stored files, got %+v", tt.expectedStored, resp.Files)
+			}
+
+			for i, uploaded := range resp.Files {
+				if uploaded.FileName != tt.files[i].name || uploaded.ContentType != "image/png" ||
+					uploaded.Size != int64(len(tt.files[i].contents)) || !strings.HasSuffix(uploaded.Name, ".png") {
+					t.Errorf("unexpected uploaded file %+v", uploaded)
+				}
+
+				if string(files.UploadedFiles[uploaded.Name]) != tt.files[i].contents {
+					t.Errorf("expected stored contents %q, got %q", tt.files[i].contents, files.UploadedFiles[uploaded.Name])
+				}
+			}
+		})
+	}
+}
@@ -3,14 +3,18 @@ package mailutils
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
 	"log/slog"
 	"time"
 
+	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/metrics"
 	"github.com/gurch101/gowebutils/pkg/parser"
 	"github.com/gurch101/gowebutils/pkg/threads"
+	"github.com/gurch101/gowebutils/pkg/tracing"
 	"gopkg.in/gomail.v2"
 )
 
@@ -22,9 +26,18 @@ var ErrTemplateExecution = errors.New("error executing mail template")
 
 const retryInterval = 500 * time.Millisecond
 
+//nolint:gochecknoglobals
+var (
+	mailSent = metrics.Default.NewCounter("mail_sent_total",
+		"Number of emails sent by template.", "template")
+	mailSendFailures = metrics.Default.NewCounter("mail_send_failures_total",
+		"Number of emails that failed to send after retrying, by template.", "template")
+)
+
 // Mailer is an interface for sending emails.
 type Mailer interface {
 	Send(recipient, templateName string, data map[string]string)
+	SendContext(ctx context.Context, recipient, templateName string, data map[string]string)
 }
 
 type Dialer interface {
@@ -70,16 +83,54 @@ func InitMailerWithDialer(dialer Dialer, sender string, templates map[string]*te
 	}
 }
 
-// Send sends an email from a template using the provided data.
+// Send sends an email from a template using the provided data in the background.
 func (m *Emailer) Send(recipient, templateName string, data map[string]string) {
-	threads.Background(func() {
-		err := m.sendInternal(recipient, templateName, data)
-		if err != nil {
-			slog.Error("failed to send email", "error", err)
-		}
+	m.SendContext(context.Background(), recipient, templateName, data)
+}
+
+// SendContext is like Send but the send is traced as part of the trace in ctx. It isn't canceled with ctx.
+// If ctx carries a unit of work, the email is sent once it commits and isn't sent if it rolls back.
+func (m *Emailer) SendContext(ctx context.Context, recipient, templateName string, data map[string]string) {
+	ctx = context.WithoutCancel(ctx)
+
+	dbutils.AfterCommit(ctx, func() {
+		threads.Background(func() {
+			err := m.send(ctx, recipient, templateName, data)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to send email", "error", err)
+			}
+		})
+	})
+}
+
+// SendAfterCommit sends an email once the unit of work in ctx commits. The email is
+// not sent if the unit of work rolls back. Without a unit of work it is sent immediately.
+// Emailer.SendContext already does this; use it for Mailer implementations that don't.
+func SendAfterCommit(ctx context.Context, mailer Mailer, recipient, templateName string, data map[string]string) {
+	dbutils.AfterCommit(ctx, func() {
+		mailer.SendContext(ctx, recipient, templateName, data)
 	})
 }
 
+// send sends an email and records a span and metrics for it.
+func (m *Emailer) send(ctx context.Context, recipient, templateName string, data map[string]string) error {
+	_, span := tracing.Start(ctx, "mail.send", tracing.WithKind(tracing.SpanKindClient),
+		tracing.WithAttributes(slog.String("mail.template", templateName)))
+	defer span.End()
+
+	err := m.sendInternal(recipient, templateName, data)
+	if err != nil {
+		span.RecordError(err)
+		mailSendFailures.WithLabelValues(templateName).Inc()
+
+		return err
+	}
+
+	mailSent.WithLabelValues(templateName).Inc()
+
+	return nil
+}
+
 func (m *Emailer) sendInternal(recipient, templateName string, data map[string]string) error {
 	var err error
 
@@ -1,12 +1,16 @@
 package mailutils_test
 
 import (
+	"context"
 	"errors"
 	"html/template"
 	"strings"
 	"testing"
 
+	"github.com/gurch101/gowebutils/pkg/dbutils"
 	"github.com/gurch101/gowebutils/pkg/mailutils"
+	"github.com/gurch101/gowebutils/pkg/testutils"
+	"github.com/gurch101/gowebutils/pkg/tracing"
 )
 
 func TestEmailer_Send_Success(t *testing.T) {
@@ -63,3 +67,97 @@ func TestEmailer_Send_TemplateNotFound(t *testing.T) {
 		t.Errorf("Expected ErrTemplateNotFound, got %v", emailer.Error)
 	}
 }
+
+func TestEmailer_Send_Span(t *testing.T) {
+	recorder := testutils.SetupTestTracer(t)
+
+	emailer := mailutils.NewMockMailer(mailutils.WithEmailTemplateMap(map[string]*template.Template{}))
+
+	ctx, parent := tracing.Start(context.Background(), "request")
+	emailer.SendContext(ctx, "recipient@example.com", "nonExistentTemplate", map[string]string{})
+	parent.End()
+
+	spans := recorder.SpansNamed("mail.send")
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 mail.send span, got %v", recorder.Spans())
+	}
+
+	if spans[0].ParentSpanID != parent.SpanContext().SpanID {
+		t.Errorf("Expected the send to be a child of the request span")
+	}
+
+	if spans[0].Status.Code != tracing.StatusError {
+		t.Errorf("Expected the failed send to be an error, got %v", spans[0].Status)
+	}
+}
+
+func TestEmailer_SendContext_UnitOfWork(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		commit   bool
+		send     func(ctx context.Context, mailer *mailutils.MockMailer)
+		expected int
+	}{
+		{
+			name:   "sent on commit",
+			commit: true,
+			send: func(ctx context.Context, mailer *mailutils.MockMailer) {
+				mailer.SendContext(ctx, "a@example.com", "t", nil)
+			},
+			expected: 1,
+		},
+		{
+			name: "not sent on rollback",
+			send: func(ctx context.Context, mailer *mailutils.MockMailer) {
+				mailer.SendContext(ctx, "a@example.com", "t", nil)
+			},
+			expected: 0,
+		},
+		{
+			name:   "SendAfterCommit sent on commit",
+			commit: true,
+			send: func(ctx context.Context, mailer *mailutils.MockMailer) {
+				mailutils.SendAfterCommit(ctx, mailer, "a@example.com", "t", nil)
+			},
+			expected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := dbutils.FromDB(testutils.SetupTestDB(t))
+			defer db.Close()
+
+			mailer := mailutils.NewMockMailer()
+
+			uow, ctx, err := dbutils.BeginUnitOfWork(context.Background(), db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.send(ctx, mailer)
+
+			if len(mailer.SentEmails) != 0 {
+				t.Fatalf("Expected no email before the unit of work finishes, got %v", mailer.SentEmails)
+			}
+
+			if tt.commit {
+				err = uow.Commit()
+			} else {
+				err = uow.Rollback()
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(mailer.SentEmails) != tt.expected {
+				t.Errorf("Expected %d emails, got %v", tt.expected, mailer.SentEmails)
+			}
+		})
+	}
+}
@@ -2,9 +2,11 @@ package mailutils
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"html/template"
 
+	"github.com/gurch101/gowebutils/pkg/dbutils"
 	"github.com/gurch101/gowebutils/pkg/templateutils"
 	"gopkg.in/gomail.v2"
 )
@@ -96,14 +98,21 @@ func (m *MockMailer) MessageToString(index int) string {
 }
 
 func (m *MockMailer) Send(recipient, templateName string, data map[string]string) {
-	email := map[string]any{
-		"recipient":    recipient,
-		"templateName": templateName,
-		"data":         data,
-	}
+	m.SendContext(context.Background(), recipient, templateName, data)
+}
 
-	m.SentEmails = append(m.SentEmails, email)
-	if m.mailer != nil {
-		m.Error = m.mailer.sendInternal(recipient, templateName, data)
-	}
+// SendContext records the email once the unit of work in ctx commits, like Emailer.SendContext.
+func (m *MockMailer) SendContext(ctx context.Context, recipient, templateName string, data map[string]string) {
+	dbutils.AfterCommit(ctx, func() {
+		email := map[string]any{
+			"recipient":    recipient,
+			"templateName": templateName,
+			"data":         data,
+		}
+
+		m.SentEmails = append(m.SentEmails, email)
+		if m.mailer != nil {
+			m.Error = m.mailer.send(ctx, recipient, templateName, data)
+		}
+	})
 }
@@ -0,0 +1,231 @@
+// Package metrics collects counters, gauges and histograms and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Kind is the type of a metric.
+type Kind string
+
+const (
+	KindCounter   Kind = "counter"
+	KindGauge     Kind = "gauge"
+	KindHistogram Kind = "histogram"
+)
+
+// DefaultBuckets are the histogram buckets used when none are given. They suit request latencies in seconds.
+//
+//nolint:gochecknoglobals
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// labelSeparator joins label values into a child key. It can't appear in valid UTF-8 label values.
+const labelSeparator = "\xff"
+
+// family is a named metric with one child per set of label values.
+type family struct {
+	name       string
+	help       string
+	kind       Kind
+	labelNames []string
+	buckets    []float64
+
+	mu       sync.RWMutex
+	children map[string]any
+
+	// collect reports the values of func metrics when they are scraped.
+	collect func(observe func(value float64, labelValues ...string))
+}
+
+func (f *family) child(labelValues []string, create func() any) any {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, labelSeparator)
+
+	f.mu.RLock()
+	c, ok := f.children[key]
+	f.mu.RUnlock()
+
+	if ok {
+		return c
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if c, ok := f.children[key]; ok {
+		return c
+	}
+
+	c = create()
+	f.children[key] = c
+
+	return c
+}
+
+// value is a float64 that can be updated concurrently.
+type value struct {
+	bits atomic.Uint64
+}
+
+func (v *value) add(delta float64) {
+	for {
+		old := v.bits.Load()
+		if v.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (v *value) set(val float64) {
+	v.bits.Store(math.Float64bits(val))
+}
+
+func (v *value) get() float64 {
+	return math.Float64frombits(v.bits.Load())
+}
+
+// Counter is a value that only goes up, such as the number of requests served.
+type Counter struct {
+	value value
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add adds delta to the counter. Negative deltas are ignored.
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+
+	c.value.add(delta)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() float64 {
+	return c.value.get()
+}
+
+// Gauge is a value that can go up and down, such as the number of requests in flight.
+type Gauge struct {
+	value value
+}
+
+// Set sets the gauge to val.
+func (g *Gauge) Set(val float64) {
+	g.value.set(val)
+}
+
+// Inc adds one to the gauge.
+func (g *Gauge) Inc() {
+	g.value.add(1)
+}
+
+// Dec subtracts one from the gauge.
+func (g *Gauge) Dec() {
+	g.value.add(-1)
+}
+
+// Add adds delta to the gauge.
+func (g *Gauge) Add(delta float64) {
+	g.value.add(delta)
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return g.value.get()
+}
+
+// Histogram counts observations, such as request latencies, in buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe adds an observation to the histogram.
+func (h *Histogram) Observe(val float64) {
+	i := sort.SearchFloat64s(h.buckets, val)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+
+	h.count++
+	h.sum += val
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.count
+}
+
+// snapshot returns the cumulative bucket counts, the count and the sum.
+func (h *Histogram) snapshot() ([]uint64, uint64, float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cumulative := make([]uint64, len(h.counts))
+
+	var total uint64
+
+	for i, count := range h.counts {
+		total += count
+		cumulative[i] = total
+	}
+
+	return cumulative, h.count, h.sum
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	family *family
+}
+
+// WithLabelValues returns the counter for the given label values, in the order of the label names.
+func (v *CounterVec) WithLabelValues(labelValues ...string) *Counter {
+	//nolint:forcetypeassert
+	return v.family.child(labelValues, func() any { return &Counter{} }).(*Counter)
+}
+
+// GaugeVec is a gauge partitioned by labels.
+type GaugeVec struct {
+	family *family
+}
+
+// WithLabelValues returns the gauge for the given label values, in the order of the label names.
+func (v *GaugeVec) WithLabelValues(labelValues ...string) *Gauge {
+	//nolint:forcetypeassert
+	return v.family.child(labelValues, func() any { return &Gauge{} }).(*Gauge)
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct {
+	family *family
+}
+
+// WithLabelValues returns the histogram for the given label values, in the order of the label names.
+func (v *HistogramVec) WithLabelValues(labelValues ...string) *Histogram {
+	//nolint:forcetypeassert
+	return v.family.child(labelValues, func() any {
+		return &Histogram{buckets: v.family.buckets, counts: make([]uint64, len(v.family.buckets))}
+	}).(*Histogram)
+}
@@ -0,0 +1,289 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+//nolint:gochecknoglobals
+var (
+	// Default is the registry used by the built-in instrumentation.
+	Default = NewRegistry()
+
+	namePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+)
+
+// Registry holds metrics and writes them in the Prometheus text format.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// NewCounter registers a counter partitioned by the given labels. Registering the same name and labels again
+// returns the existing counter. It panics if the name is invalid or is already used by a different metric.
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{family: r.register(name, help, KindCounter, labelNames, nil, nil)}
+}
+
+// NewGauge registers a gauge partitioned by the given labels. Registering the same name and labels again
+// returns the existing gauge. It panics if the name is invalid or is already used by a different metric.
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{family: r.register(name, help, KindGauge, labelNames, nil, nil)}
+}
+
+// NewHistogram registers a histogram partitioned by the given labels. DefaultBuckets are used when buckets is nil.
+// Registering the same name and labels again returns the existing histogram. It panics if the name is invalid
+// or is already used by a different metric.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &HistogramVec{family: r.register(name, help, KindHistogram, labelNames, buckets, nil)}
+}
+
+// NewGaugeFunc registers a gauge whose values are reported by collect when the registry is scraped. collect calls
+// observe once per set of label values. Registering the same name again replaces collect.
+func (r *Registry) NewGaugeFunc(
+	name, help string,
+	labelNames []string,
+	collect func(observe func(value float64, labelValues ...string)),
+) {
+	r.register(name, help, KindGauge, labelNames, nil, collect)
+}
+
+// NewCounterFunc registers a counter whose values are reported by collect when the registry is scraped. collect
+// calls observe once per set of label values. Registering the same name again replaces collect.
+func (r *Registry) NewCounterFunc(
+	name, help string,
+	labelNames []string,
+	collect func(observe func(value float64, labelValues ...string)),
+) {
+	r.register(name, help, KindCounter, labelNames, nil, collect)
+}
+
+func (r *Registry) register(
+	name, help string,
+	kind Kind,
+	labelNames []string,
+	buckets []float64,
+	collect func(observe func(value float64, labelValues ...string)),
+) *family {
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("invalid metric name %q", name))
+	}
+
+	for _, labelName := range labelNames {
+		if !namePattern.MatchString(labelName) || strings.Contains(labelName, ":") || labelName == "le" {
+			panic(fmt.Sprintf("invalid label name %q for metric %s", labelName, name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.families[name]; ok {
+		if existing.kind != kind || !slices.Equal(existing.labelNames, labelNames) ||
+			(existing.collect == nil) != (collect == nil) {
+			panic(fmt.Sprintf("metric %s is already registered with a different type or labels", name))
+		}
+
+		if collect != nil {
+			existing.mu.Lock()
+			existing.collect = collect
+			existing.mu.Unlock()
+		}
+
+		return existing
+	}
+
+	f := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: slices.Clone(labelNames),
+		buckets:    buckets,
+		children:   make(map[string]any),
+		collect:    collect,
+	}
+	r.families[name] = f
+
+	return f
+}
+
+// Write writes every metric in the Prometheus text format, sorted by name.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	buf := bufio.NewWriter(w)
+
+	for _, f := range families {
+		f.write(buf)
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	return nil
+}
+
+// Handler serves the metrics of the registry in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+
+		if err := r.Write(w); err != nil {
+			slog.ErrorContext(req.Context(), "failed to write metrics", "error", err)
+		}
+	})
+}
+
+type sample struct {
+	labelValues []string
+	child       any
+}
+
+func (f *family) samples() []sample {
+	f.mu.RLock()
+	collect := f.collect
+	samples := make([]sample, 0, len(f.children))
+
+	for key, child := range f.children {
+		var labelValues []string
+		if len(f.labelNames) > 0 {
+			labelValues = strings.Split(key, labelSeparator)
+		}
+
+		samples = append(samples, sample{labelValues: labelValues, child: child})
+	}
+	f.mu.RUnlock()
+
+	if collect != nil {
+		collect(func(value float64, labelValues ...string) {
+			if len(labelValues) != len(f.labelNames) {
+				panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+			}
+
+			samples = append(samples, sample{labelValues: slices.Clone(labelValues), child: value})
+		})
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return slices.Compare(samples[i].labelValues, samples[j].labelValues) < 0
+	})
+
+	return samples
+}
+
+func (f *family) write(w *bufio.Writer) {
+	samples := f.samples()
+	if len(samples) == 0 {
+		return
+	}
+
+	if f.help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	}
+
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	for _, s := range samples {
+		switch child := s.child.(type) {
+		case float64:
+			f.writeSample(w, f.name, s.labelValues, "", child)
+		case *Counter:
+			f.writeSample(w, f.name, s.labelValues, "", child.Value())
+		case *Gauge:
+			f.writeSample(w, f.name, s.labelValues, "", child.Value())
+		case *Histogram:
+			cumulative, count, sum := child.snapshot()
+			for i, upperBound := range f.buckets {
+				f.writeSample(w, f.name+"_bucket", s.labelValues, formatFloat(upperBound), float64(cumulative[i]))
+			}
+
+			f.writeSample(w, f.name+"_bucket", s.labelValues, "+Inf", float64(count))
+			f.writeSample(w, f.name+"_sum", s.labelValues, "", sum)
+			f.writeSample(w, f.name+"_count", s.labelValues, "", float64(count))
+		}
+	}
+}
+
+func (f *family) writeSample(w *bufio.Writer, name string, labelValues []string, le string, val float64) {
+	w.WriteString(name)
+
+	if len(labelValues) > 0 || le != "" {
+		w.WriteByte('{')
+
+		for i, labelValue := range labelValues {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+
+			w.WriteString(f.labelNames[i] + `="` + escapeLabelValue(labelValue) + `"`)
+		}
+
+		if le != "" {
+			if len(labelValues) > 0 {
+				w.WriteByte(',')
+			}
+
+			w.WriteString(`le="` + le + `"`)
+		}
+
+		w.WriteByte('}')
+	}
+
+	w.WriteString(" " + formatFloat(val) + "\n")
+}
+
+func formatFloat(val float64) string {
+	switch {
+	case math.IsInf(val, 1):
+		return "+Inf"
+	case math.IsInf(val, -1):
+		return "-Inf"
+	case math.IsNaN(val):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	}
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabelValue(labelValue string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(labelValue)
+}
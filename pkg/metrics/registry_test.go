@@ -0,0 +1,214 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/metrics"
+)
+
+func write(t *testing.T, registry *metrics.Registry) string {
+	t.Helper()
+
+	var b strings.Builder
+	if err := registry.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.String()
+}
+
+func TestRegistryWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		register func(registry *metrics.Registry)
+		expected string
+	}{
+		{
+			name: "counter",
+			register: func(registry *metrics.Registry) {
+				requests := registry.NewCounter("requests_total", "Requests served.", "method", "status")
+				requests.WithLabelValues("POST", "201").Inc()
+				requests.WithLabelValues("GET", "200").Add(2)
+				requests.WithLabelValues("GET", "200").Add(-1)
+			},
+			expected: "# HELP requests_total Requests served.\n" +
+				"# TYPE requests_total counter\n" +
+				"requests_total{method=\"GET\",status=\"200\"} 2\n" +
+				"requests_total{method=\"POST\",status=\"201\"} 1\n",
+		},
+		{
+			name: "gauge without labels",
+			register: func(registry *metrics.Registry) {
+				inFlight := registry.NewGauge("in_flight", "Requests in flight.").WithLabelValues()
+				inFlight.Inc()
+				inFlight.Inc()
+				inFlight.Dec()
+				inFlight.Add(0.5)
+			},
+			expected: "# HELP in_flight Requests in flight.\n" +
+				"# TYPE in_flight gauge\n" +
+				"in_flight 1.5\n",
+		},
+		{
+			name: "histogram",
+			register: func(registry *metrics.Registry) {
+				latency := registry.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+				latency.WithLabelValues("/").Observe(0.05)
+				latency.WithLabelValues("/").Observe(0.1)
+				latency.WithLabelValues("/").Observe(0.5)
+				latency.WithLabelValues("/").Observe(3)
+			},
+			expected: "# HELP latency_seconds Latency.\n" +
+				"# TYPE latency_seconds histogram\n" +
+				"latency_seconds_bucket{route=\"/\",le=\"0.1\"} 2\n" +
+				"latency_seconds_bucket{route=\"/\",le=\"1\"} 3\n" +
+				"latency_seconds_bucket{route=\"/\",le=\"+Inf\"} 4\n" +
+				"latency_seconds_sum{route=\"/\"} 3.65\n" +
+				"latency_seconds_count{route=\"/\"} 4\n",
+		},
+		{
+			name: "escaping",
+			register: func(registry *metrics.Registry) {
+				registry.NewCounter("errors_total", "Errors\\failures\nby message.", "message").
+					WithLabelValues("bad \"input\"\\\n").Inc()
+			},
+			expected: "# HELP errors_total Errors\\\\failures\\nby message.\n" +
+				"# TYPE errors_total counter\n" +
+				"errors_total{message=\"bad \\\"input\\\"\\\\\\n\"} 1\n",
+		},
+		{
+			name: "func metrics",
+			register: func(registry *metrics.Registry) {
+				registry.NewGaugeFunc("connections", "Open connections.", []string{"pool"},
+					func(observe func(value float64, labelValues ...string)) {
+						observe(2, "write")
+						observe(4, "read")
+					})
+			},
+			expected: "# HELP connections Open connections.\n" +
+				"# TYPE connections gauge\n" +
+				"connections{pool=\"read\"} 4\n" +
+				"connections{pool=\"write\"} 2\n",
+		},
+		{
+			name: "metrics are sorted by name and unused metrics are omitted",
+			register: func(registry *metrics.Registry) {
+				registry.NewCounter("b_total", "").WithLabelValues().Inc()
+				registry.NewCounter("a_total", "").WithLabelValues().Inc()
+				registry.NewCounter("unused_total", "unused", "label")
+			},
+			expected: "# TYPE a_total counter\n" +
+				"a_total 1\n" +
+				"# TYPE b_total counter\n" +
+				"b_total 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := metrics.NewRegistry()
+			tt.register(registry)
+
+			if actual := write(t, registry); actual != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRegistryReregister(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	registry.NewCounter("requests_total", "", "method").WithLabelValues("GET").Inc()
+	registry.NewCounter("requests_total", "", "method").WithLabelValues("GET").Inc()
+
+	registry.NewGaugeFunc("size", "", nil, func(observe func(float64, ...string)) { observe(1) })
+	registry.NewGaugeFunc("size", "", nil, func(observe func(float64, ...string)) { observe(2) })
+
+	expected := "# TYPE requests_total counter\nrequests_total{method=\"GET\"} 2\n# TYPE size gauge\nsize 2\n"
+	if actual := write(t, registry); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestRegistryPanics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		register func(registry *metrics.Registry)
+	}{
+		{
+			name:     "invalid name",
+			register: func(registry *metrics.Registry) { registry.NewCounter("requests-total", "") },
+		},
+		{
+			name:     "reserved label name",
+			register: func(registry *metrics.Registry) { registry.NewHistogram("latency", "", nil, "le") },
+		},
+		{
+			name: "different type",
+			register: func(registry *metrics.Registry) {
+				registry.NewCounter("requests", "")
+				registry.NewGauge("requests", "")
+			},
+		},
+		{
+			name: "different labels",
+			register: func(registry *metrics.Registry) {
+				registry.NewCounter("requests", "", "method")
+				registry.NewCounter("requests", "", "status")
+			},
+		},
+		{
+			name: "wrong number of label values",
+			register: func(registry *metrics.Registry) {
+				registry.NewCounter("requests", "", "method").WithLabelValues("GET", "200")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+
+			tt.register(metrics.NewRegistry())
+		})
+	}
+}
+
+func TestRegistryHandler(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	registry.NewCounter("requests_total", "").WithLabelValues().Inc()
+
+	rr := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != metrics.ContentType {
+		t.Errorf("expected content type %s, got %s", metrics.ContentType, contentType)
+	}
+
+	if !strings.Contains(rr.Body.String(), "requests_total 1\n") {
+		t.Errorf("unexpected body %s", rr.Body.String())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 func ParseEnvString(key string, defaultValue string) string {
@@ -74,3 +75,22 @@ func ParseEnvFloat64(key string, defaultValue float64) (float64, error) {
 
 	return floatVal, nil
 }
+
+// ParseEnvStringSlice parses a comma-separated env var. Whitespace around each value is trimmed and
+// empty values are dropped.
+func ParseEnvStringSlice(key string, defaultValue []string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue
+	}
+
+	var values []string
+
+	for _, value := range strings.Split(val, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
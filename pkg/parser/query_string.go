@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gurch101/gowebutils/pkg/decimal"
 	"github.com/gurch101/gowebutils/pkg/validation"
 )
 
@@ -149,6 +150,23 @@ func ParseQSInt64(queryValues url.Values, key string, defaultValue *int64) *int6
 	return &intVal
 }
 
+// ParseQSDecimal returns a decimal value from the query string or the provided
+// default value if no matching key can be found.
+func ParseQSDecimal(queryValues url.Values, key string, defaultValue *decimal.Decimal) *decimal.Decimal {
+	val := queryValues.Get(key)
+
+	if val == "" {
+		return defaultValue
+	}
+
+	decimalVal, err := decimal.Parse(val)
+	if err != nil {
+		return defaultValue
+	}
+
+	return &decimalVal
+}
+
 // ParseQSBool returns a boolean value from the query string or the provided
 // default value if no matching key can be found.
 func ParseQSBool(queryValues url.Values, key string, defaultValue *bool) *bool {
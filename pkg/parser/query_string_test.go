@@ -4,6 +4,7 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/gurch101/gowebutils/pkg/decimal"
 	"github.com/gurch101/gowebutils/pkg/parser"
 	"github.com/gurch101/gowebutils/pkg/validation"
 )
@@ -64,6 +65,33 @@ func TestParseInt(t *testing.T) {
 	}
 }
 
+func TestParseDecimal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		qs       url.Values
+		expected string
+	}{
+		{"key exists", url.Values{"key": {"10.50"}}, "10.50"},
+		{"key does not exist", url.Values{}, "1"},
+		{"invalid value", url.Values{"key": {"abc"}}, "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			defaultValue := decimal.NewFromInt(1)
+
+			result := parser.ParseQSDecimal(tt.qs, "key", &defaultValue)
+			if result.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result.String())
+			}
+		})
+	}
+}
+
 func TestFilters_ParseFilters(t *testing.T) {
 	t.Parallel()
 
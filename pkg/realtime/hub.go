@@ -0,0 +1,472 @@
+// Package realtime pushes messages to browsers over Server-Sent Events and WebSockets so that background work
+// can report progress and alerts.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gurch101/gowebutils/pkg/authutils"
+	"github.com/gurch101/gowebutils/pkg/httputils"
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+const (
+	defaultBufferSize        = 64
+	defaultHeartbeatInterval = 30 * time.Second
+	defaultWriteTimeout      = 10 * time.Second
+	defaultReadLimit         = 4096
+	defaultMaxTopics         = 32
+)
+
+const (
+	userTopicPrefix   = "user:"
+	tenantTopicPrefix = "tenant:"
+)
+
+var (
+	// ErrHubClosed is returned when publishing to a closed hub.
+	ErrHubClosed = errors.New("realtime hub closed")
+	// ErrTopicForbidden is sent to clients that subscribe to a topic they aren't allowed to receive.
+	ErrTopicForbidden = errors.New("topic forbidden")
+)
+
+// Config configures a Hub.
+type Config struct {
+	// BufferSize is the number of messages queued for each client. Clients that fall further behind are
+	// disconnected so that a slow client can't hold up publishers. Defaults to 64.
+	BufferSize int
+	// HeartbeatInterval is how often idle connections are pinged. WebSocket clients that don't answer within two
+	// intervals are disconnected. Defaults to 30 seconds.
+	HeartbeatInterval time.Duration
+	// ReadLimit is the maximum size of a message sent by a WebSocket client. Defaults to 4KB.
+	ReadLimit int64
+	// MaxTopics is the maximum number of topics a client can subscribe to. Defaults to 32.
+	MaxTopics int
+	// AllowedOrigins are the origins other than the application's own that can open WebSockets.
+	AllowedOrigins []string
+	// Authorize reports whether a user can subscribe to a topic. Users can only receive their own user and tenant
+	// topics if nil. Users are always subscribed to their own user and tenant topics and can't subscribe to
+	// anyone else's.
+	Authorize func(ctx context.Context, user authutils.User, topic string) bool
+}
+
+// ConfigFromEnv returns the hub configuration set by the REALTIME_* environment variables.
+func ConfigFromEnv() Config {
+	heartbeat, err := parser.ParseEnvInt("REALTIME_HEARTBEAT_INTERVAL", int(defaultHeartbeatInterval.Seconds()))
+	if err != nil {
+		panic(err)
+	}
+
+	bufferSize, err := parser.ParseEnvInt("REALTIME_BUFFER_SIZE", defaultBufferSize)
+	if err != nil {
+		panic(err)
+	}
+
+	//nolint: exhaustruct
+	return Config{
+		BufferSize:        bufferSize,
+		HeartbeatInterval: time.Duration(heartbeat) * time.Second,
+		AllowedOrigins:    parser.ParseEnvStringSlice("REALTIME_ALLOWED_ORIGINS", nil),
+	}
+}
+
+// Message is sent to clients as JSON.
+type Message struct {
+	Topic string `json:"topic"`
+	Event string `json:"event"`
+	Data  any    `json:"data"`
+}
+
+// clientRequest is sent by WebSocket clients to change their subscriptions.
+type clientRequest struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+}
+
+type outgoing struct {
+	event   string
+	payload []byte
+}
+
+type client struct {
+	user      authutils.User
+	topics    map[string]bool
+	send      chan outgoing
+	done      chan struct{}
+	closeOnce sync.Once
+	// closeCode is the WebSocket status sent when the client is disconnected by the hub.
+	closeCode int
+}
+
+func (c *client) disconnect(code int) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		close(c.done)
+	})
+}
+
+// Hub delivers published messages to the clients subscribed to their topics.
+type Hub struct {
+	config   Config
+	upgrader *websocket.Upgrader
+	mutex    sync.RWMutex
+	topics   map[string]map[*client]bool
+	clients  map[*client]bool
+	closed   bool
+}
+
+// NewHub creates a hub. Register Hub.ServeSSE and Hub.ServeWebSocket as protected routes so that clients are
+// authenticated by the session middleware.
+func NewHub(config Config) *Hub {
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultBufferSize
+	}
+
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = defaultHeartbeatInterval
+	}
+
+	if config.ReadLimit <= 0 {
+		config.ReadLimit = defaultReadLimit
+	}
+
+	if config.MaxTopics <= 0 {
+		config.MaxTopics = defaultMaxTopics
+	}
+
+	return &Hub{
+		config:   config,
+		upgrader: newUpgrader(config.AllowedOrigins),
+		topics:   make(map[string]map[*client]bool),
+		clients:  make(map[*client]bool),
+	}
+}
+
+// UserTopic returns the topic every connection of a user is subscribed to.
+func UserTopic(userID int64) string {
+	return userTopicPrefix + strconv.FormatInt(userID, 10)
+}
+
+// TenantTopic returns the topic every connection of a tenant's users is subscribed to.
+func TenantTopic(tenantID int64) string {
+	return tenantTopicPrefix + strconv.FormatInt(tenantID, 10)
+}
+
+// Publish sends an event with data encoded as JSON to every client subscribed to topic. It never blocks on
+// clients; clients whose queue is full are disconnected.
+func (h *Hub) Publish(topic, event string, data any) error {
+	payload, err := json.Marshal(Message{Topic: topic, Event: event, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if h.closed {
+		return ErrHubClosed
+	}
+
+	for c := range h.topics[topic] {
+		select {
+		case c.send <- outgoing{event: event, payload: payload}:
+		default:
+			slog.Warn("disconnecting slow realtime client", "userId", c.user.ID, "topic", topic)
+			c.disconnect(websocket.CloseTryAgainLater)
+		}
+	}
+
+	return nil
+}
+
+// PublishToUser sends an event to every connection of a user.
+func (h *Hub) PublishToUser(userID int64, event string, data any) error {
+	return h.Publish(UserTopic(userID), event, data)
+}
+
+// PublishToTenant sends an event to every connection of a tenant's users.
+func (h *Hub) PublishToTenant(tenantID int64, event string, data any) error {
+	return h.Publish(TenantTopic(tenantID), event, data)
+}
+
+// Close disconnects every client and rejects new connections. WebSocket clients get a going away close frame and
+// event streams end, so that clients reconnect to another server.
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.closed = true
+
+	for c := range h.clients {
+		c.disconnect(websocket.CloseGoingAway)
+	}
+}
+
+// ServeSSE streams the messages of the signed in user's topics and the topics in the topic query parameters as
+// Server-Sent Events.
+func (h *Hub) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.connect(w, r)
+	if !ok {
+		return
+	}
+	defer h.remove(c)
+
+	for _, topic := range r.URL.Query()["topic"] {
+		if err := h.subscribe(r.Context(), c, topic); err != nil {
+			httputils.ForbiddenResponse(w, r)
+
+			return
+		}
+	}
+
+	stream, err := NewEventStream(w)
+	if err != nil {
+		httputils.ServerErrorResponse(w, r, err)
+
+		return
+	}
+
+	ticker := time.NewTicker(h.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message := <-c.send:
+			err = stream.send(message.event, message.payload)
+		case <-ticker.C:
+			err = stream.Heartbeat()
+		case <-c.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ServeWebSocket upgrades the request to a WebSocket that receives the messages of the signed in user's topics.
+// Clients subscribe to other topics by sending {"type":"subscribe","topic":"..."} and unsubscribe with
+// {"type":"unsubscribe","topic":"..."}.
+func (h *Hub) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.connect(w, r)
+	if !ok {
+		return
+	}
+	defer h.remove(c)
+
+	// the upgrader responds with an error if the handshake fails
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(h.config.ReadLimit)
+
+	extendDeadline := func() {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * h.config.HeartbeatInterval))
+	}
+	conn.SetPongHandler(func(string) error {
+		extendDeadline()
+
+		return nil
+	})
+	extendDeadline()
+
+	// the hub context outlives the request, which ends when the connection is hijacked
+	ctx := context.WithoutCancel(r.Context())
+
+	go h.readWebSocket(ctx, c, conn, extendDeadline)
+
+	h.writeWebSocket(c, conn)
+}
+
+func (h *Hub) readWebSocket(ctx context.Context, c *client, conn *websocket.Conn, extendDeadline func()) {
+	defer c.disconnect(websocket.CloseNormalClosure)
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		extendDeadline()
+
+		var request clientRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			h.reply(c, "error", map[string]string{"message": "invalid request"})
+
+			continue
+		}
+
+		switch request.Type {
+		case "subscribe":
+			if err := h.subscribe(ctx, c, request.Topic); err != nil {
+				h.reply(c, "error", map[string]string{"message": err.Error(), "topic": request.Topic})
+
+				continue
+			}
+
+			h.reply(c, "subscribed", map[string]string{"topic": request.Topic})
+		case "unsubscribe":
+			h.unsubscribe(c, request.Topic)
+			h.reply(c, "unsubscribed", map[string]string{"topic": request.Topic})
+		default:
+			h.reply(c, "error", map[string]string{"message": "unknown request type"})
+		}
+	}
+}
+
+func (h *Hub) writeWebSocket(c *client, conn *websocket.Conn) {
+	ticker := time.NewTicker(h.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+
+		deadline := time.Now().Add(defaultWriteTimeout)
+
+		select {
+		case message := <-c.send:
+			if err = conn.SetWriteDeadline(deadline); err == nil {
+				err = conn.WriteMessage(websocket.TextMessage, message.payload)
+			}
+		case <-ticker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, deadline)
+		case <-c.done:
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, ""), deadline)
+
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// reply queues a message to a single client.
+func (h *Hub) reply(c *client, event string, data any) {
+	payload, err := json.Marshal(Message{Topic: "", Event: event, Data: data})
+	if err != nil {
+		return
+	}
+
+	select {
+	case c.send <- outgoing{event: event, payload: payload}:
+	default:
+		c.disconnect(websocket.CloseTryAgainLater)
+	}
+}
+
+// connect registers a client for the signed in user and subscribes it to the user's own topics.
+func (h *Hub) connect(w http.ResponseWriter, r *http.Request) (*client, bool) {
+	user, ok := authutils.UserFromContext(r.Context())
+	if !ok {
+		httputils.UnauthorizedResponse(w, r)
+
+		return nil, false
+	}
+
+	//nolint: exhaustruct
+	c := &client{
+		user:   user,
+		topics: make(map[string]bool),
+		send:   make(chan outgoing, h.config.BufferSize),
+		done:   make(chan struct{}),
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, ErrHubClosed.Error(), http.StatusServiceUnavailable)
+
+		return nil, false
+	}
+
+	h.clients[c] = true
+	h.addTopic(c, UserTopic(user.ID))
+	h.addTopic(c, TenantTopic(user.TenantID))
+
+	return c, true
+}
+
+func (h *Hub) subscribe(ctx context.Context, c *client, topic string) error {
+	if topic == "" || strings.HasPrefix(topic, userTopicPrefix) || strings.HasPrefix(topic, tenantTopicPrefix) {
+		return ErrTopicForbidden
+	}
+
+	if h.config.Authorize == nil || !h.config.Authorize(ctx, c.user, topic) {
+		return ErrTopicForbidden
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// the user and tenant topics don't count towards the limit
+	if len(c.topics) >= h.config.MaxTopics+2 && !c.topics[topic] {
+		return fmt.Errorf("%w: too many topics", ErrTopicForbidden)
+	}
+
+	h.addTopic(c, topic)
+
+	return nil
+}
+
+func (h *Hub) unsubscribe(c *client, topic string) {
+	// users can't leave their own topics
+	if strings.HasPrefix(topic, userTopicPrefix) || strings.HasPrefix(topic, tenantTopicPrefix) {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.removeTopic(c, topic)
+}
+
+func (h *Hub) addTopic(c *client, topic string) {
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*client]bool)
+	}
+
+	h.topics[topic][c] = true
+	c.topics[topic] = true
+}
+
+func (h *Hub) removeTopic(c *client, topic string) {
+	delete(h.topics[topic], c)
+	delete(c.topics, topic)
+
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+func (h *Hub) remove(c *client) {
+	c.disconnect(websocket.CloseNormalClosure)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for topic := range c.topics {
+		h.removeTopic(c, topic)
+	}
+
+	delete(h.clients, c)
+}
@@ -0,0 +1,376 @@
+package realtime_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/gurch101/gowebutils/pkg/authutils"
+	"github.com/gurch101/gowebutils/pkg/realtime"
+)
+
+var testUser = authutils.User{ID: 1, TenantID: 2} //nolint: exhaustruct
+
+func newTestServer(t *testing.T, hub *realtime.Hub) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", hub.ServeSSE)
+	mux.HandleFunc("/ws", hub.ServeWebSocket)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Anonymous") == "" {
+			r = authutils.ContextSetUser(r, testUser)
+		}
+
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestServeSSE(t *testing.T) {
+	t.Parallel()
+
+	//nolint: exhaustruct
+	hub := realtime.NewHub(realtime.Config{
+		Authorize: func(_ context.Context, _ authutils.User, topic string) bool { return topic != "secret" },
+	})
+	server := newTestServer(t, hub)
+
+	t.Run("receives user, tenant and topic messages", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/events?topic=reports")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("expected text/event-stream, got %s", resp.Header.Get("Content-Type"))
+		}
+
+		reader := bufio.NewReader(resp.Body)
+
+		for _, publish := range []func() error{
+			func() error { return hub.PublishToUser(testUser.ID, "progress", map[string]int{"percent": 50}) },
+			func() error { return hub.PublishToUser(99, "progress", "not mine") },
+			func() error { return hub.PublishToTenant(testUser.TenantID, "alert", "tenant") },
+			func() error { return hub.Publish("reports", "done", "report") },
+		} {
+			if err := publish(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		expected := []string{
+			`event: progress` + "\n" + `data: {"topic":"user:1","event":"progress","data":{"percent":50}}`,
+			`event: alert` + "\n" + `data: {"topic":"tenant:2","event":"alert","data":"tenant"}`,
+			`event: done` + "\n" + `data: {"topic":"reports","event":"done","data":"report"}`,
+		}
+
+		for _, event := range expected {
+			if got := readSSEEvent(t, reader); got != event {
+				t.Errorf("expected event %q, got %q", event, got)
+			}
+		}
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		anonymous      bool
+		expectedStatus int
+	}{
+		{"anonymous", "/events", true, http.StatusUnauthorized},
+		{"forbidden topic", "/events?topic=secret", false, http.StatusForbidden},
+		{"other user's topic", "/events?topic=user:99", false, http.StatusForbidden},
+		{"other tenant's topic", "/events?topic=tenant:99", false, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Set("Accept", "application/json")
+			if tt.anonymous {
+				req.Header.Set("X-Anonymous", "true")
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestServeSSEHeartbeatAndClose(t *testing.T) {
+	t.Parallel()
+
+	//nolint: exhaustruct
+	hub := realtime.NewHub(realtime.Config{HeartbeatInterval: 10 * time.Millisecond})
+	server := newTestServer(t, hub)
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+
+	if got := readSSEEvent(t, reader); got != ": ping" {
+		t.Errorf("expected heartbeat, got %q", got)
+	}
+
+	hub.Close()
+
+	// the stream ends after any queued heartbeats
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Fatalf("expected the stream to end, got %v", err)
+	}
+
+	if err := hub.PublishToUser(testUser.ID, "progress", nil); err == nil {
+		t.Error("expected publishing to a closed hub to fail")
+	}
+}
+
+func allowAll(context.Context, authutils.User, string) bool {
+	return true
+}
+
+func TestServeWebSocket(t *testing.T) {
+	t.Parallel()
+
+	//nolint: exhaustruct
+	hub := realtime.NewHub(realtime.Config{ReadLimit: 64, Authorize: allowAll})
+	server := newTestServer(t, hub)
+
+	t.Run("subscribes and receives messages", func(t *testing.T) {
+		conn := dialWebSocket(t, server)
+		defer conn.Close()
+
+		writeMessage(t, conn, `{"type":"subscribe","topic":"reports"}`)
+
+		if payload := readMessage(t, conn); !strings.Contains(string(payload), `"event":"subscribed"`) {
+			t.Fatalf("expected subscribed reply, got %s", payload)
+		}
+
+		if err := hub.Publish("reports", "done", map[string]int{"id": 7}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := hub.PublishToUser(testUser.ID, "alert", "hello"); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, expected := range []realtime.Message{
+			{Topic: "reports", Event: "done", Data: map[string]any{"id": float64(7)}},
+			{Topic: "user:1", Event: "alert", Data: "hello"},
+		} {
+			var message realtime.Message
+			if err := json.Unmarshal(readMessage(t, conn), &message); err != nil {
+				t.Fatal(err)
+			}
+
+			if message.Topic != expected.Topic || message.Event != expected.Event {
+				t.Errorf("expected %+v, got %+v", expected, message)
+			}
+		}
+	})
+
+	t.Run("rejects reserved topics", func(t *testing.T) {
+		conn := dialWebSocket(t, server)
+		defer conn.Close()
+
+		writeMessage(t, conn, `{"type":"subscribe","topic":"user:99"}`)
+
+		if payload := readMessage(t, conn); !strings.Contains(string(payload), `"event":"error"`) {
+			t.Errorf("expected error reply, got %s", payload)
+		}
+	})
+
+	t.Run("closes connections with messages over the read limit", func(t *testing.T) {
+		conn := dialWebSocket(t, server)
+		defer conn.Close()
+
+		writeMessage(t, conn, strings.Repeat("a", 65))
+
+		assertCloseCode(t, conn, websocket.CloseMessageTooBig)
+	})
+}
+
+func TestServeWebSocketWithoutAuthorize(t *testing.T) {
+	t.Parallel()
+
+	//nolint: exhaustruct
+	hub := realtime.NewHub(realtime.Config{})
+	server := newTestServer(t, hub)
+
+	conn := dialWebSocket(t, server)
+	defer conn.Close()
+
+	writeMessage(t, conn, `{"type":"subscribe","topic":"reports"}`)
+
+	if payload := readMessage(t, conn); !strings.Contains(string(payload), `"event":"error"`) {
+		t.Errorf("expected error reply, got %s", payload)
+	}
+}
+
+func TestServeWebSocketHandshake(t *testing.T) {
+	t.Parallel()
+
+	//nolint: exhaustruct
+	hub := realtime.NewHub(realtime.Config{AllowedOrigins: []string{"https://app.example.com"}})
+	server := newTestServer(t, hub)
+
+	tests := []struct {
+		name           string
+		origin         string
+		expectedStatus int
+	}{
+		{"same origin", server.URL, http.StatusSwitchingProtocols},
+		{"no origin", "", http.StatusSwitchingProtocols},
+		{"allowed origin", "https://app.example.com", http.StatusSwitchingProtocols},
+		{"cross origin", "https://evil.example.com", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+
+			conn, resp, err := websocket.DefaultDialer.Dial(webSocketURL(server), header)
+			if err == nil {
+				defer conn.Close()
+			}
+
+			if resp == nil {
+				t.Fatalf("expected a response, got %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHubCloseDisconnectsWebSockets(t *testing.T) {
+	t.Parallel()
+
+	//nolint: exhaustruct
+	hub := realtime.NewHub(realtime.Config{})
+	server := newTestServer(t, hub)
+
+	conn := dialWebSocket(t, server)
+	defer conn.Close()
+
+	// wait for the connection to be registered
+	writeMessage(t, conn, `{"type":"ping"}`)
+	readMessage(t, conn)
+
+	hub.Close()
+
+	assertCloseCode(t, conn, websocket.CloseGoingAway)
+
+	_, resp, err := websocket.DefaultDialer.Dial(webSocketURL(server), nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected new connections to be rejected, got %v", err)
+	}
+
+	if resp != nil {
+		resp.Body.Close()
+	}
+}
+
+func readSSEEvent(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	var lines []string
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return strings.Join(lines, "\n")
+		}
+
+		lines = append(lines, line)
+	}
+}
+
+func webSocketURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial(webSocketURL(server), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+func writeMessage(t *testing.T, conn *websocket.Conn, message string) {
+	t.Helper()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) []byte {
+	t.Helper()
+
+	messageType, payload, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if messageType != websocket.TextMessage {
+		t.Fatalf("expected a text message, got %d", messageType)
+	}
+
+	return payload
+}
+
+func assertCloseCode(t *testing.T, conn *websocket.Conn, expectedCode int) {
+	t.Helper()
+
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, expectedCode) {
+		t.Errorf("expected close code %d, got %v", expectedCode, err)
+	}
+}
@@ -0,0 +1,100 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrStreamingUnsupported is returned by NewEventStream when the response can't be flushed.
+var ErrStreamingUnsupported = errors.New("streaming unsupported")
+
+// EventStream writes Server-Sent Events to a response.
+type EventStream struct {
+	w            http.ResponseWriter
+	controller   *http.ResponseController
+	writeTimeout time.Duration
+}
+
+// NewEventStream starts a text/event-stream response. The server's write timeout is replaced by a timeout on each
+// event so that the stream can stay open. Use it directly to stream the progress of a single request, or use
+// Hub.ServeSSE to receive published messages.
+func NewEventStream(w http.ResponseWriter) (*EventStream, error) {
+	stream := &EventStream{w: w, controller: http.NewResponseController(w), writeTimeout: defaultWriteTimeout}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// stop proxies such as nginx from buffering events
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := stream.flush(); err != nil {
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// Send writes an event with data encoded as JSON.
+func (s *EventStream) Send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return s.send(event, payload)
+}
+
+func (s *EventStream) send(event string, payload []byte) error {
+	var b strings.Builder
+
+	if event != "" {
+		b.WriteString("event: " + stripNewlines(event) + "\n")
+	}
+
+	for _, line := range strings.Split(string(payload), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	return s.write(b.String())
+}
+
+// Heartbeat writes a comment that keeps the connection open through proxies and detects disconnected clients.
+func (s *EventStream) Heartbeat() error {
+	return s.write(": ping\n\n")
+}
+
+func (s *EventStream) write(text string) error {
+	if err := s.controller.SetWriteDeadline(time.Now().Add(s.writeTimeout)); err != nil &&
+		!errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+
+	if _, err := io.WriteString(s.w, text); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return s.flush()
+}
+
+func (s *EventStream) flush() error {
+	if err := s.controller.Flush(); err != nil {
+		if errors.Is(err, http.ErrNotSupported) {
+			return ErrStreamingUnsupported
+		}
+
+		return fmt.Errorf("failed to flush event: %w", err)
+	}
+
+	return nil
+}
+
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,38 @@
+package realtime
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// newUpgrader returns an upgrader for WebSocket handshakes. Browsers send cookies with WebSocket handshakes from
+// any site, so the Origin header must be the same as the host of the request or one of allowedOrigins
+// ("*" allows any origin).
+func newUpgrader(allowedOrigins []string) *websocket.Upgrader {
+	//nolint: exhaustruct
+	return &websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return originAllowed(r, allowedOrigins)
+		},
+	}
+}
+
+func originAllowed(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// non-browser clients don't send an origin and can't be tricked into sending cookies
+		return true
+	}
+
+	if slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin) {
+		return true
+	}
+
+	originURL, err := url.Parse(origin)
+
+	return err == nil && strings.EqualFold(originURL.Host, r.Host)
+}
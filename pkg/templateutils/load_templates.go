@@ -12,6 +12,33 @@ import (
 // ErrParseTemplate is an error that occurs when template parsing fails.
 var ErrParseTemplate = errors.New("failed to parse template")
 
+// Template functions that depend on the request being rendered. They return empty values until replaced
+// with WithFuncs.
+const (
+	// CSPNonceFunc returns the request's Content-Security-Policy nonce, e.g. <script nonce="{{ cspNonce }}">.
+	CSPNonceFunc = "cspNonce"
+	// CSRFTokenFunc returns the session's CSRF token.
+	CSRFTokenFunc = "csrfToken"
+	// CSRFFieldFunc returns a hidden form input holding the session's CSRF token, e.g. <form>{{ csrfField }}</form>.
+	CSRFFieldFunc = "csrfField"
+)
+
+var requestFuncs = template.FuncMap{
+	CSPNonceFunc:  func() string { return "" },
+	CSRFTokenFunc: func() string { return "" },
+	CSRFFieldFunc: func() template.HTML { return "" },
+}
+
+// WithFuncs returns a copy of tmpl with the given template functions replaced.
+func WithFuncs(tmpl *template.Template, funcs template.FuncMap) (*template.Template, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("error cloning template: %w", err)
+	}
+
+	return clone.Funcs(funcs), nil
+}
+
 // LoadTemplates loads all the templates from the given embed.FS and returns a map of templates.
 // Panics if any error occurs.
 func LoadTemplates(templateFS embed.FS) map[string]*template.Template {
@@ -23,7 +50,9 @@ func LoadTemplates(templateFS embed.FS) map[string]*template.Template {
 		}
 		// Check if it's a file (not a directory)
 		if !entry.IsDir() {
-			tmpl, err := template.ParseFS(templateFS, path)
+			tmpl, err := template.New(entry.Name()).
+				Funcs(requestFuncs).
+				ParseFS(templateFS, path)
 			if err != nil {
 				return ErrParseTemplate
 			}
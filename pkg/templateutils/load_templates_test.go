@@ -2,6 +2,8 @@ package templateutils_test
 
 import (
 	"embed"
+	"html/template"
+	"strings"
 	"testing"
 
 	"github.com/gurch101/gowebutils/pkg/templateutils"
@@ -23,3 +25,43 @@ func TestLoadTemplates(t *testing.T) {
 		t.Errorf("expected template 'test.go.tmpl' to be loaded, got none")
 	}
 }
+
+func TestWithFuncs(t *testing.T) {
+	t.Parallel()
+
+	tmpl := templateutils.LoadTemplates(testTemplates)["nonce.go.tmpl"]
+
+	tests := []struct {
+		name     string
+		nonce    string
+		expected string
+	}{
+		{name: "without a nonce", expected: `<script nonce=""></script>`},
+		{name: "with a nonce", nonce: "abc123", expected: `<script nonce="abc123"></script>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			funcs := template.FuncMap{}
+			if tt.nonce != "" {
+				funcs[templateutils.CSPNonceFunc] = func() string { return tt.nonce }
+			}
+
+			rendered, err := templateutils.WithFuncs(tmpl, funcs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var sb strings.Builder
+			if err := rendered.ExecuteTemplate(&sb, "nonce.go.tmpl", nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.TrimSpace(sb.String()); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -16,7 +16,8 @@ import (
 
 type TestApp struct {
 	*app.App
-	TestRouter *chi.Mux
+	TestRouter   *chi.Mux
+	queryTracker *dbutils.QueryTracker
 }
 
 type Option func(options *options) error
@@ -148,12 +149,31 @@ func (a *TestApp) MakeAuthenticatedPutRequest(t *testing.T, path string, body in
 }
 
 func (a *TestApp) MakeRequest(req *http.Request) *httptest.ResponseRecorder {
+	if a.queryTracker != nil {
+		req = req.WithContext(dbutils.ContextWithQueryTracker(req.Context(), a.queryTracker))
+	}
+
 	rr := httptest.NewRecorder()
 	a.TestRouter.ServeHTTP(rr, req)
 
 	return rr
 }
 
+// AssertMaxQueries fails the test if the requests made with MakeRequest while running fn
+// issue more than maxQueries database queries through app.DB().
+func (a *TestApp) AssertMaxQueries(t *testing.T, maxQueries int, fn func()) {
+	t.Helper()
+
+	a.queryTracker = dbutils.NewQueryTracker(0)
+	defer func() { a.queryTracker = nil }()
+
+	fn()
+
+	if count := a.queryTracker.Count(); count > maxQueries {
+		t.Errorf("expected at most %d queries, got %d: %v", maxQueries, count, a.queryTracker.Fingerprints())
+	}
+}
+
 func getUserExists(_ context.Context, _ dbutils.DB, _ authutils.User) bool {
 	return true
 }
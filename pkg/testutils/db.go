@@ -2,6 +2,7 @@ package testutils
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -11,15 +12,33 @@ import (
 	"text/tabwriter"
 
 	"github.com/gurch101/gowebutils/pkg/dbutils"
+	"github.com/gurch101/gowebutils/pkg/fixtures"
 	"github.com/gurch101/gowebutils/pkg/fsutils"
 
 	// needed for sqlite3 driver.
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const testFixtureSet = "test"
+
+// seedDB seeds the test fixture set, or runs the db/data/test_*.sql files of apps that don't have one.
 func seedDB(db *sql.DB) error {
-	projectRoot := GetProjectRoot()
-	dataDir := filepath.Join(projectRoot, "db", "data")
+	fixturesDir := filepath.Join(GetProjectRoot(), "db", "fixtures")
+
+	if _, err := os.Stat(filepath.Join(fixturesDir, testFixtureSet)); os.IsNotExist(err) {
+		return seedSQLFiles(db)
+	}
+
+	_, err := fixtures.Seed(context.Background(), db, os.DirFS(fixturesDir), testFixtureSet)
+	if err != nil {
+		return fmt.Errorf("failed to seed test fixtures: %w", err)
+	}
+
+	return nil
+}
+
+func seedSQLFiles(db *sql.DB) error {
+	dataDir := filepath.Join(GetProjectRoot(), "db", "data")
 
 	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
 		return nil
@@ -52,6 +71,21 @@ func seedDB(db *sql.DB) error {
 	return nil
 }
 
+// SeedFixtures seeds the given fixture sets from the db/fixtures directory and returns
+// the ids of the seeded rows.
+func SeedFixtures(t *testing.T, db dbutils.DB, sets ...string) fixtures.Refs {
+	t.Helper()
+
+	fixturesDir := filepath.Join(GetProjectRoot(), "db", "fixtures")
+
+	refs, err := fixtures.Seed(context.Background(), db, os.DirFS(fixturesDir), sets...)
+	if err != nil {
+		t.Fatalf("Failed to seed fixtures %v: %v", sets, err)
+	}
+
+	return refs
+}
+
 func SetupTestDB(t *testing.T) *sql.DB {
 	t.Helper()
 
@@ -1,43 +1,133 @@
 package testutils
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"sync"
+
+	"github.com/gurch101/gowebutils/pkg/fsutils"
 )
 
 type MockFileService struct {
+	mutex              sync.Mutex
 	UploadedFileName   string
 	DownloadedFileName string
 	DeletedFileNames   []string
 	DownloadedFile     []byte
 	UploadedFile       io.Reader
 	UploadedLocation   string
+	// UploadedFiles holds the contents of every uploaded file by name.
+	UploadedFiles map[string][]byte
+	// DownloadedFileInfo is returned by Open, OpenRange and Stat with the name and size of DownloadedFile.
+	DownloadedFileInfo fsutils.ObjectInfo
 }
 
 func NewMockFileService() *MockFileService {
 	//nolint: exhaustruct
-	return &MockFileService{}
+	return &MockFileService{UploadedFiles: make(map[string][]byte)}
 }
 
 func (s *MockFileService) UploadFile(fileName string, file io.Reader) (string, error) {
+	return s.UploadFileContext(context.Background(), fileName, file)
+}
+
+// UploadFileContext reads the whole file, like the S3 uploader, so that streamed uploads can be inspected.
+func (s *MockFileService) UploadFileContext(_ context.Context, fileName string, file io.Reader) (string, error) {
+	contents, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	s.UploadedFileName = fileName
-	s.UploadedFile = file
+	s.UploadedFile = bytes.NewReader(contents)
+
+	if s.UploadedFiles == nil {
+		s.UploadedFiles = make(map[string][]byte)
+	}
+
+	s.UploadedFiles[fileName] = contents
 
 	return s.UploadedLocation, nil
 }
 
 func (s *MockFileService) DownloadFile(fileName string) ([]byte, error) {
+	return s.DownloadFileContext(context.Background(), fileName)
+}
+
+func (s *MockFileService) DownloadFileContext(_ context.Context, fileName string) ([]byte, error) {
 	s.DownloadedFileName = fileName
 
 	return s.DownloadedFile, nil
 }
 
+func (s *MockFileService) Open(ctx context.Context, fileName string) (io.ReadCloser, fsutils.ObjectInfo, error) {
+	info, err := s.Stat(ctx, fileName)
+	if err != nil {
+		return nil, info, err
+	}
+
+	return s.OpenRange(ctx, fileName, 0, info.Size)
+}
+
+func (s *MockFileService) OpenRange(
+	ctx context.Context,
+	fileName string,
+	offset, length int64,
+) (io.ReadCloser, fsutils.ObjectInfo, error) {
+	info, err := s.Stat(ctx, fileName)
+	if err != nil {
+		return nil, info, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.DownloadedFileName = fileName
+
+	return io.NopCloser(bytes.NewReader(s.DownloadedFile[offset : offset+length])), info, nil
+}
+
+func (s *MockFileService) Stat(_ context.Context, fileName string) (fsutils.ObjectInfo, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.DownloadedFile == nil {
+		return fsutils.ObjectInfo{}, fmt.Errorf("failed to stat file: %w", fsutils.ErrFileNotFound)
+	}
+
+	info := s.DownloadedFileInfo
+	info.Name = fileName
+	info.Size = int64(len(s.DownloadedFile))
+
+	return info, nil
+}
+
 func (s *MockFileService) DeleteFile(fileName string) error {
+	return s.DeleteFileContext(context.Background(), fileName)
+}
+
+func (s *MockFileService) DeleteFileContext(_ context.Context, fileName string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	s.DeletedFileNames = append(s.DeletedFileNames, fileName)
 
 	return nil
 }
 
 func (s *MockFileService) DeleteFiles(fileNames []string) error {
+	return s.DeleteFilesContext(context.Background(), fileNames)
+}
+
+func (s *MockFileService) DeleteFilesContext(_ context.Context, fileNames []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	s.DeletedFileNames = append(s.DeletedFileNames, fileNames...)
 
 	return nil
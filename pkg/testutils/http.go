@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gurch101/gowebutils/pkg/collectionutils"
+	"github.com/gurch101/gowebutils/pkg/decimal"
 	"github.com/gurch101/gowebutils/pkg/httputils"
 	"github.com/gurch101/gowebutils/pkg/validation"
 )
@@ -135,3 +136,7 @@ func IntPtr(i int) *int {
 func Int64Ptr(i int64) *int64 {
 	return &i
 }
+
+func DecimalPtr(d decimal.Decimal) *decimal.Decimal {
+	return &d
+}
@@ -0,0 +1,78 @@
+package testutils
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/tracing"
+)
+
+// SpanRecorder is a tracing exporter that keeps spans in memory.
+type SpanRecorder struct {
+	mu     sync.Mutex
+	spans  []tracing.SpanData
+	tracer *tracing.Tracer
+}
+
+// SetupTestTracer sets a default tracer that records every span until the test ends. Tests that use it must not
+// be parallel since the default tracer is shared.
+func SetupTestTracer(t *testing.T) *SpanRecorder {
+	t.Helper()
+
+	//nolint: exhaustruct
+	recorder := &SpanRecorder{}
+	//nolint: exhaustruct
+	recorder.tracer = tracing.NewTracer(tracing.Config{Exporter: recorder})
+
+	tracing.SetDefault(recorder.tracer)
+	t.Cleanup(func() {
+		tracing.SetDefault(nil)
+
+		if err := recorder.tracer.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return recorder
+}
+
+// Export records spans.
+func (r *SpanRecorder) Export(_ context.Context, spans []tracing.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = append(r.spans, spans...)
+
+	return nil
+}
+
+// Shutdown does nothing.
+func (r *SpanRecorder) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// Spans returns the spans that have ended, in the order they ended.
+func (r *SpanRecorder) Spans() []tracing.SpanData {
+	if r.tracer != nil {
+		_ = r.tracer.ForceFlush(context.Background())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]tracing.SpanData(nil), r.spans...)
+}
+
+// SpansNamed returns the spans with the given name.
+func (r *SpanRecorder) SpansNamed(name string) []tracing.SpanData {
+	var spans []tracing.SpanData
+
+	for _, span := range r.Spans() {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+
+	return spans
+}
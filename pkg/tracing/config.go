@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gurch101/gowebutils/pkg/parser"
+)
+
+// ErrUnknownExporter is returned when TRACING_EXPORTER isn't stdout, otlp or none.
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+// ConfigFromEnv returns the tracer configuration set by the TRACING_* env vars. ok is false when TRACING_EXPORTER
+// is unset or none.
+func ConfigFromEnv() (Config, bool) {
+	var exporter Exporter
+
+	switch name := parser.ParseEnvString("TRACING_EXPORTER", "none"); name {
+	case "none":
+		return Config{}, false
+	case "stdout":
+		exporter = NewStdoutExporter(os.Stdout)
+	case "otlp":
+		//nolint: exhaustruct
+		exporter = NewOTLPExporter(OTLPConfig{
+			Endpoint:    parser.ParseEnvString("TRACING_OTLP_ENDPOINT", DefaultOTLPEndpoint),
+			Headers:     parseHeaders(parser.ParseEnvStringSlice("TRACING_OTLP_HEADERS", nil)),
+			ServiceName: parser.ParseEnvString("TRACING_SERVICE_NAME", filepath.Base(os.Args[0])),
+		})
+	default:
+		panic(fmt.Errorf("%w: %s", ErrUnknownExporter, name))
+	}
+
+	sampleRatio, err := parser.ParseEnvFloat64("TRACING_SAMPLE_RATIO", 1)
+	if err != nil {
+		panic(err)
+	}
+
+	//nolint: exhaustruct
+	return Config{
+		Exporter:    exporter,
+		SampleRatio: sampleRatio,
+	}, true
+}
+
+// parseHeaders parses key=value pairs.
+func parseHeaders(pairs []string) map[string]string {
+	headers := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		key, value, _ := strings.Cut(pair, "=")
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return headers
+}
@@ -0,0 +1,179 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrTracerShutdown is returned when a tracer is used after it was shut down.
+var ErrTracerShutdown = errors.New("tracer is shut down")
+
+// exportTimeout is the longest an exporter can take to export a batch.
+const exportTimeout = 10 * time.Second
+
+// Exporter sends ended spans to a backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// batcher queues ended spans and exports them in batches from a single goroutine.
+type batcher struct {
+	exporter  Exporter
+	batchSize int
+	timeout   time.Duration
+
+	queue    chan SpanData
+	flush    chan chan error
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	dropOnce sync.Once
+}
+
+func newBatcher(config Config) *batcher {
+	b := &batcher{
+		exporter:  config.Exporter,
+		batchSize: config.BatchSize,
+		timeout:   config.BatchTimeout,
+		queue:     make(chan SpanData, config.QueueSize),
+		flush:     make(chan chan error),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+
+	go b.run()
+
+	return b
+}
+
+func (b *batcher) enqueue(span SpanData) {
+	select {
+	case <-b.done:
+		return
+	default:
+	}
+
+	select {
+	case b.queue <- span:
+	default:
+		b.dropOnce.Do(func() {
+			slog.Warn("tracing queue is full, dropping spans", "queue_size", cap(b.queue))
+		})
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.stopped)
+
+	ticker := time.NewTicker(b.timeout)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, b.batchSize)
+
+	export := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := b.export(batch)
+		batch = batch[:0]
+
+		return err
+	}
+
+	drain := func() error {
+		var err error
+
+		for {
+			select {
+			case span := <-b.queue:
+				batch = append(batch, span)
+				if len(batch) >= b.batchSize {
+					err = errors.Join(err, export())
+				}
+			default:
+				return errors.Join(err, export())
+			}
+		}
+	}
+
+	for {
+		select {
+		case span := <-b.queue:
+			batch = append(batch, span)
+			if len(batch) >= b.batchSize {
+				_ = export()
+			}
+		case <-ticker.C:
+			_ = export()
+		case reply := <-b.flush:
+			reply <- drain()
+		case <-b.done:
+			_ = drain()
+
+			return
+		}
+	}
+}
+
+func (b *batcher) export(batch []SpanData) error {
+	if b.exporter == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if err := b.exporter.Export(ctx, batch); err != nil {
+		slog.Error("failed to export spans", "count", len(batch), "error", err)
+
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+
+	return nil
+}
+
+func (b *batcher) forceFlush(ctx context.Context) error {
+	reply := make(chan error, 1)
+
+	select {
+	case b.flush <- reply:
+	case <-b.stopped:
+		return ErrTracerShutdown
+	case <-ctx.Done():
+		return fmt.Errorf("failed to flush spans: %w", ctx.Err())
+	}
+
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("failed to flush spans: %w", ctx.Err())
+	}
+}
+
+func (b *batcher) shutdown(ctx context.Context) error {
+	b.stopOnce.Do(func() { close(b.done) })
+
+	select {
+	case <-b.stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to shut down tracer: %w", ctx.Err())
+	}
+
+	if b.exporter == nil {
+		return nil
+	}
+
+	if err := b.exporter.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down exporter: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gurch101/gowebutils/pkg/tracing"
+)
+
+func testSpan() tracing.SpanData {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	return tracing.SpanData{
+		Name: "GET /api/users/{id}",
+		Kind: tracing.SpanKindServer,
+		SpanContext: tracing.SpanContext{
+			TraceID: tracing.TraceID{0x4b, 0xf9},
+			SpanID:  tracing.SpanID{0x01},
+			Sampled: true,
+		},
+		ParentSpanID: tracing.SpanID{0x02},
+		StartTime:    start,
+		EndTime:      start.Add(1500 * time.Microsecond),
+		Attributes: []slog.Attr{
+			slog.String("http.route", "/api/users/{id}"),
+			slog.Int("http.response.status_code", 500),
+			slog.Bool("cached", false),
+			slog.Float64("ratio", 0.5),
+		},
+		Status: tracing.Status{Code: tracing.StatusError, Message: "boom"},
+	}
+}
+
+func TestStdoutExporter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	exporter := tracing.NewStdoutExporter(&buf)
+	if err := exporter.Export(context.Background(), []tracing.SpanData{testSpan()}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"traceId":"4bf90000000000000000000000000000","spanId":"0100000000000000",` +
+		`"parentSpanId":"0200000000000000","name":"GET /api/users/{id}","kind":"server",` +
+		`"startTime":"2024-01-02T03:04:05Z","endTime":"2024-01-02T03:04:05.0015Z","durationMs":1.5,` +
+		`"attributes":{"cached":false,"http.response.status_code":500,"http.route":"/api/users/{id}","ratio":0.5},` +
+		`"status":"error","message":"boom"}` + "\n"
+
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+// collectorStub records the requests posted to it like an OpenTelemetry collector.
+type collectorStub struct {
+	mu       sync.Mutex
+	status   int
+	bodies   [][]byte
+	requests []*http.Request
+}
+
+func (c *collectorStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	c.mu.Lock()
+	c.bodies = append(c.bodies, body)
+	c.requests = append(c.requests, r)
+	status := c.status
+	c.mu.Unlock()
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{}`))
+}
+
+func TestOTLPExporter(t *testing.T) {
+	t.Parallel()
+
+	collector := &collectorStub{}
+
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	exporter := tracing.NewOTLPExporter(tracing.OTLPConfig{
+		Endpoint:    server.URL + "/",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		ServiceName: "myapp",
+	})
+
+	if err := exporter.Export(context.Background(), []tracing.SpanData{testSpan()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(collector.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(collector.requests))
+	}
+
+	req := collector.requests[0]
+
+	if req.Method != http.MethodPost || req.URL.Path != "/v1/traces" {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+	}
+
+	if req.Header.Get("Content-Type") != "application/json" || req.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("unexpected headers %v", req.Header)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(collector.bodies[0], &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"myapp"}}]},` +
+		`"scopeSpans":[{"scope":{"name":"github.com/gurch101/gowebutils"},"spans":[{` +
+		`"traceId":"4bf90000000000000000000000000000","spanId":"0100000000000000","parentSpanId":"0200000000000000",` +
+		`"name":"GET /api/users/{id}","kind":2,` +
+		`"startTimeUnixNano":"1704164645000000000","endTimeUnixNano":"1704164645001500000",` +
+		`"attributes":[{"key":"http.route","value":{"stringValue":"/api/users/{id}"}},` +
+		`{"key":"http.response.status_code","value":{"intValue":"500"}},` +
+		`{"key":"cached","value":{"boolValue":false}},{"key":"ratio","value":{"doubleValue":0.5}}],` +
+		`"status":{"code":2,"message":"boom"}}]}]}]}`
+
+	if string(collector.bodies[0]) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, collector.bodies[0])
+	}
+}
+
+func TestOTLPExporterRejected(t *testing.T) {
+	t.Parallel()
+
+	collector := &collectorStub{status: http.StatusBadRequest}
+
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	exporter := tracing.NewOTLPExporter(tracing.OTLPConfig{Endpoint: server.URL})
+
+	err := exporter.Export(context.Background(), []tracing.SpanData{testSpan()})
+	if !errors.Is(err, tracing.ErrExportFailed) || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected ErrExportFailed, got %v", err)
+	}
+}
+
+func TestTracerExportsToCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := &collectorStub{}
+
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	tracer := tracing.NewTracer(tracing.Config{
+		Exporter:  tracing.NewOTLPExporter(tracing.OTLPConfig{Endpoint: server.URL}),
+		BatchSize: 2,
+	})
+
+	for range 3 {
+		_, span := tracer.Start(context.Background(), "span")
+		span.End()
+	}
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	if len(collector.bodies) != 2 {
+		t.Errorf("expected 3 spans to be exported in 2 batches, got %d", len(collector.bodies))
+	}
+}
@@ -0,0 +1,227 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrExportFailed is returned when the collector rejects an export.
+var ErrExportFailed = errors.New("export failed")
+
+const (
+	// DefaultOTLPEndpoint is the address of a collector running on the same host.
+	DefaultOTLPEndpoint = "http://localhost:4318"
+
+	otlpTracesPath     = "/v1/traces"
+	otlpClientTimeout  = 10 * time.Second
+	maxErrorBodyLength = 512
+)
+
+// OTLPConfig configures an OTLPExporter.
+type OTLPConfig struct {
+	// Endpoint is the base URL of the collector. Spans are posted to Endpoint/v1/traces. Defaults to
+	// http://localhost:4318.
+	Endpoint string
+	// Headers are sent with every export, e.g. to authenticate with a hosted collector.
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute.
+	ServiceName string
+	// Client sends the exports. Defaults to a client with a 10 second timeout.
+	Client *http.Client
+}
+
+// OTLPExporter posts spans to an OpenTelemetry collector with OTLP over HTTP, encoded as JSON.
+type OTLPExporter struct {
+	url     string
+	headers map[string]string
+	service string
+	client  *http.Client
+}
+
+// NewOTLPExporter creates an exporter that posts spans to the collector at config.Endpoint.
+func NewOTLPExporter(config OTLPConfig) *OTLPExporter {
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultOTLPEndpoint
+	}
+
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: otlpClientTimeout}
+	}
+
+	return &OTLPExporter{
+		url:     strings.TrimSuffix(config.Endpoint, "/") + otlpTracesPath,
+		headers: config.Headers,
+		service: config.ServiceName,
+		client:  config.Client,
+	}
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	TraceState        string          `json:"traceState,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an AnyValue. Only one field is set. 64-bit integers are encoded as strings.
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// Export posts spans to the collector.
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(e.newRequest(spans))
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+
+		return fmt.Errorf("%w: %s: %s", ErrExportFailed, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// Shutdown closes idle connections to the collector.
+func (e *OTLPExporter) Shutdown(_ context.Context) error {
+	e.client.CloseIdleConnections()
+
+	return nil
+}
+
+func (e *OTLPExporter) newRequest(spans []SpanData) otlpRequest {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+
+	for _, span := range spans {
+		out := otlpSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			TraceState:        span.SpanContext.TraceState,
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+			Status:            otlpStatus{Code: span.Status.Code, Message: span.Status.Message},
+		}
+
+		if span.ParentSpanID.IsValid() {
+			out.ParentSpanID = span.ParentSpanID.String()
+		}
+
+		otlpSpans = append(otlpSpans, out)
+	}
+
+	var resource []otlpAttribute
+	if e.service != "" {
+		resource = otlpAttributes([]slog.Attr{slog.String("service.name", e.service)})
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   otlpResource{Attributes: resource},
+			ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: otlpSpans}},
+		}},
+	}
+}
+
+// scopeName is the instrumentation scope of every span.
+const scopeName = "github.com/gurch101/gowebutils"
+
+func otlpAttributes(attrs []slog.Attr) []otlpAttribute {
+	out := make([]otlpAttribute, 0, len(attrs))
+
+	for _, attr := range attrs {
+		var value otlpValue
+
+		switch v := attributeValue(attr.Value).(type) {
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		case uint64:
+			s := strconv.FormatUint(v, 10)
+			value.IntValue = &s
+		case float64:
+			value.DoubleValue = &v
+		case bool:
+			value.BoolValue = &v
+		case string:
+			value.StringValue = &v
+		}
+
+		out = append(out, otlpAttribute{Key: attr.Key, Value: value})
+	}
+
+	return out
+}
@@ -0,0 +1,154 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+
+	traceparentVersion = "00"
+	sampledFlag        = 0x01
+	// traceparentLength is the length of a version 00 traceparent: 00-<32 hex>-<16 hex>-<2 hex>.
+	traceparentLength = 55
+)
+
+// ParseTraceparent parses a W3C traceparent header value.
+func ParseTraceparent(traceparent string) (SpanContext, bool) {
+	// later versions may append fields, so only the version 00 prefix is read
+	if len(traceparent) < traceparentLength ||
+		(len(traceparent) > traceparentLength && traceparent[traceparentLength] != '-') {
+		return SpanContext{}, false
+	}
+
+	parts := strings.Split(traceparent[:traceparentLength], "-")
+	if len(parts) != 4 || parts[0] == "ff" || !isLowerHex(parts[0]) ||
+		(parts[0] == traceparentVersion && len(traceparent) != traceparentLength) {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+
+	var flags [1]byte
+
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, false
+	}
+
+	sc.Sampled = flags[0]&sampledFlag != 0
+
+	return sc, sc.IsValid()
+}
+
+// Traceparent returns the W3C traceparent header value of sc.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return fmt.Sprintf("%s-%s-%s-%s", traceparentVersion, sc.TraceID, sc.SpanID, flags)
+}
+
+// Extract returns a context whose new spans are children of the span in the traceparent header, if it is valid.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+
+	sc.TraceState = header.Get(TracestateHeader)
+
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Inject sets the traceparent and tracestate headers to the current span in ctx, if any.
+func Inject(ctx context.Context, header http.Header) {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	header.Set(TraceparentHeader, sc.Traceparent())
+
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+// Transport records a client span for each request and propagates it to the server with the traceparent header.
+type Transport struct {
+	// Base sends the requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := Start(r.Context(), r.Method, WithKind(SpanKindClient), WithAttributes(
+		slog.String("http.request.method", r.Method),
+		slog.String("server.address", r.URL.Hostname()),
+		slog.String("url.full", redactURL(r.URL)),
+	))
+	defer span.End()
+
+	r = r.Clone(ctx)
+	Inject(ctx, r.Header)
+
+	resp, err := base.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+
+		//nolint: wrapcheck
+		return nil, err
+	}
+
+	span.SetAttributes(slog.Int("http.response.status_code", resp.StatusCode))
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(StatusError, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// redactURL formats u without its query string and credentials, which may be secret.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.Fragment = ""
+
+	return redacted.String()
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || !isLowerHex(s) {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+
+	return err == nil
+}
@@ -0,0 +1,174 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/testutils"
+	"github.com/gurch101/gowebutils/pkg/tracing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		traceparent string
+		valid       bool
+		sampled     bool
+	}{
+		{
+			name:        "sampled",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			valid:       true,
+			sampled:     true,
+		},
+		{
+			name:        "not sampled",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			valid:       true,
+		},
+		{
+			name:        "later version with extra fields",
+			traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			valid:       true,
+			sampled:     true,
+		},
+		{
+			name:        "version 00 with extra fields",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		},
+		{
+			name:        "invalid version",
+			traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:        "uppercase",
+			traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01",
+		},
+		{
+			name:        "zero trace id",
+			traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			name:        "zero span id",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		},
+		{
+			name:        "short",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		},
+		{
+			name:        "empty",
+			traceparent: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sc, ok := tracing.ParseTraceparent(tt.traceparent)
+			if ok != tt.valid {
+				t.Fatalf("expected valid %v, got %v", tt.valid, ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+				t.Errorf("unexpected ids %s %s", sc.TraceID, sc.SpanID)
+			}
+
+			if sc.Sampled != tt.sampled {
+				t.Errorf("expected sampled %v, got %v", tt.sampled, sc.Sampled)
+			}
+		})
+	}
+}
+
+func TestExtractAndInject(t *testing.T) {
+	t.Parallel()
+
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	incoming.Set("tracestate", "vendor=value")
+
+	ctx := tracing.Extract(context.Background(), incoming)
+
+	outgoing := http.Header{}
+	tracing.Inject(ctx, outgoing)
+
+	if outgoing.Get("traceparent") != incoming.Get("traceparent") {
+		t.Errorf("expected traceparent %s, got %s", incoming.Get("traceparent"), outgoing.Get("traceparent"))
+	}
+
+	if outgoing.Get("tracestate") != "vendor=value" {
+		t.Errorf("expected tracestate to be propagated, got %s", outgoing.Get("tracestate"))
+	}
+
+	empty := http.Header{}
+	tracing.Inject(context.Background(), empty)
+
+	if len(empty) != 0 {
+		t.Errorf("expected no headers without a span, got %v", empty)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	recorder := testutils.SetupTestTracer(t)
+
+	var traceparent string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/path?secret=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: &tracing.Transport{}}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+	parent.End()
+
+	spans := recorder.SpansNamed(http.MethodGet)
+	if len(spans) != 1 {
+		t.Fatalf("expected a client span, got %v", recorder.Spans())
+	}
+
+	span := spans[0]
+
+	if span.ParentSpanID != parent.SpanContext().SpanID || span.Kind != tracing.SpanKindClient {
+		t.Errorf("unexpected client span %+v", span)
+	}
+
+	if expected := span.SpanContext.Traceparent(); traceparent != expected {
+		t.Errorf("expected traceparent %s, got %s", expected, traceparent)
+	}
+
+	if span.Status.Code != tracing.StatusError {
+		t.Errorf("expected a 502 to fail the span, got %v", span.Status)
+	}
+
+	for _, attr := range span.Attributes {
+		if attr.Key == "url.full" && attr.Value.String() != server.URL+"/path" {
+			t.Errorf("expected the query string to be removed, got %s", attr.Value)
+		}
+	}
+}
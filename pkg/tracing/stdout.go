@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// StdoutExporter writes each span as a line of JSON. It is meant for development.
+type StdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutExporter creates an exporter that writes spans to w.
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	return &StdoutExporter{w: w}
+}
+
+type stdoutSpan struct {
+	TraceID      string         `json:"traceId"`
+	SpanID       string         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId,omitempty"`
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	StartTime    time.Time      `json:"startTime"`
+	EndTime      time.Time      `json:"endTime"`
+	DurationMS   float64        `json:"durationMs"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       string         `json:"status"`
+	Message      string         `json:"message,omitempty"`
+}
+
+// Export writes spans to the writer.
+func (e *StdoutExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	encoder := json.NewEncoder(e.w)
+
+	for _, span := range spans {
+		out := stdoutSpan{
+			TraceID:    span.SpanContext.TraceID.String(),
+			SpanID:     span.SpanContext.SpanID.String(),
+			Name:       span.Name,
+			Kind:       span.Kind.String(),
+			StartTime:  span.StartTime.UTC(),
+			EndTime:    span.EndTime.UTC(),
+			DurationMS: float64(span.EndTime.Sub(span.StartTime)) / float64(time.Millisecond),
+			Status:     span.Status.Code.String(),
+			Message:    span.Status.Message,
+		}
+
+		if span.ParentSpanID.IsValid() {
+			out.ParentSpanID = span.ParentSpanID.String()
+		}
+
+		if len(span.Attributes) > 0 {
+			out.Attributes = make(map[string]any, len(span.Attributes))
+			for _, attr := range span.Attributes {
+				out.Attributes[attr.Key] = attributeValue(attr.Value)
+			}
+		}
+
+		if err := encoder.Encode(out); err != nil {
+			return fmt.Errorf("failed to write span: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Shutdown does nothing.
+func (e *StdoutExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// attributeValue returns the JSON value of an attribute.
+func attributeValue(value slog.Value) any {
+	value = value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindString:
+		return value.String()
+	case slog.KindInt64:
+		return value.Int64()
+	case slog.KindUint64:
+		return value.Uint64()
+	case slog.KindFloat64:
+		return value.Float64()
+	case slog.KindBool:
+		return value.Bool()
+	default:
+		return value.String()
+	}
+}
+
+// String returns the name of the kind.
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// String returns the name of the status code.
+func (c StatusCode) String() string {
+	switch c {
+	case StatusOK:
+		return "ok"
+	case StatusError:
+		return "error"
+	default:
+		return "unset"
+	}
+}
@@ -0,0 +1,369 @@
+// Package tracing records spans for requests, queries and other operations and exports them to stdout or to an
+// OpenTelemetry collector. Trace context is propagated with the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// String returns the lowercase hex encoding of the id.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether the id isn't all zeros.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns the lowercase hex encoding of the id.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether the id isn't all zeros.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext is the part of a span that is propagated to other services.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+}
+
+// IsValid reports whether the trace and span ids are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind describes the relationship between a span and its parent. The values match OTLP.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode is the status of a span. The values match OTLP.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Status is the outcome of the operation of a span.
+type Status struct {
+	Code    StatusCode
+	Message string
+}
+
+// SpanData is an ended span as it is exported.
+type SpanData struct {
+	Name         string
+	Kind         SpanKind
+	SpanContext  SpanContext
+	ParentSpanID SpanID
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   []slog.Attr
+	Status       Status
+}
+
+// Span records an operation. The methods of a nil Span do nothing, so spans can be used whether tracing is
+// enabled or not.
+type Span struct {
+	tracer    *Tracer
+	recording bool
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns the ids of the span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+
+	return s.data.SpanContext
+}
+
+// IsRecording reports whether the span will be exported when it ends.
+func (s *Span) IsRecording() bool {
+	return s != nil && s.recording
+}
+
+// SetName replaces the name of the span, e.g. once the route of a request is known.
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Name = name
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// SetStatus sets the status of the span.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Status = Status{Code: code, Message: message}
+}
+
+// RecordError marks the span as failed with the message of err. Nil errors are ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End ends the span and queues it for export. Spans can only be ended once.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.batcher.enqueue(data)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context that carries span as the parent of new spans.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span in ctx, if any.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+
+	return span
+}
+
+// SpanContextFromContext returns the span context of the current span in ctx, which may belong to another
+// service if it was extracted from a request.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc := SpanFromContext(ctx).SpanContext()
+
+	return sc, sc.IsValid()
+}
+
+// ContextWithRemoteSpanContext returns a context whose new spans are children of a span in another service.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return ContextWithSpan(ctx, &Span{tracer: nil, recording: false, data: SpanData{SpanContext: sc}})
+}
+
+// SpanOption configures a span when it starts.
+type SpanOption func(*SpanData)
+
+// WithKind sets the kind of the span. Spans are internal by default.
+func WithKind(kind SpanKind) SpanOption {
+	return func(data *SpanData) {
+		data.Kind = kind
+	}
+}
+
+// WithAttributes sets attributes when the span starts.
+func WithAttributes(attrs ...slog.Attr) SpanOption {
+	return func(data *SpanData) {
+		data.Attributes = append(data.Attributes, attrs...)
+	}
+}
+
+// Tracer starts spans and exports them in batches.
+type Tracer struct {
+	sampleThreshold uint64
+	batcher         *batcher
+}
+
+const (
+	defaultBatchSize    = 512
+	defaultBatchTimeout = 5 * time.Second
+	defaultQueueSize    = 2048
+)
+
+// Config configures a Tracer.
+type Config struct {
+	// Exporter receives ended spans.
+	Exporter Exporter
+	// SampleRatio is the fraction of new traces that are recorded. Traces started by another service follow its
+	// sampling decision. Defaults to 1.
+	SampleRatio float64
+	// BatchSize is the maximum number of spans in an export. Defaults to 512.
+	BatchSize int
+	// BatchTimeout is the longest that a span waits to be exported. Defaults to 5 seconds.
+	BatchTimeout time.Duration
+	// QueueSize is the maximum number of spans waiting to be exported. Spans are dropped when the queue is full.
+	// Defaults to 2048.
+	QueueSize int
+}
+
+// NewTracer creates a tracer that exports spans with config.Exporter. Call Shutdown to export the remaining spans.
+func NewTracer(config Config) *Tracer {
+	if config.SampleRatio <= 0 || config.SampleRatio > 1 {
+		config.SampleRatio = 1
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+
+	if config.BatchTimeout <= 0 {
+		config.BatchTimeout = defaultBatchTimeout
+	}
+
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+
+	threshold := uint64(math.MaxUint64)
+	if config.SampleRatio < 1 {
+		threshold = uint64(config.SampleRatio * math.MaxUint64)
+	}
+
+	return &Tracer{
+		sampleThreshold: threshold,
+		batcher:         newBatcher(config),
+	}
+}
+
+// Start starts a span that is a child of the current span in ctx and returns a context that carries it. End the
+// span when the operation finishes.
+func (t *Tracer) Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:      name,
+			Kind:      SpanKindInternal,
+			StartTime: time.Now(),
+		},
+	}
+
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		span.data.SpanContext = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled, TraceState: parent.TraceState}
+		span.data.ParentSpanID = parent.SpanID
+	} else {
+		span.data.SpanContext.TraceID = newTraceID()
+		span.data.SpanContext.Sampled = t.sample(span.data.SpanContext.TraceID)
+	}
+
+	span.data.SpanContext.SpanID = newSpanID()
+	span.recording = span.data.SpanContext.Sampled
+
+	for _, opt := range opts {
+		opt(&span.data)
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// sample decides whether a new trace is recorded from its id so that the decision is the same in every service.
+func (t *Tracer) sample(traceID TraceID) bool {
+	return binary.BigEndian.Uint64(traceID[8:]) <= t.sampleThreshold
+}
+
+// ForceFlush exports the spans that have ended.
+func (t *Tracer) ForceFlush(ctx context.Context) error {
+	return t.batcher.forceFlush(ctx)
+}
+
+// Shutdown exports the remaining spans and shuts down the exporter. Spans that end afterwards are dropped.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.batcher.shutdown(ctx)
+}
+
+//nolint:gochecknoglobals
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault sets the tracer used by Start. A nil tracer disables tracing.
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Default returns the tracer used by Start, or nil if tracing is disabled.
+func Default() *Tracer {
+	return defaultTracer.Load()
+}
+
+// Start starts a span with the default tracer. When tracing is disabled it returns ctx and a nil span, whose
+// methods do nothing.
+func Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	tracer := Default()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	return tracer.Start(ctx, name, opts...)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+
+	return id
+}
@@ -0,0 +1,160 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/gurch101/gowebutils/pkg/testutils"
+	"github.com/gurch101/gowebutils/pkg/tracing"
+)
+
+func TestStart(t *testing.T) {
+	recorder := testutils.SetupTestTracer(t)
+
+	ctx, parent := tracing.Start(context.Background(), "parent", tracing.WithKind(tracing.SpanKindServer))
+	_, child := tracing.Start(ctx, "child", tracing.WithAttributes(slog.String("key", "value")))
+
+	child.SetAttributes(slog.Int("count", 2))
+	child.RecordError(errors.New("boom"))
+	child.End()
+	child.End()
+
+	parent.SetName("renamed")
+	parent.RecordError(nil)
+	parent.End()
+
+	spans := recorder.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	childData, parentData := spans[0], spans[1]
+
+	if parentData.Name != "renamed" || parentData.Kind != tracing.SpanKindServer {
+		t.Errorf("unexpected parent span %+v", parentData)
+	}
+
+	if parentData.ParentSpanID.IsValid() {
+		t.Errorf("expected a root span, got parent %s", parentData.ParentSpanID)
+	}
+
+	if parentData.Status.Code != tracing.StatusUnset {
+		t.Errorf("expected unset status, got %v", parentData.Status)
+	}
+
+	if childData.SpanContext.TraceID != parentData.SpanContext.TraceID {
+		t.Errorf("expected trace id %s, got %s", parentData.SpanContext.TraceID, childData.SpanContext.TraceID)
+	}
+
+	if childData.ParentSpanID != parentData.SpanContext.SpanID {
+		t.Errorf("expected parent span id %s, got %s", parentData.SpanContext.SpanID, childData.ParentSpanID)
+	}
+
+	if childData.Kind != tracing.SpanKindInternal {
+		t.Errorf("expected internal span, got %v", childData.Kind)
+	}
+
+	if len(childData.Attributes) != 2 || childData.Attributes[0].Key != "key" || childData.Attributes[1].Key != "count" {
+		t.Errorf("unexpected attributes %v", childData.Attributes)
+	}
+
+	if childData.Status.Code != tracing.StatusError || childData.Status.Message != "boom" {
+		t.Errorf("unexpected status %v", childData.Status)
+	}
+
+	if childData.EndTime.Before(childData.StartTime) {
+		t.Errorf("expected end time after start time")
+	}
+}
+
+func TestStartWithoutTracer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	spanCtx, span := tracing.Start(ctx, "span")
+	if span != nil || spanCtx != ctx {
+		t.Fatal("expected no span when tracing is disabled")
+	}
+
+	// the methods of a nil span do nothing
+	span.SetName("name")
+	span.SetAttributes(slog.String("key", "value"))
+	span.RecordError(errors.New("boom"))
+	span.End()
+
+	if span.IsRecording() || span.SpanContext().IsValid() {
+		t.Error("expected a nil span to not record")
+	}
+}
+
+func TestSampling(t *testing.T) {
+	t.Parallel()
+
+	recorder := &testutils.SpanRecorder{}
+	tracer := tracing.NewTracer(tracing.Config{Exporter: recorder, SampleRatio: 0.000001})
+
+	sampled := tracing.SpanContext{TraceID: tracing.TraceID{1}, SpanID: tracing.SpanID{1}, Sampled: true}
+	notSampled := tracing.SpanContext{TraceID: tracing.TraceID{2}, SpanID: tracing.SpanID{2}, Sampled: false}
+
+	_, remoteSampled := tracer.Start(tracing.ContextWithRemoteSpanContext(context.Background(), sampled), "sampled")
+	_, remoteNotSampled := tracer.Start(tracing.ContextWithRemoteSpanContext(context.Background(), notSampled), "not")
+
+	if !remoteSampled.IsRecording() {
+		t.Error("expected the sampling decision of the parent to be followed")
+	}
+
+	if remoteNotSampled.IsRecording() || !remoteNotSampled.SpanContext().IsValid() {
+		t.Error("expected an unsampled span that propagates its ids")
+	}
+
+	recorded := 0
+
+	for range 100 {
+		if _, span := tracer.Start(context.Background(), "root"); span.IsRecording() {
+			recorded++
+		}
+	}
+
+	if recorded > 1 {
+		t.Errorf("expected almost no root spans to be sampled, got %d", recorded)
+	}
+
+	remoteSampled.End()
+	remoteNotSampled.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if spans := recorder.Spans(); len(spans) != 1 || spans[0].Name != "sampled" {
+		t.Errorf("expected only the sampled span to be exported, got %v", spans)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	recorder := &testutils.SpanRecorder{}
+	tracer := tracing.NewTracer(tracing.Config{Exporter: recorder})
+
+	_, span := tracer.Start(context.Background(), "before")
+	span.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, span = tracer.Start(context.Background(), "after")
+	span.End()
+
+	if err := tracer.ForceFlush(context.Background()); !errors.Is(err, tracing.ErrTracerShutdown) {
+		t.Errorf("expected ErrTracerShutdown, got %v", err)
+	}
+
+	if spans := recorder.Spans(); len(spans) != 1 || spans[0].Name != "before" {
+		t.Errorf("expected spans ended before shutdown to be exported, got %v", spans)
+	}
+}
@@ -0,0 +1,14 @@
+package validation
+
+import "github.com/gurch101/gowebutils/pkg/decimal"
+
+// DecimalRange adds an error to the Validator if value is not between minValue and maxValue inclusive.
+func (v *Validator) DecimalRange(value, minValue, maxValue decimal.Decimal, field, message string) {
+	v.CheckCode(!value.LessThan(minValue) && !value.GreaterThan(maxValue), field, CodeOutOfRange, message)
+}
+
+// DecimalScale adds an error to the Validator if value has more than maxScale significant digits
+// after the decimal point. Trailing zeros are ignored, so 1.50 passes a maxScale of 1.
+func (v *Validator) DecimalScale(value decimal.Decimal, maxScale int32, field, message string) {
+	v.CheckCode(value.Round(maxScale, decimal.RoundDown).Equal(value), field, CodeInvalidFormat, message)
+}
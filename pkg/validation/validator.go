@@ -12,14 +12,28 @@ import (
 // The regex pattern used is taken from  https://html.spec.whatwg.org/#valid-e-mail-address.
 var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$") //nolint:lll
 
+// Machine-readable codes attached to validation errors.
+const (
+	CodeInvalid       = "invalid"
+	CodeRequired      = "required"
+	CodeInvalidEmail  = "invalid_email"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidChoice = "invalid_choice"
+	CodeOutOfRange    = "out_of_range"
+	CodeAlreadyExists = "already_exists"
+	CodeNotFound      = "not_found"
+)
+
 // Validator is a simple struct for collecting validation errors.
 type Validator struct {
 	Errors []Error `json:"errors"`
 }
 
 // Error is a simple struct for representing a validation error.
+// Field is empty for errors that don't belong to a single field.
 type Error struct {
-	Field   string `json:"field"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
 }
 
@@ -49,8 +63,13 @@ func NewValidator() *Validator {
 
 // Check adds an error to the Validator if the condition is false.
 func (v *Validator) Check(condition bool, field, message string) {
+	v.CheckCode(condition, field, CodeInvalid, message)
+}
+
+// CheckCode adds an error with the given code to the Validator if the condition is false.
+func (v *Validator) CheckCode(condition bool, field, code, message string) {
 	if !condition {
-		v.Errors = append(v.Errors, Error{Field: field, Message: message})
+		v.AddErrorCode(field, code, message)
 	}
 }
 
@@ -66,15 +85,15 @@ func (v *Validator) ContainsAll(values, list []string, key, message string) {
 
 // Matches returns true if a string value matches a specific regexp pattern.
 func (v *Validator) Matches(value string, rx *regexp.Regexp, field, message string) {
-	v.Check(rx.MatchString(value), field, message)
+	v.CheckCode(rx.MatchString(value), field, CodeInvalidFormat, message)
 }
 
 func (v *Validator) Email(value string, field, message string) {
-	v.Check(EmailRX.MatchString(value), field, message)
+	v.CheckCode(EmailRX.MatchString(value), field, CodeInvalidEmail, message)
 }
 
 func (v *Validator) Required(value, field, message string) {
-	v.Check(value != "", field, message)
+	v.CheckCode(value != "", field, CodeRequired, message)
 }
 
 // In returns true if a specific value is in a list of strings.
@@ -83,15 +102,21 @@ func (v *Validator) In(value string, list []string, key, message string) bool {
 		return true
 	}
 
-	v.AddError(key, message)
+	v.AddErrorCode(key, CodeInvalidChoice, message)
 
 	return false
 }
 
 // AddError adds an error to the Validator.
 func (v *Validator) AddError(field, message string) {
+	v.AddErrorCode(field, CodeInvalid, message)
+}
+
+// AddErrorCode adds an error with a machine-readable code to the Validator.
+func (v *Validator) AddErrorCode(field, code, message string) {
 	newError := Error{
 		Field:   field,
+		Code:    code,
 		Message: message,
 	}
 	v.Errors = append(v.Errors, newError)
@@ -3,6 +3,7 @@ package validation_test
 import (
 	"testing"
 
+	"github.com/gurch101/gowebutils/pkg/decimal"
 	"github.com/gurch101/gowebutils/pkg/validation"
 )
 
@@ -108,3 +109,35 @@ func TestValidatorRequired(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatorDecimal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    string
+		expected bool
+	}{
+		{"in range", "10.5", false},
+		{"trailing zeros", "10.500", false},
+		{"too many decimal places", "10.555", true},
+		{"below min", "-0.01", true},
+		{"above max", "100.01", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			value := decimal.MustParse(tt.value)
+
+			v := validation.NewValidator()
+			v.DecimalRange(value, decimal.NewFromInt(0), decimal.NewFromInt(100), "price", "price must be between 0 and 100")
+			v.DecimalScale(value, 2, "price", "price must have at most 2 decimal places")
+
+			if v.HasErrors() != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, v.HasErrors())
+			}
+		})
+	}
+}